@@ -0,0 +1,63 @@
+package genesis
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/att-cloudnative-labs/template-api/pkg/genesis/template"
+
+	"github.com/spf13/cobra"
+)
+
+var lintStrict bool
+
+// lintCmd reports problems in the templates of a directory before they are published
+var lintCmd = &cobra.Command{
+	Use:   "lint [directory]",
+	Short: "Check the templates in a directory for mistakes.",
+	Long: `Check the templates in a directory for tokens that are not declared as options, options
+that are never used, required options with defaults, groups without a form group, unknown
+filters, unclosed delimiters and paths that collide once rendered.
+The directory defaults to the current directory. The command fails when errors are found,
+or warnings with --strict.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  Lint,
+}
+
+func Lint(cmd *cobra.Command, args []string) {
+	directory := "."
+	if len(args) == 1 {
+		directory = args[0]
+	}
+	directory, err := filepath.Abs(directory)
+	if err != nil {
+		fmt.Printf("error occurred: %+v\n", err)
+		os.Exit(1)
+	}
+
+	issues, err := template.NewGenesisTemplateApi(directory + "/").Lint()
+	if err != nil {
+		fmt.Printf("error occurred: %+v\n", err)
+		os.Exit(1)
+	}
+
+	errorCount, warningCount := 0, 0
+	for _, issue := range issues {
+		fmt.Println(issue)
+		if issue.Severity == template.LINT_ERROR {
+			errorCount++
+		} else {
+			warningCount++
+		}
+	}
+	fmt.Printf("%d errors, %d warnings\n", errorCount, warningCount)
+	if errorCount > 0 || (lintStrict && warningCount > 0) {
+		os.Exit(1)
+	}
+}
+
+func init() {
+	lintCmd.Flags().BoolVar(&lintStrict, "strict", false, "Fail on warnings as well as errors")
+	rootCmd.AddCommand(lintCmd)
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/att-cloudnative-labs/template-api/genesis_config"
 	"github.com/att-cloudnative-labs/template-api/pkg/genesis"
 	"github.com/att-cloudnative-labs/template-api/pkg/genesis/git_client"
+	"github.com/att-cloudnative-labs/template-api/pkg/genesis/template"
 
 	"github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
@@ -39,6 +40,10 @@ var (
 	templateRepoJenkinsUrl      string
 	userID                      string
 	templateRepoCreateWebhook   bool
+	noInput                     bool
+	answersFile                 string
+	targetProvider              string
+	targetRepoUrl               string
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -65,27 +70,113 @@ func Execute() {
 	}
 }
 
+// newTargetRepoConfig returns the configuration of a target repository of the git provider. projectKey is the
+// BitBucket project key, GitHub organization, GitLab group, Gitea organization or BitBucket Cloud workspace[/PROJECT],
+// repoUrl the URL of the repository for the git provider, and the functional domain and project name only apply
+// to BitBucket.
+func newTargetRepoConfig(provider, projectKey, repoSlug, repoUrl, functionalDomain, projectName string) git_client.GitRepoConfig {
+	switch provider {
+	case "github":
+		return &git_client.GithubRepoConfig{Domain: projectKey, RepositoryName: repoSlug}
+	case "gitlab":
+		return &git_client.GitLabRepoConfig{Namespace: projectKey, ProjectPath: repoSlug}
+	case "gitea":
+		return &git_client.GiteaRepoConfig{Owner: projectKey, RepositoryName: repoSlug}
+	case "bitbucket-cloud":
+		// an incomplete configuration is rejected when the target repository is validated
+		targetRepo, _ := git_client.NewBitBucketCloudRepoConfig(projectKey, repoSlug)
+		return targetRepo
+	case "git":
+		return &git_client.PlainGitRepoConfig{Url: repoUrl}
+	}
+	return git_client.NewBitBucketRepoConfig(projectKey, repoSlug, functionalDomain, projectName)
+}
+
 func Orchestrator(cmd *cobra.Command, args []string) {
 	orchestrator := genesis.NewTemplateOrchestrator(genesis_config.AuthConfig)
 
-	targetRepo := git_client.NewBitBucketRepoConfig(targetRepoProjectKey, targetRepoSlug, targetRepoFunctionalDomain, targetRepoProjectName)
+	targetRepo := newTargetRepoConfig(targetProvider, targetRepoProjectKey, targetRepoSlug, targetRepoUrl, targetRepoFunctionalDomain, targetRepoProjectName)
 
-	repoUrl, err := orchestrator.GenerateFromTemplateAndCommit(userID, templateProjectName, templateProjectTemplateName, templateRepoJenkinsUrl, optionsMap, targetRepo, templateRepoCreateWebhook)
+	options, err := collectOptions()
+	if err == nil {
+		options, err = promptMissingOptions(orchestrator, options)
+	}
+	if err != nil {
+		fmt.Printf("error occurred: %+v\n", err)
+		return
+	}
+
+	report, err := orchestrator.GenerateFromTemplateAndCommit(userID, templateProjectName, templateProjectTemplateName, templateRepoJenkinsUrl, options, targetRepo, templateRepoCreateWebhook)
+
+	printHookResults(report.Hooks)
 
 	if err != nil {
 		fmt.Printf("error occurred: %+v\n", err)
 		return
 	}
 
-	fmt.Printf("Repo URL: %s", repoUrl)
+	fmt.Printf("Repo URL: %s", report.RepoUrl)
+}
+
+// collectOptions merges the options of the answers file, the GENESIS_OPT_* environment variables and --options,
+// in increasing order of precedence
+func collectOptions() (template.OptionValues, error) {
+	answers := make(template.OptionValues)
+	if answersFile != "" {
+		var err error
+		answers, err = template.ReadAnswersFile(answersFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return template.MergeOptionValues(answers, template.EnvOptionValues(os.Environ()), template.StringOptionValues(optionsMap)), nil
+}
+
+// promptMissingOptions asks for the options of the template when required options were not provided,
+// unless --no-input is set or the input is not a terminal
+func promptMissingOptions(orchestrator *genesis.TemplateOrchestrator, options template.OptionValues) (template.OptionValues, error) {
+	if noInput || !isTerminal(os.Stdin) {
+		return options, nil
+	}
+	projectTemplate, err := orchestrator.GetTemplate(templateProjectName, templateProjectTemplateName)
+	if err != nil {
+		return nil, err
+	}
+	genesisTemplate, ok := projectTemplate.(*template.GenesisTemplate)
+	if !ok {
+		return options, nil
+	}
+	missing, err := genesisTemplate.MissingOptions(options)
+	if err != nil || len(missing) == 0 {
+		return options, err
+	}
+	return template.NewPrompter(os.Stdin, os.Stdout).PromptOptions(genesisTemplate, options)
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func printHookResults(results []template.HookResult) {
+	for _, result := range results {
+		fmt.Printf("Hook %s: %s (exit code %d, %s)\n", result.Name, result.Status, result.ExitCode, result.Duration)
+		if result.Output != "" {
+			fmt.Println(result.Output)
+		}
+	}
 }
 
 func init() {
 	cobra.OnInitialize(initConfig)
 
-	rootCmd.Flags().StringToStringVar(&optionsMap, "options", map[string]string{}, "Pass in options to create your project.")
+	rootCmd.Flags().StringToStringVar(&optionsMap, "options", map[string]string{}, "Pass in options to create your project. Lists are comma separated or JSON, e.g. tags=[\"a\",\"b\"].")
+
+	rootCmd.Flags().StringVar(&answersFile, "answers", "", "YAML or JSON file with the options to create your project. --options and GENESIS_OPT_<option> environment variables take precedence.")
 
-	rootCmd.Flags().StringVar(&targetRepoProjectKey, "targetProjectKey", "", "Project key for target repository")
+	rootCmd.Flags().StringVar(&targetProvider, "targetProvider", "bitbucket", "Git provider of the target repository, bitbucket, bitbucket-cloud, github, gitlab, gitea or git")
+	rootCmd.Flags().StringVar(&targetRepoUrl, "targetRepoUrl", "", "URL of an existing empty target repository for the git provider")
+	rootCmd.Flags().StringVar(&targetRepoProjectKey, "targetProjectKey", "", "Project key for target repository, GitHub organization, GitLab group, Gitea organization or BitBucket Cloud workspace[/PROJECT]")
 	rootCmd.Flags().StringVar(&targetRepoSlug, "targetRepoSlug", "", "Project slug for target repository")
 	rootCmd.Flags().StringVar(&targetRepoFunctionalDomain, "targetRepoFunctionalDomain", "", "Functional Domain for target repository")
 	rootCmd.Flags().StringVar(&targetRepoProjectName, "targetRepoProjectName", "", "Then name of the target project for target repository")
@@ -95,6 +186,7 @@ func init() {
 	rootCmd.Flags().StringVar(&templateRepoJenkinsUrl, "templateRepoJenkinsUrl", "", "The Jenkins URL for webhook configuration")
 	rootCmd.Flags().BoolVar(&templateRepoCreateWebhook, "templateRepoCreateWebhook", false, "Flag to generate webhook or not")
 	rootCmd.Flags().StringVar(&userID, "userID", "", "The user ID of the person creating a project")
+	rootCmd.Flags().BoolVar(&noInput, "no-input", false, "Never prompt for options that were not provided")
 	// Here you will define your flags and configuration settings.
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
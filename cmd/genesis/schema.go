@@ -0,0 +1,54 @@
+package genesis
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/att-cloudnative-labs/template-api/pkg/genesis/template"
+
+	"github.com/spf13/cobra"
+)
+
+var schemaTemplateName string
+
+// schemaCmd prints the form of a template as a JSON Schema and a UI schema
+var schemaCmd = &cobra.Command{
+	Use:   "schema [directory]",
+	Short: "Print the form of a template as a JSON Schema and a UI schema.",
+	Long: `Print the options, form groups and form fields of a template as a JSON Schema of the
+option values, and a UI schema with the layout, widgets, hints and placeholders of the form.
+The directory defaults to the current directory.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  Schema,
+}
+
+func Schema(cmd *cobra.Command, args []string) {
+	directory := "."
+	if len(args) == 1 {
+		directory = args[0]
+	}
+	directory, err := filepath.Abs(directory)
+	if err != nil {
+		fmt.Printf("error occurred: %+v\n", err)
+		os.Exit(1)
+	}
+
+	project, err := template.NewGenesisTemplateApi(directory + "/").GetProjectFromRepo(schemaTemplateName)
+	if err != nil {
+		fmt.Printf("error occurred: %+v\n", err)
+		os.Exit(1)
+	}
+	formSchema, err := template.MarshalFormSchema(project)
+	if err != nil {
+		fmt.Printf("error occurred: %+v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(string(formSchema))
+}
+
+func init() {
+	schemaCmd.Flags().StringVar(&schemaTemplateName, "template", "", "Name of the template")
+	_ = schemaCmd.MarkFlagRequired("template")
+	rootCmd.AddCommand(schemaCmd)
+}
@@ -0,0 +1,42 @@
+package genesis
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/att-cloudnative-labs/template-api/genesis_config"
+	"github.com/att-cloudnative-labs/template-api/pkg/api"
+	"github.com/att-cloudnative-labs/template-api/pkg/genesis"
+
+	"github.com/spf13/cobra"
+)
+
+// serveCmd serves the template API over HTTP
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the template API.",
+	Long: `Serve the template API on the configured port. GET /api/v1/templates lists the templates, and
+GET /api/v1/templates/form-schema?repository=...&template=... returns the form of a template as a
+JSON Schema and a UI schema.`,
+	Args: cobra.NoArgs,
+	Run:  Serve,
+}
+
+func Serve(cmd *cobra.Command, args []string) {
+	orchestrator := genesis.NewTemplateOrchestrator(genesis_config.AuthConfig)
+
+	port := genesis_config.AuthConfig.Port
+	if port == "" {
+		port = "8080"
+	}
+	fmt.Printf("Serving the template API on port %s\n", port)
+	if err := http.ListenAndServe(":"+port, api.NewRouter(orchestrator)); err != nil {
+		fmt.Printf("error occurred: %+v\n", err)
+		os.Exit(1)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+}
@@ -0,0 +1,68 @@
+package genesis
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/att-cloudnative-labs/template-api/pkg/genesis/template"
+
+	"github.com/spf13/cobra"
+)
+
+var updateGolden bool
+
+// testCmd renders the test cases of a template repository and compares them with their expected trees
+var testCmd = &cobra.Command{
+	Use:   "test [directory]",
+	Short: "Run the golden tests of a template repository.",
+	Long: `Render every case in tests/<case>/case.yml and compare the result with tests/<case>/expected,
+printing a unified diff for each case that does not match. With --update, the expected trees are
+regenerated instead, so that template changes can be reviewed as rendered diffs.
+The directory defaults to the current directory.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  Test,
+}
+
+func Test(cmd *cobra.Command, args []string) {
+	directory := "."
+	if len(args) == 1 {
+		directory = args[0]
+	}
+	directory, err := filepath.Abs(directory)
+	if err != nil {
+		fmt.Printf("error occurred: %+v\n", err)
+		os.Exit(1)
+	}
+
+	results, err := template.NewGenesisTemplateApi(directory + "/").RunGoldenTests(updateGolden)
+	if err != nil {
+		fmt.Printf("error occurred: %+v\n", err)
+		os.Exit(1)
+	}
+
+	failed := 0
+	for _, result := range results {
+		switch {
+		case result.Error != "":
+			fmt.Printf("ERROR   %s: %s\n", result.Case, result.Error)
+			failed++
+		case result.Updated:
+			fmt.Printf("UPDATED %s\n", result.Case)
+		case result.Passed:
+			fmt.Printf("PASS    %s\n", result.Case)
+		default:
+			fmt.Printf("FAIL    %s\n%s", result.Case, result.Diff)
+			failed++
+		}
+	}
+	fmt.Printf("%d cases, %d failed\n", len(results), failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func init() {
+	testCmd.Flags().BoolVar(&updateGolden, "update", false, "Regenerate the expected trees from the rendered output")
+	rootCmd.AddCommand(testCmd)
+}
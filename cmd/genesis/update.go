@@ -0,0 +1,75 @@
+package genesis
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/att-cloudnative-labs/template-api/genesis_config"
+	"github.com/att-cloudnative-labs/template-api/pkg/genesis"
+	"github.com/att-cloudnative-labs/template-api/pkg/genesis/template"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	updateProvider   string
+	updateRepoUrl    string
+	updateProjectKey string
+	updateRepoSlug   string
+	updateRef        string
+	updateBranch     string
+	updateOptions    map[string]string
+)
+
+// updateCmd upgrades a generated repository to a newer version of its template
+var updateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update a generated repository to a newer template version.",
+	Long: `Read the generation manifest (.genesis/answers.yml) of a generated repository, render the
+template version it was generated from and the version given by --ref with the same options,
+and merge the changes between them into the repository. The result is pushed to a new branch for
+review. Files changed both in the repository and in the template contain conflict markers.
+Secret options are not recorded in the manifest and must be passed again with --options.`,
+	Args: cobra.NoArgs,
+	Run:  Update,
+}
+
+func Update(cmd *cobra.Command, args []string) {
+	orchestrator := genesis.NewTemplateOrchestrator(genesis_config.AuthConfig)
+
+	targetRepo := newTargetRepoConfig(updateProvider, updateProjectKey, updateRepoSlug, updateRepoUrl, "", "")
+
+	report, err := orchestrator.UpdateProject(targetRepo, updateRef, updateBranch, template.StringOptionValues(updateOptions))
+
+	printHookResults(report.Hooks)
+
+	if err != nil {
+		fmt.Printf("error occurred: %+v\n", err)
+		os.Exit(1)
+	}
+
+	for _, path := range report.Updated {
+		fmt.Printf("updated  %s\n", path)
+	}
+	for _, path := range report.Added {
+		fmt.Printf("added    %s\n", path)
+	}
+	for _, path := range report.Removed {
+		fmt.Printf("removed  %s\n", path)
+	}
+	for _, path := range report.Conflicts {
+		fmt.Printf("conflict %s\n", path)
+	}
+	fmt.Printf("Pushed branch %s with %d conflicts\n", report.Branch, len(report.Conflicts))
+}
+
+func init() {
+	updateCmd.Flags().StringVar(&updateProvider, "targetProvider", "bitbucket", "Git provider of the generated repository, bitbucket, bitbucket-cloud, github, gitlab, gitea or git")
+	updateCmd.Flags().StringVar(&updateRepoUrl, "targetRepoUrl", "", "URL of the generated repository for the git provider")
+	updateCmd.Flags().StringVar(&updateProjectKey, "targetProjectKey", "", "Project key of the generated repository, GitHub organization, GitLab group, Gitea organization or BitBucket Cloud workspace")
+	updateCmd.Flags().StringVar(&updateRepoSlug, "targetRepoSlug", "", "Slug of the generated repository")
+	updateCmd.Flags().StringVar(&updateRef, "ref", "", "Tag or branch of the template to update to (default branch when empty)")
+	updateCmd.Flags().StringVar(&updateBranch, "branch", "", "Branch to push the update to (default genesis-update-<ref>)")
+	updateCmd.Flags().StringToStringVar(&updateOptions, "options", map[string]string{}, "Options to change, and secret options, for the updated project.")
+	rootCmd.AddCommand(updateCmd)
+}
@@ -0,0 +1,93 @@
+package genesis
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/att-cloudnative-labs/template-api/pkg/genesis/template"
+
+	"github.com/spf13/cobra"
+)
+
+var printSchema bool
+
+// validateCmd checks a .genesis.yml file against the genesis schema
+var validateCmd = &cobra.Command{
+	Use:   "validate [directory or .genesis.yml]",
+	Short: "Validate a .genesis.yml file against the genesis schema.",
+	Long: `Validate a .genesis.yml file against the genesis schema, reporting unknown fields
+and invalid values with their line and column. Every .genesis.yml in a directory is validated,
+including those in subdirectories. The directory defaults to the current directory.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  Validate,
+}
+
+func Validate(cmd *cobra.Command, args []string) {
+	if printSchema {
+		schema, err := template.MarshalGenesisProjectSchema()
+		if err != nil {
+			fmt.Printf("error occurred: %+v\n", err)
+			os.Exit(1)
+		}
+		fmt.Print(string(schema))
+		return
+	}
+
+	path := "."
+	if len(args) == 1 {
+		path = args[0]
+	}
+	paths := []string{path}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		descriptors, err := template.FindGenesisFiles(path)
+		if err != nil {
+			fmt.Printf("error occurred: %+v\n", err)
+			os.Exit(1)
+		}
+		if len(descriptors) == 0 {
+			fmt.Printf("error occurred: no .genesis.yml found in %s\n", path)
+			os.Exit(1)
+		}
+		paths = make([]string, len(descriptors))
+		for i, descriptor := range descriptors {
+			paths[i] = filepath.Join(path, descriptor)
+		}
+	}
+
+	valid := true
+	for _, path := range paths {
+		valid = validateFile(path) && valid
+	}
+	if !valid {
+		os.Exit(1)
+	}
+}
+
+// validateFile prints the problems found in a .genesis.yml file, and returns true when there are none
+func validateFile(path string) bool {
+	file, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Printf("error occurred: %+v\n", err)
+		return false
+	}
+	validationErrors, err := template.ValidateGenesisFile(file)
+	if err != nil {
+		fmt.Printf("%s: %s\n", path, err)
+		return false
+	}
+	for _, validationError := range validationErrors {
+		fmt.Printf("%s:%d:%d: %s: %s\n", path, validationError.Line, validationError.Column, validationError.Path, validationError.Message)
+	}
+	if len(validationErrors) > 0 {
+		return false
+	}
+	fmt.Printf("%s is valid\n", path)
+	return true
+}
+
+func init() {
+	validateCmd.Flags().BoolVar(&printSchema, "print-schema", false, "Print the JSON Schema of .genesis.yml files instead of validating")
+	rootCmd.AddCommand(validateCmd)
+}
@@ -14,18 +14,18 @@ import (
 type customProject struct {
 	TemplateSubFolder string
 	TemplateName      string
-	Options           map[string]string
+	Options           template.OptionValues
 }
 
 func (d customProject) GetRequiredOptions() []template.Option {
 	return []template.Option{}
 }
 
-func (d customProject) SetValidatedOptions(args map[string]string) error {
+func (d customProject) SetValidatedOptions(args template.OptionValues) error {
 	return nil
 }
 
-func (d customProject) GetValidatedOptions() (map[string]string, error) {
+func (d customProject) GetValidatedOptions() (template.OptionValues, error) {
 	return d.Options, nil
 }
 
@@ -41,6 +41,26 @@ func (d customProject) OrganizeGroups() error {
 	return nil
 }
 
+func (d customProject) GetHooks() template.GenesisHooks {
+	return template.GenesisHooks{}
+}
+
+func (d customProject) GetLayers() []template.Layer {
+	return nil
+}
+
+func (d customProject) GetPartialDirectories() []string {
+	return nil
+}
+
+func (d customProject) GetGitRepository() template.GenesisGitRepository {
+	return template.GenesisGitRepository{}
+}
+
+func (d customProject) GetOptions() []template.Option {
+	return []template.Option{}
+}
+
 // terminateOnError If err is not nil, it prints message an exits with code 1
 func terminateOnError(message string, err error) {
 	if err != nil {
@@ -58,7 +78,7 @@ func copyFile(source string, destination string) error {
 	return ioutil.WriteFile(destination, content, 0644)
 }
 
-// copyProject Copies the .genesis.yml file and all the base folder to target
+// copyProject Copies the .genesis.yml file, the base folder and the partials folder to target
 func copyProject(target string) error {
 	sep := string(os.PathSeparator)
 	files := []string{".genesis.yml"}
@@ -68,7 +88,20 @@ func copyProject(target string) error {
 			return err
 		}
 	}
-	templateFolder := "base"
+	err := copyFolder(target, "base")
+	if err != nil {
+		return err
+	}
+	// partials are optional
+	if _, err := os.Stat("partials"); err == nil {
+		return copyFolder(target, "partials")
+	}
+	return nil
+}
+
+// copyFolder Copies templateFolder from the current directory to target
+func copyFolder(target string, templateFolder string) error {
+	sep := string(os.PathSeparator)
 	err := os.Mkdir(fmt.Sprintf("%s%s%s", target, sep, templateFolder), 0700)
 	if err != nil {
 		return err
@@ -3,14 +3,15 @@ package main
 import (
 	"io/ioutil"
 
+	"github.com/att-cloudnative-labs/template-api/pkg/genesis/template"
 	"gopkg.in/yaml.v2"
 )
 
 // Settings schema for configuration files (i.e. options.test.yaml)
 type Settings struct {
-	Source           string            `yaml:"source"`
-	TemplateName     string            `yaml:"template_name"`
-	ConfigurationMap map[string]string `yaml:"settings"`
+	Source           string                `yaml:"source"`
+	TemplateName     string                `yaml:"template_name"`
+	ConfigurationMap template.OptionValues `yaml:"settings"`
 }
 
 // getOptionsFrom Returns a map of values from a yaml file
@@ -21,5 +22,7 @@ func getOptionsFrom(settingsFile string) (Settings, error) {
 	}
 	var options Settings
 	err = yaml.Unmarshal(content, &options)
+	// nested settings are decoded as map[interface{}]interface{}
+	options.ConfigurationMap = template.NormalizeOptionValues(options.ConfigurationMap)
 	return options, err
 }
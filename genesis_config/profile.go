@@ -17,16 +17,99 @@ type AppConfig struct {
 	GitHubUser           string `mapstructure:"github_user"`
 	GitHubPassword       string `mapstructure:"github_password"`
 	GitHubToken          string `mapstructure:"github_token"`
+	// GitHubHost is github.com, or the host of a GitHub Enterprise Server. The web, API and clone URLs are
+	// derived from it unless they are set.
+	GitHubHost     string `mapstructure:"github_host"`
+	GitHubWebUrl   string `mapstructure:"github_web_url"`
+	GitHubApiUrl   string `mapstructure:"github_api_url"`
+	GitHubCloneUrl string `mapstructure:"github_clone_url"`
+	// additional GitHub instances, referenced by the host of template repositories
+	GitHubHosts []GitHubHost `mapstructure:"github_hosts"`
+	// GitLabHost is gitlab.com, or the host of a self-managed instance with its API at /api/v4 unless gitlab_api_url is set
+	GitLabHost   string `mapstructure:"gitlab_host"`
+	GitLabApiUrl string `mapstructure:"gitlab_api_url"`
+	GitLabUser   string `mapstructure:"gitlab_user"`
+	GitLabToken  string `mapstructure:"gitlab_token"`
+	// GiteaHost is the host of a Gitea or Forgejo instance, optionally with its scheme, e.g. http://localhost:3000.
+	// Its API is at /api/v1 unless gitea_api_url is set.
+	GiteaHost     string `mapstructure:"gitea_host"`
+	GiteaApiUrl   string `mapstructure:"gitea_api_url"`
+	GiteaUser     string `mapstructure:"gitea_user"`
+	GiteaPassword string `mapstructure:"gitea_password"`
+	GiteaToken    string `mapstructure:"gitea_token"`
+	// BitBucket Cloud authenticates with the app password of the user, or with an OAuth or workspace access token
+	BitBucketCloudUser        string `mapstructure:"bitbucket_cloud_user"`
+	BitBucketCloudAppPassword string `mapstructure:"bitbucket_cloud_app_password"`
+	BitBucketCloudToken       string `mapstructure:"bitbucket_cloud_token"`
+	// credentials of repositories addressed by a plain git URL, used for https:// and ssh:// URLs
+	GitUser           string `mapstructure:"git_user"`
+	GitPassword       string `mapstructure:"git_password"`
+	GitSshKeyPath     string `mapstructure:"git_ssh_key_path"`
+	GitSshKeyPassword string `mapstructure:"git_ssh_key_password"`
+	GitUserEmail      string `mapstructure:"git_user_email"`
 	// TODO - reconfigure to enable override with environment variables
-	GitHubTemplateRepositories    []GitHubTemplateRepository    `mapstructure:"github_template_repositories"`
-	BitBucketTemplateRepositories []BitBucketTemplateRepository `mapstructure:"bitbucket_template_repositories"`
-	Port                          string                        `mapstructure:"port"`
+	GitHubTemplateRepositories         []GitHubTemplateRepository         `mapstructure:"github_template_repositories"`
+	BitBucketTemplateRepositories      []BitBucketTemplateRepository      `mapstructure:"bitbucket_template_repositories"`
+	GitLabTemplateRepositories         []GitLabTemplateRepository         `mapstructure:"gitlab_template_repositories"`
+	GiteaTemplateRepositories          []GiteaTemplateRepository          `mapstructure:"gitea_template_repositories"`
+	BitBucketCloudTemplateRepositories []BitBucketCloudTemplateRepository `mapstructure:"bitbucket_cloud_template_repositories"`
+	GitTemplateRepositories            []GitTemplateRepository            `mapstructure:"git_template_repositories"`
+	LocalTemplateRepositories          []LocalTemplateRepository          `mapstructure:"local_template_repositories"`
+	Port                               string                             `mapstructure:"port"`
+	// template hooks run arbitrary commands on the server, so they are disabled unless allowed
+	HooksEnabled     bool     `mapstructure:"hooks_enabled"`
+	HookEnvAllowList []string `mapstructure:"hook_env_allowlist"`
+	HookTimeout      int      `mapstructure:"hook_timeout"`
+	// seconds for which options resolved from an optionsUrl are cached
+	OptionsCacheTime int `mapstructure:"options_cache_time"`
 }
 
 type GitHubTemplateRepository struct {
 	Name     string `mapstructure:"name"`
 	Domain   string `mapstructure:"domain"`
 	RepoName string `mapstructure:"repo_name"`
+	// Host of one of the github_hosts, empty for github_host
+	Host string `mapstructure:"host"`
+}
+
+type GitHubHost struct {
+	Host     string `mapstructure:"host"`
+	WebUrl   string `mapstructure:"web_url"`
+	ApiUrl   string `mapstructure:"api_url"`
+	CloneUrl string `mapstructure:"clone_url"`
+	User     string `mapstructure:"user"`
+	Password string `mapstructure:"password"`
+	Token    string `mapstructure:"token"`
+}
+
+type GitLabTemplateRepository struct {
+	Name        string `mapstructure:"name"`
+	Namespace   string `mapstructure:"namespace"`
+	ProjectPath string `mapstructure:"project_path"`
+}
+
+type GiteaTemplateRepository struct {
+	Name     string `mapstructure:"name"`
+	Owner    string `mapstructure:"owner"`
+	RepoName string `mapstructure:"repo_name"`
+}
+
+type BitBucketCloudTemplateRepository struct {
+	Name           string `mapstructure:"name"`
+	Workspace      string `mapstructure:"workspace"`
+	RepositorySlug string `mapstructure:"repository_slug"`
+}
+
+type GitTemplateRepository struct {
+	Name string `mapstructure:"name"`
+	// Url of the repository, such as https://, ssh:// or file:// URLs, or the path of a local repository
+	Url string `mapstructure:"url"`
+}
+
+type LocalTemplateRepository struct {
+	Name string `mapstructure:"name"`
+	// Path of a template directory, or of a .tar, .tar.gz or .tgz tarball of one
+	Path string `mapstructure:"path"`
 }
 
 type BitBucketTemplateRepository struct {
@@ -55,6 +138,12 @@ func InitConfig() {
 	}
 
 	v.SetDefault("bitbucket_timeout", 3)
+	v.SetDefault("github_host", "github.com")
+	v.SetDefault("gitlab_host", "gitlab.com")
+	v.SetDefault("hooks_enabled", false)
+	v.SetDefault("hook_env_allowlist", []string{"PATH", "HOME"})
+	v.SetDefault("hook_timeout", 300)
+	v.SetDefault("options_cache_time", 300)
 
 	err = v.Unmarshal(&AuthConfig)
 	if err != nil {
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/att-cloudnative-labs/template-api/pkg/genesis"
+	"github.com/att-cloudnative-labs/template-api/pkg/genesis/template"
+)
+
+// TemplateService is the part of the orchestrator served by the API
+type TemplateService interface {
+	GetTemplateNames() ([]genesis.TemplateName, error)
+	GetTemplate(projectName, templateName string) (template.ProjectTemplate, error)
+}
+
+// NewRouter returns the routes of the API:
+//
+//	GET /api/v1/templates lists the template repositories and their templates
+//	GET /api/v1/templates/form-schema?repository=...&template=... returns the JSON Schema and UI schema of a template form
+func NewRouter(service TemplateService) http.Handler {
+	router := http.NewServeMux()
+	router.HandleFunc("/api/v1/templates", func(w http.ResponseWriter, r *http.Request) {
+		if !allowMethod(w, r, http.MethodGet) {
+			return
+		}
+		templateNames, err := service.GetTemplateNames()
+		if err != nil {
+			writeJson(w, http.StatusInternalServerError, NewErrorResponseJson(http.StatusInternalServerError, err.Error()))
+			return
+		}
+		writeJson(w, http.StatusOK, NewSuccessResponseJson(http.StatusOK, templateNames))
+	})
+	router.HandleFunc("/api/v1/templates/form-schema", func(w http.ResponseWriter, r *http.Request) {
+		if !allowMethod(w, r, http.MethodGet) {
+			return
+		}
+		repository, templateName := r.URL.Query().Get("repository"), r.URL.Query().Get("template")
+		if repository == "" || templateName == "" {
+			writeJson(w, http.StatusBadRequest, NewErrorResponseJson(http.StatusBadRequest, "repository and template are required"))
+			return
+		}
+		projectTemplate, err := service.GetTemplate(repository, templateName)
+		if err != nil {
+			writeJson(w, http.StatusNotFound, NewErrorResponseJson(http.StatusNotFound, err.Error()))
+			return
+		}
+		genesisTemplate, ok := projectTemplate.(*template.GenesisTemplate)
+		if !ok {
+			writeJson(w, http.StatusNotFound, NewErrorResponseJson(http.StatusNotFound, fmt.Sprintf("template %s does not describe a form", templateName)))
+			return
+		}
+		formSchema, err := genesisTemplate.GetFormSchema()
+		if err != nil {
+			writeJson(w, http.StatusUnprocessableEntity, NewErrorResponseJson(http.StatusUnprocessableEntity, err.Error()))
+			return
+		}
+		writeJson(w, http.StatusOK, NewSuccessResponseJson(http.StatusOK, formSchema))
+	})
+	return router
+}
+
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeJson(w, http.StatusMethodNotAllowed, NewErrorResponseJson(http.StatusMethodNotAllowed, "method not allowed"))
+	return false
+}
+
+func writeJson(w http.ResponseWriter, code int, payload []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if _, err := w.Write(payload); err != nil {
+		fmt.Printf("something happened while writing the response: %+v\n", err)
+	}
+}
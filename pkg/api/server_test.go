@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/att-cloudnative-labs/template-api/pkg/genesis"
+	"github.com/att-cloudnative-labs/template-api/pkg/genesis/template"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeTemplateService map[string]*template.GenesisTemplate
+
+func (s fakeTemplateService) GetTemplateNames() ([]genesis.TemplateName, error) {
+	return []genesis.TemplateName{{Name: "Services", ProjectNames: []string{"Go Service"}}}, nil
+}
+
+func (s fakeTemplateService) GetTemplate(projectName, templateName string) (template.ProjectTemplate, error) {
+	if projectTemplate, ok := s[projectName+"/"+templateName]; ok {
+		return projectTemplate, nil
+	}
+	return nil, errors.Errorf("unable to find project template with name %s", templateName)
+}
+
+func TestNewRouter_FormSchema(t *testing.T) {
+	service := fakeTemplateService{"Services/Go Service": {
+		Name: "Go Service",
+		Options: []template.Option{
+			{Name: "name", Required: true, GroupName: "Main", FormField: template.FormField{Type: template.TEXT, Label: "Name", Placeholder: "orders"}},
+		},
+		FormGroups: []template.FormGroup{{DisplayName: "Main"}},
+	}}
+	router := NewRouter(service)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("GET", "/api/v1/templates/form-schema?repository=Services&template=Go+Service", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var response struct {
+		Code    int `json:"code"`
+		Payload struct {
+			Schema   template.JSONSchema    `json:"schema"`
+			UISchema map[string]interface{} `json:"uiSchema"`
+		} `json:"payload"`
+	}
+	assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, []string{"name"}, response.Payload.Schema.Required)
+	assert.Equal(t, "Name", response.Payload.Schema.Properties["name"].Title)
+	assert.Equal(t, map[string]interface{}{"ui:placeholder": "orders"}, response.Payload.UISchema["name"])
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("GET", "/api/v1/templates/form-schema?repository=Services&template=Missing", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("GET", "/api/v1/templates/form-schema?repository=Missing&template=Go+Service", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("GET", "/api/v1/templates/form-schema", nil))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("POST", "/api/v1/templates", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+}
@@ -17,9 +17,11 @@ type GenesisTemplate struct {
 	Options  []Option `json:"options"`
 }
 
+// Value holds a string, bool, number, list or object, and is parsed into the type
+// declared by the matching template option during validation
 type Option struct {
-	Name  string `json:"name"`
-	Value string `json:"value"`
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
 }
 
 type GenesisPayload struct {
@@ -32,6 +34,15 @@ type GenesisPayload struct {
 	TargetRepo    BitBucketRepo `json:"targetRepo"`
 }
 
+// GetOptionValues returns the payload options keyed by option name
+func (payload GenesisPayload) GetOptionValues() map[string]interface{} {
+	values := make(map[string]interface{}, len(payload.Options))
+	for _, option := range payload.Options {
+		values[option.Name] = option.Value
+	}
+	return values
+}
+
 type BitBucketRepo struct {
 	ProjectKey       string `json:"projectKey"`
 	ProjectDomain    string `json:"projectDomain"`
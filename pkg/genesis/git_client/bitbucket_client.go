@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"github.com/pkg/errors"
 	"gopkg.in/src-d/go-git.v4"
-	"gopkg.in/src-d/go-git.v4/config"
 	"gopkg.in/src-d/go-git.v4/plumbing"
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
 	gitHttp "gopkg.in/src-d/go-git.v4/plumbing/transport/http"
@@ -167,6 +166,11 @@ func (gitClient *BitBucketClient) ListAllReposForProjectKey(projectKey string) (
 	return bitBucketResponse.GetRepositoryNames(), nil
 }
 
+func (gitClient *BitBucketClient) ListBranches(gitRepoConfig GitRepoConfig) ([]string, error) {
+	auth := &gitHttp.BasicAuth{Username: gitClient.Config.Username, Password: gitClient.Config.Password}
+	return listRemoteBranches(gitClient.CreateScmRepoUrl(gitRepoConfig), auth)
+}
+
 func (gitClient *BitBucketClient) CreateWebhook(url string, gitConfig GitRepoConfig) error {
 	webhookUrl := "https://" + gitClient.Config.GitHost + "/rest/webhook/1.0/projects/" + gitConfig.GetRepoDomain() + "/repos/" + gitConfig.GetRepoName() + "/configurations"
 	payload := BitBucketWebHookRequest{
@@ -278,71 +282,22 @@ func (gitClient *BitBucketClient) InitRepo(gitRepoConfig GitRepoConfig) (directo
 }
 
 func (gitClient *BitBucketClient) InitialCommitProjectToRepo(baseDirectory string, gitRepoConfig GitRepoConfig) error {
-	// check if directory exists
-	if _, err := os.Stat(baseDirectory); os.IsNotExist(err) {
-		// baseDirectory does not exist
-		return errors.Wrapf(err, "Base directory %s does not exist", baseDirectory)
-	}
-
-	// if directory is not empty, run git init
-	// git init
-	repository, err := git.PlainInit(baseDirectory, false)
-	if err != nil {
-		return errors.Wrapf(err, "something happened while running `git init` in %s", baseDirectory)
-	}
-
-	// add new remote
-	// git remote add {Name} {URL}
-	remote, err := repository.CreateRemote(&config.RemoteConfig{
-		Name: gitRepoConfig.GetRepoName(),
-		URLs: []string{gitClient.CreateScmRepoUrl(gitRepoConfig)},
-	})
-
-	if err != nil {
-		return errors.Wrapf(err, "something happened while creating remote repository %s", gitRepoConfig.GetRepoName())
-	}
-
-	// get the worktree from the repository
-	worktree, err := repository.Worktree()
-	if err != nil {
-		return errors.Wrapf(err, "something happened while getting the worktree from the repository object")
-	}
-
-	var commitMessage = "Initial Commit by Genesis API"
-
-	// recursively add files to commit
-	// git add
-	err = addFilesToGit(baseDirectory, worktree)
-
-	if err != nil {
-		return err
-	}
-
-	// git commit
-	commit, err := worktree.Commit(commitMessage, &git.CommitOptions{
-		Author: &object.Signature{
-			Name:  "Genesis API",
-			Email: gitClient.Config.Email,
-			When:  time.Now(),
-		},
-	})
+	author := object.Signature{Name: "Genesis API", Email: gitClient.Config.Email}
+	auth := &gitHttp.BasicAuth{Username: gitClient.Config.Username, Password: gitClient.Config.Password}
+	err := initialCommitAndPush(baseDirectory, gitClient.CreateScmRepoUrl(gitRepoConfig), "Initial Commit by Genesis API", author, auth)
 	if err != nil {
-		return errors.Wrapf(err, "something happened while running `git commit` for project")
-	}
-	_, err = repository.CommitObject(commit)
-	if err != nil {
-		return errors.Wrapf(err, "something happened while running `git commit` for project")
+		return errors.Wrapf(err, "unable to push project %s", gitRepoConfig.GetRepoName())
 	}
+	return nil
+}
 
-	// git push
-	err = repository.Push(&git.PushOptions{
-		RemoteName: remote.Config().Name,
-		Auth:       &gitHttp.BasicAuth{Username: gitClient.Config.Username, Password: gitClient.Config.Password},
-	})
+func (gitClient *BitBucketClient) CommitAndPushBranch(directoryPath, branchName, message string, gitRepoConfig GitRepoConfig) error {
+	author := object.Signature{Name: "Genesis API", Email: gitClient.Config.Email}
+	auth := &gitHttp.BasicAuth{Username: gitClient.Config.Username, Password: gitClient.Config.Password}
+	err := commitAndPushBranch(directoryPath, branchName, message, author, auth)
 	if err != nil {
-		return errors.Wrapf(err, "something happened while running `git push` for project %s", gitRepoConfig.GetRepoName())
+		return errors.Wrapf(err, "unable to push branch %s to repository %s", branchName, gitRepoConfig.GetRepoName())
 	}
-
 	return nil
 }
 
@@ -510,6 +465,9 @@ func getTempDirectoryAndRepoUrl(gitClient *BitBucketClient, gitRepoConfig GitRep
 
 func verifyRepo(repo *git.Repository, err error) error {
 
+	if isReferenceNotExist(err) {
+		return errors.Wrapf(ErrReferenceNotExist, "%v", err)
+	}
 	if err != nil {
 		return errors.Wrapf(err, "something happened while cloning repo")
 	}
@@ -524,29 +482,29 @@ func verifyRepo(repo *git.Repository, err error) error {
 }
 
 func addFilesToGit(directoryPath string, worktree *git.Worktree) error {
-	files, err := ioutil.ReadDir(directoryPath)
-
-	if err != nil {
-		return errors.Wrapf(err, "something happened while reading the directory path %s", directoryPath)
-	}
-
-	for _, file := range files {
-		err = filepath.Walk(directoryPath+"/"+file.Name(), func(path string, f os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-			if f.Name() != ".git" {
-				// do work
-				_, err = worktree.Add(f.Name())
-				if err != nil {
-					return errors.Wrapf(err, "something happened while adding file %s to worktree", f.Name())
-				}
+	err := filepath.Walk(directoryPath, func(path string, f os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if f.IsDir() {
+			if f.Name() == ".git" {
+				return filepath.SkipDir
 			}
 			return nil
-		})
+		}
+		// the worktree expects paths relative to its root, including files in sub directories
+		rel, err := filepath.Rel(directoryPath, path)
 		if err != nil {
-			fmt.Printf("Error encountered but not fatal. Error: %s\n", err)
+			return err
 		}
+		_, err = worktree.Add(filepath.ToSlash(rel))
+		if err != nil {
+			fmt.Printf("Error encountered but not fatal. Error: %s\n", errors.Wrapf(err, "something happened while adding file %s to worktree", rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "something happened while reading the directory path %s", directoryPath)
 	}
 	return nil
 }
@@ -0,0 +1,281 @@
+package git_client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/pkg/errors"
+	gitHttp "gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// REST API URL: https://api.bitbucket.org/2.0/repositories/{WORKSPACE}/{REPO_SLUG}
+// clone URL: https://bitbucket.org/{WORKSPACE}/{REPO_SLUG}.git
+// repo URL: https://bitbucket.org/{WORKSPACE}/{REPO_SLUG}
+type BitBucketCloudRepoConfig struct {
+	Workspace string
+	// ProjectKey of the project in the workspace that new repositories are added to, optional
+	ProjectKey     string
+	RepositorySlug string
+	Tags           []string
+}
+
+// NewBitBucketCloudRepoConfig returns the configuration of a repository, where domain is a workspace, or a workspace
+// and the key of one of its projects, e.g. acme/PLAT
+func NewBitBucketCloudRepoConfig(domain, repositorySlug string) (*BitBucketCloudRepoConfig, error) {
+	workspace, projectKey := splitBitBucketCloudDomain(domain)
+	if workspace == "" || repositorySlug == "" {
+		return &BitBucketCloudRepoConfig{}, errors.New("workspace and repositorySlug are required")
+	}
+	return &BitBucketCloudRepoConfig{
+		Workspace:      workspace,
+		ProjectKey:     projectKey,
+		RepositorySlug: repositorySlug,
+	}, nil
+}
+
+// splitBitBucketCloudDomain splits workspace/PROJECT_KEY into the workspace and the project key
+func splitBitBucketCloudDomain(domain string) (workspace, projectKey string) {
+	parts := strings.SplitN(strings.Trim(domain, "/"), "/", 2)
+	if len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+	return parts[0], ""
+}
+
+func (b *BitBucketCloudRepoConfig) GetRepoDomain() string {
+	return b.Workspace
+}
+
+// ConstructRestApiUrl returns the REST API URL of the repository, where base is the URL of the API,
+// e.g. https://api.bitbucket.org/2.0
+func (b *BitBucketCloudRepoConfig) ConstructRestApiUrl(base string) string {
+	return strings.TrimSuffix(base, "/") + "/repositories/" + url.PathEscape(b.Workspace) + "/" + url.PathEscape(b.RepositorySlug)
+}
+
+// ConstructRepoUrl returns the URL of the repository in a browser, where base is https://bitbucket.org
+func (b *BitBucketCloudRepoConfig) ConstructRepoUrl(base string) string {
+	if base == "" {
+		return ""
+	}
+	return strings.TrimSuffix(base, "/") + "/" + b.Workspace + "/" + b.RepositorySlug
+}
+
+func (b *BitBucketCloudRepoConfig) GetRepoName() string {
+	return b.RepositorySlug
+}
+
+func (b *BitBucketCloudRepoConfig) SetRepoName(name string) {
+	b.RepositorySlug = name
+}
+
+func (b *BitBucketCloudRepoConfig) Validate() bool {
+	return b.Workspace != "" && b.RepositorySlug != ""
+}
+
+type BitBucketCloudClientConfig struct {
+	Username string `yaml:"username" json:"username"`
+	// AppPassword authenticates Username. AccessToken is an OAuth access token, or a workspace or repository
+	// access token, used instead of the app password when it is set.
+	AppPassword string `yaml:"app_password" json:"app_password"`
+	AccessToken string `yaml:"access_token" json:"access_token"`
+	// WebBaseUrl, ApiBaseUrl and CloneBaseUrl default to https://bitbucket.org, https://api.bitbucket.org/2.0 and
+	// https://bitbucket.org
+	RestBaseUrls `yaml:",inline"`
+}
+
+func NewBitBucketCloudClientConfig(username, appPassword, accessToken string) (BitBucketCloudClientConfig, error) {
+	if accessToken == "" && (username == "" || appPassword == "") {
+		return BitBucketCloudClientConfig{}, errors.New("either accessToken, or username and appPassword must not be empty")
+	}
+
+	return BitBucketCloudClientConfig{
+		Username:    username,
+		AppPassword: appPassword,
+		AccessToken: accessToken,
+		RestBaseUrls: RestBaseUrls{
+			WebBaseUrl:   "https://bitbucket.org",
+			ApiBaseUrl:   "https://api.bitbucket.org/2.0",
+			CloneBaseUrl: "https://bitbucket.org",
+		},
+	}, nil
+}
+
+// apiHeaders authenticate with the access token or else the app password
+func (config *BitBucketCloudClientConfig) apiHeaders() map[string]string {
+	authorization := "Bearer " + config.AccessToken
+	if config.AccessToken == "" {
+		authorization = "Basic " + base64.StdEncoding.EncodeToString([]byte(config.Username+":"+config.AppPassword))
+	}
+	return map[string]string{"Authorization": authorization}
+}
+
+// gitAuth returns the credentials used over HTTPS, where access tokens authenticate as x-token-auth
+func (config *BitBucketCloudClientConfig) gitAuth() *gitHttp.BasicAuth {
+	if config.AccessToken != "" {
+		return &gitHttp.BasicAuth{Username: "x-token-auth", Password: config.AccessToken}
+	}
+	return &gitHttp.BasicAuth{Username: config.Username, Password: config.AppPassword}
+}
+
+func (config *BitBucketCloudClientConfig) authorEmail() string {
+	return config.Username
+}
+
+// BitBucket Cloud implementation of the GitClient interface, for bitbucket.org. BitBucketClient serves
+// BitBucket Server and Data Center.
+type BitBucketCloudClient struct {
+	Config *BitBucketCloudClientConfig
+	restGitClient
+}
+
+func NewBitBucketCloudClient(config *BitBucketCloudClientConfig) BitBucketCloudClient {
+	return BitBucketCloudClient{
+		Config:        config,
+		restGitClient: newRestGitClient("BitBucket Cloud", config),
+	}
+}
+
+// ListAllReposForProjectKey lists the repositories of a workspace, or of one of its projects when projectKey is
+// workspace/PROJECT_KEY
+func (client *BitBucketCloudClient) ListAllReposForProjectKey(projectKey string) ([]string, error) {
+	workspace, key := splitBitBucketCloudDomain(projectKey)
+	reposUrl := client.apiUrl("/repositories/" + url.PathEscape(workspace) + "?pagelen=100")
+	if key != "" {
+		reposUrl += "&q=" + url.QueryEscape(`project.key="`+key+`"`)
+	}
+
+	var repositories []BitBucketCloudRepoResponseItem
+	err := client.getAllValues(reposUrl, &repositories)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Problem retrieving the repositories of %s from the BitBucket Cloud REST API", projectKey)
+	}
+
+	repoNames := make([]string, len(repositories))
+	for i, repository := range repositories {
+		repoNames[i] = repository.Slug
+	}
+	return repoNames, nil
+}
+
+func (client *BitBucketCloudClient) ListBranches(gitRepoConfig GitRepoConfig) ([]string, error) {
+	var branches []BitBucketCloudBranchResponseItem
+	err := client.getAllValues(gitRepoConfig.ConstructRestApiUrl(client.Config.ApiBaseUrl)+"/refs/branches?pagelen=100", &branches)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Problem retrieving the branches of %s from the BitBucket Cloud REST API", gitRepoConfig.GetRepoName())
+	}
+
+	branchNames := make([]string, len(branches))
+	for i, branch := range branches {
+		branchNames[i] = branch.Name
+	}
+	return branchNames, nil
+}
+
+// GetFileFromRepo downloads a file from the main branch of the repository into a temporary directory
+func (client *BitBucketCloudClient) GetFileFromRepo(filename string, gitRepoConfig GitRepoConfig) (file *os.File, err error) {
+	srcUrl := gitRepoConfig.ConstructRestApiUrl(client.Config.ApiBaseUrl) + "/src/HEAD/" + strings.TrimPrefix(filename, "/")
+	request, err := client.api().newRequest("GET", srcUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.api().downloadFile(request, filename)
+}
+
+// CreateNewRemoteRepo creates a new empty private repository in the workspace of the GitRepoConfig and returns
+// the URL of the repository. The repository is added to the project of the GitRepoConfig, which is created when
+// it does not exist yet, or else to the default project of the workspace.
+func (client *BitBucketCloudClient) CreateNewRemoteRepo(gitRepoConfig GitRepoConfig) (fullRepoUrl string, err error) {
+	exists, err := client.RepoExists(gitRepoConfig)
+	if err != nil {
+		return "", err
+	}
+
+	if exists {
+		return "", errors.Errorf("the repository already exists at %s", gitRepoConfig.ConstructRepoUrl(client.Config.WebBaseUrl))
+	}
+
+	newRepository := BitBucketCloudRepoRequest{Scm: "git", IsPrivate: true}
+	if cloudRepoConfig, ok := gitRepoConfig.(*BitBucketCloudRepoConfig); ok && cloudRepoConfig.ProjectKey != "" {
+		if err := client.ensureProject(cloudRepoConfig.Workspace, cloudRepoConfig.ProjectKey); err != nil {
+			return "", err
+		}
+		newRepository.Project = &BitBucketCloudProjectReference{Key: cloudRepoConfig.ProjectKey}
+	}
+
+	var repository BitBucketCloudRepoResponseItem
+	_, err = client.api().request("POST", gitRepoConfig.ConstructRestApiUrl(client.Config.ApiBaseUrl), newRepository, &repository, http.StatusOK, http.StatusCreated)
+	if err != nil {
+		return "", errors.Wrapf(err, "something happened while creating repository %s", gitRepoConfig.GetRepoName())
+	}
+
+	return repository.Links.Html.Href, nil
+}
+
+// ensureProject creates a private project in the workspace, unless it exists
+func (client *BitBucketCloudClient) ensureProject(workspace, projectKey string) error {
+	projectsUrl := client.apiUrl("/workspaces/" + url.PathEscape(workspace) + "/projects")
+	_, err := client.api().request("GET", projectsUrl+"/"+url.PathEscape(projectKey), nil, nil, http.StatusOK)
+	if err == nil {
+		return nil
+	}
+	if !isNotFound(err) {
+		return errors.Wrapf(err, "unable to find project %s in workspace %s", projectKey, workspace)
+	}
+
+	project := BitBucketCloudProjectRequest{Key: projectKey, Name: projectKey, IsPrivate: true}
+	_, err = client.api().request("POST", projectsUrl, project, nil, http.StatusCreated)
+	if err != nil {
+		return errors.Wrapf(err, "something happened while creating project %s in workspace %s", projectKey, workspace)
+	}
+	return nil
+}
+
+// CreateWebhook adds a webhook that is notified of pushes, including created branches and tags
+func (client *BitBucketCloudClient) CreateWebhook(url string, gitConfig GitRepoConfig) error {
+	payload := BitBucketCloudHookRequest{
+		Description: "Genesis API",
+		URL:         url,
+		Active:      true,
+		Events:      []string{"repo:push"},
+	}
+	_, err := client.api().request("POST", gitConfig.ConstructRestApiUrl(client.Config.ApiBaseUrl)+"/hooks", payload, nil, http.StatusCreated)
+	if err != nil {
+		return errors.Wrapf(err, "something happened while creating webhook")
+	}
+	return nil
+}
+
+// AddAdminRights grants the user admin permission on the repository. BitBucket Cloud identifies users by their
+// account ID or UUID, e.g. {a1b2c3d4-...}, rather than by username.
+func (client *BitBucketCloudClient) AddAdminRights(userID string, gitRepoConfig GitRepoConfig) error {
+	permissionUrl := gitRepoConfig.ConstructRestApiUrl(client.Config.ApiBaseUrl) + "/permissions-config/users/" + url.PathEscape(userID)
+	_, err := client.api().request("PUT", permissionUrl, BitBucketCloudPermissionRequest{Permission: "admin"}, nil, http.StatusOK, http.StatusCreated)
+	if err != nil {
+		return errors.Wrapf(err, "unable to apply admin rights for user %s", userID)
+	}
+	return nil
+}
+
+// getAllValues appends the values of every page of a list endpoint to result, following the next URL of each page
+func (client *BitBucketCloudClient) getAllValues(pageUrl string, result interface{}) error {
+	var all []json.RawMessage
+	for pageUrl != "" {
+		var page BitBucketCloudPage
+		_, err := client.api().request("GET", pageUrl, nil, &page, http.StatusOK)
+		if err != nil {
+			return err
+		}
+		all = append(all, page.Values...)
+		pageUrl = page.Next
+	}
+
+	content, err := json.Marshal(all)
+	if err != nil {
+		return errors.Wrapf(err, "Problem marshalling pages")
+	}
+	return json.Unmarshal(content, result)
+}
@@ -0,0 +1,71 @@
+package git_client
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestBitBucketCloudClient returns a client of a BitBucket Cloud stand-in that records the requests and bodies it receives
+func newTestBitBucketCloudClient(t *testing.T, handler http.HandlerFunc) (*BitBucketCloudClient, *[]string) {
+	server, requests := newRecordingServer(t, "Authorization", "Bearer secret", handler)
+
+	config, err := NewBitBucketCloudClientConfig("", "", "secret")
+	assert.Nil(t, err)
+	config.SetBaseUrls("", server.URL+"/2.0", "")
+	client := NewBitBucketCloudClient(&config)
+	return &client, requests
+}
+
+func TestBitBucketCloudClient_CreateNewRemoteRepo_Project(t *testing.T) {
+	client, requests := newTestBitBucketCloudClient(t, routeHandler(map[string]restResponse{
+		"POST /2.0/workspaces/acme/projects": {status: http.StatusCreated},
+		"POST /2.0/repositories/acme/orders": {body: `{"slug": "orders", "links": {"html": {"href": "https://bitbucket.org/acme/orders"}}}`},
+	}))
+
+	repoConfig, err := NewBitBucketCloudRepoConfig("acme/PLAT", "orders")
+	assert.Nil(t, err)
+	repoUrl, err := client.CreateNewRemoteRepo(repoConfig)
+	assert.Nil(t, err)
+	assert.Equal(t, "https://bitbucket.org/acme/orders", repoUrl)
+	assert.Equal(t, []string{
+		"GET /2.0/repositories/acme/orders ",
+		"GET /2.0/workspaces/acme/projects/PLAT ",
+		`POST /2.0/workspaces/acme/projects {"key":"PLAT","name":"PLAT","is_private":true}`,
+		`POST /2.0/repositories/acme/orders {"scm":"git","is_private":true,"project":{"key":"PLAT"}}`,
+	}, *requests)
+}
+
+func TestBitBucketCloudClient_ListAllReposForProjectKey_Project(t *testing.T) {
+	client, _ := newTestBitBucketCloudClient(t, routeHandler(map[string]restResponse{
+		"GET /2.0/repositories/acme?pagelen=100&q=project.key%3D%22PLAT%22": {body: `{"values": [{"slug": "orders"}]}`},
+	}))
+
+	repositories, err := client.ListAllReposForProjectKey("acme/PLAT")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"orders"}, repositories)
+
+	_, err = client.ListAllReposForProjectKey("missing")
+	assert.NotNil(t, err)
+}
+
+func TestBitBucketCloudClientConfig(t *testing.T) {
+	_, err := NewBitBucketCloudClientConfig("jdoe", "", "")
+	assert.NotNil(t, err)
+
+	config, err := NewBitBucketCloudClientConfig("jdoe", "app-password", "")
+	assert.Nil(t, err)
+	client := NewBitBucketCloudClient(&config)
+	assert.Equal(t, "Basic amRvZTphcHAtcGFzc3dvcmQ=", client.api().headers["Authorization"])
+	assert.Equal(t, "jdoe", client.gitAuth().Username)
+
+	config.AccessToken = "secret"
+	assert.Equal(t, "x-token-auth", client.gitAuth().Username)
+
+	repoConfig, err := NewBitBucketCloudRepoConfig("acme", "orders")
+	assert.Nil(t, err)
+	assert.Equal(t, "", repoConfig.ProjectKey)
+	assert.Equal(t, "https://bitbucket.org/acme/orders.git", client.CreateScmRepoUrl(repoConfig))
+	assert.Equal(t, "https://api.bitbucket.org/2.0/repositories/acme/orders", repoConfig.ConstructRestApiUrl(config.ApiBaseUrl))
+}
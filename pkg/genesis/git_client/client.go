@@ -12,6 +12,7 @@ import (
 var (
 	ErrRepoNotExist       = errors.New("repository does not exist")
 	ErrCredentialsInvalid = errors.New("provided credentials are invalid")
+	ErrReferenceNotExist  = errors.New("branch or tag does not exist")
 )
 
 // interface and common package functions
@@ -37,12 +38,16 @@ type GitClient interface {
 	InitRepo(gitRepoConfig GitRepoConfig) (directory string, err error)
 	// InitialCommitProjectToRepo Commits a project to new repo with an initialize commit message.
 	InitialCommitProjectToRepo(baseDirectory string, gitRepoConfig GitRepoConfig) (err error)
+	// CommitAndPushBranch Commits the changes in a cloned repository to a new branch and pushes it.
+	CommitAndPushBranch(directoryPath, branchName, message string, gitRepoConfig GitRepoConfig) (err error)
 	// CreateScmRepoUrl Constructs a URL suitable for pushing git commits to
 	CreateScmRepoUrl(config GitRepoConfig) string
 	// CreateWebhook Adds a webhook
 	CreateWebhook(url string, gitConfig GitRepoConfig) error
 	// ListAllReposForProjectKey queries the BitBucket REST API to retrieve a list of repository names, or an error
 	ListAllReposForProjectKey(projectKey string) ([]string, error)
+	// ListBranches returns the branch names of a repository, or an error
+	ListBranches(gitRepoConfig GitRepoConfig) ([]string, error)
 	// AddAdminRights adds the given userID to the list of admins for a repository
 	AddAdminRights(userID string, gitRepoConfig GitRepoConfig) error
 }
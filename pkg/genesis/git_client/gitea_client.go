@@ -0,0 +1,224 @@
+package git_client
+
+import (
+	"encoding/base64"
+	"github.com/pkg/errors"
+	gitHttp "gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// REST API URL: https://{HOST}/api/v1/repos/{OWNER}/{REPO_NAME}
+// clone URL: https://{HOST}/{OWNER}/{REPO_NAME}.git
+// repo URL: https://{HOST}/{OWNER}/{REPO_NAME}
+type GiteaRepoConfig struct {
+	// Owner is an organization or a user
+	Owner          string
+	RepositoryName string
+	Tags           []string
+}
+
+func NewGiteaRepoConfig(owner, repositoryName string) (*GiteaRepoConfig, error) {
+	if owner == "" || repositoryName == "" {
+		return &GiteaRepoConfig{}, errors.New("owner and repositoryName are required")
+	}
+	return &GiteaRepoConfig{
+		Owner:          owner,
+		RepositoryName: repositoryName,
+	}, nil
+}
+
+func (g *GiteaRepoConfig) GetRepoDomain() string {
+	return g.Owner
+}
+
+// ConstructRestApiUrl returns the REST API URL of the repository, where base is the URL of the API,
+// e.g. https://gitea.example.com/api/v1
+func (g *GiteaRepoConfig) ConstructRestApiUrl(base string) string {
+	return strings.TrimSuffix(base, "/") + "/repos/" + url.PathEscape(g.Owner) + "/" + url.PathEscape(g.RepositoryName)
+}
+
+// ConstructRepoUrl returns the URL of the repository in a browser, where base is the web URL of the instance
+func (g *GiteaRepoConfig) ConstructRepoUrl(base string) string {
+	if base == "" {
+		return ""
+	}
+	return strings.TrimSuffix(base, "/") + "/" + g.Owner + "/" + g.RepositoryName
+}
+
+func (g *GiteaRepoConfig) GetRepoName() string {
+	return g.RepositoryName
+}
+
+func (g *GiteaRepoConfig) SetRepoName(name string) {
+	g.RepositoryName = name
+}
+
+func (g *GiteaRepoConfig) Validate() bool {
+	return g.Owner != "" && g.RepositoryName != ""
+}
+
+type GiteaClientConfig struct {
+	GitHost     string `yaml:"git_host" json:"git_host"`
+	Username    string `yaml:"username" json:"username"`
+	Password    string `yaml:"password" json:"password"`
+	AccessToken string `yaml:"access_token" json:"access_token"`
+	// WebBaseUrl, ApiBaseUrl and CloneBaseUrl default to https://{GitHost}, https://{GitHost}/api/v1 and https://{GitHost}
+	RestBaseUrls `yaml:",inline"`
+}
+
+// NewGiteaClientConfig returns the configuration of a Gitea or Forgejo instance. gitHost may include the scheme,
+// e.g. http://localhost:3000 for an instance running in a container.
+func NewGiteaClientConfig(gitHost, username, password, accessToken string) (GiteaClientConfig, error) {
+	if gitHost == "" {
+		return GiteaClientConfig{}, errors.New("gitHost must not be empty")
+	}
+	if username == "" {
+		return GiteaClientConfig{}, errors.New("username must not be empty")
+	}
+	if accessToken == "" && password == "" {
+		return GiteaClientConfig{}, errors.New("either accessToken or password must not be empty")
+	}
+
+	webBaseUrl := hostBaseUrl(gitHost)
+
+	return GiteaClientConfig{
+		GitHost:      gitHost,
+		Username:     username,
+		Password:     password,
+		AccessToken:  accessToken,
+		RestBaseUrls: RestBaseUrls{WebBaseUrl: webBaseUrl, ApiBaseUrl: webBaseUrl + "/api/v1", CloneBaseUrl: webBaseUrl},
+	}, nil
+}
+
+// apiHeaders authenticate with the access token or else the password
+func (config *GiteaClientConfig) apiHeaders() map[string]string {
+	authorization := "token " + config.AccessToken
+	if config.AccessToken == "" {
+		authorization = "Basic " + base64.StdEncoding.EncodeToString([]byte(config.Username+":"+config.Password))
+	}
+	return map[string]string{"Authorization": authorization}
+}
+
+// gitAuth returns the credentials used over HTTPS, where Gitea accepts an access token as the password
+func (config *GiteaClientConfig) gitAuth() *gitHttp.BasicAuth {
+	if config.AccessToken != "" {
+		return &gitHttp.BasicAuth{Username: config.Username, Password: config.AccessToken}
+	}
+	return &gitHttp.BasicAuth{Username: config.Username, Password: config.Password}
+}
+
+func (config *GiteaClientConfig) authorEmail() string {
+	return config.Username
+}
+
+// Gitea implementation of the GitClient interface, which also serves Forgejo and other Gitea-compatible APIs
+type GiteaClient struct {
+	Config *GiteaClientConfig
+	restGitClient
+}
+
+func NewGiteaClient(config *GiteaClientConfig) GiteaClient {
+	return GiteaClient{
+		Config:        config,
+		restGitClient: newRestGitClient("Gitea", config),
+	}
+}
+
+// ListAllReposForProjectKey lists the repositories of an organization, or of a user when projectKey is not an organization
+func (client *GiteaClient) ListAllReposForProjectKey(projectKey string) ([]string, error) {
+	var repositories []GiteaRepoResponseItem
+	err := client.api().getAllPages(client.apiUrl("/orgs/"+url.PathEscape(projectKey)+"/repos?limit=50"), &repositories)
+	if isNotFound(err) {
+		repositories = nil
+		err = client.api().getAllPages(client.apiUrl("/users/"+url.PathEscape(projectKey)+"/repos?limit=50"), &repositories)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "Problem retrieving the repositories of %s from the Gitea REST API", projectKey)
+	}
+
+	repoNames := make([]string, len(repositories))
+	for i, repository := range repositories {
+		repoNames[i] = repository.Name
+	}
+	return repoNames, nil
+}
+
+func (client *GiteaClient) ListBranches(gitRepoConfig GitRepoConfig) ([]string, error) {
+	var branches []GiteaBranchResponseItem
+	err := client.api().getAllPages(gitRepoConfig.ConstructRestApiUrl(client.Config.ApiBaseUrl)+"/branches?limit=50", &branches)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Problem retrieving the branches of %s from the Gitea REST API", gitRepoConfig.GetRepoName())
+	}
+
+	branchNames := make([]string, len(branches))
+	for i, branch := range branches {
+		branchNames[i] = branch.Name
+	}
+	return branchNames, nil
+}
+
+// GetFileFromRepo downloads a file from the default branch of the repository into a temporary directory
+func (client *GiteaClient) GetFileFromRepo(filename string, gitRepoConfig GitRepoConfig) (file *os.File, err error) {
+	rawUrl := gitRepoConfig.ConstructRestApiUrl(client.Config.ApiBaseUrl) + "/raw/" + strings.TrimPrefix(filename, "/")
+	request, err := client.api().newRequest("GET", rawUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.api().downloadFile(request, filename)
+}
+
+// CreateNewRemoteRepo creates a new empty private repository owned by the organization of the GitRepoConfig, or
+// by the configured user, and returns the URL of the repository
+func (client *GiteaClient) CreateNewRemoteRepo(gitRepoConfig GitRepoConfig) (fullRepoUrl string, err error) {
+	exists, err := client.RepoExists(gitRepoConfig)
+	if err != nil {
+		return "", err
+	}
+
+	if exists {
+		return "", errors.Errorf("the repository already exists at %s", gitRepoConfig.ConstructRepoUrl(client.Config.WebBaseUrl))
+	}
+
+	createUrl := client.apiUrl("/orgs/" + url.PathEscape(gitRepoConfig.GetRepoDomain()) + "/repos")
+	if strings.EqualFold(gitRepoConfig.GetRepoDomain(), client.Config.Username) {
+		createUrl = client.apiUrl("/user/repos")
+	}
+
+	var repository GiteaRepoResponseItem
+	newRepository := GiteaRepoRequest{Name: gitRepoConfig.GetRepoName(), Private: true}
+	_, err = client.api().request("POST", createUrl, newRepository, &repository, http.StatusCreated)
+	if err != nil {
+		return "", errors.Wrapf(err, "something happened while creating repository %s", gitRepoConfig.GetRepoName())
+	}
+
+	return repository.HtmlUrl, nil
+}
+
+// CreateWebhook adds a Gitea webhook that is notified of pushes and of created branches and tags
+func (client *GiteaClient) CreateWebhook(url string, gitConfig GitRepoConfig) error {
+	payload := GiteaHookRequest{
+		Type:   "gitea",
+		Active: true,
+		Events: []string{"push", "create"},
+		Config: GiteaHookConfig{URL: url, ContentType: "json"},
+	}
+	_, err := client.api().request("POST", gitConfig.ConstructRestApiUrl(client.Config.ApiBaseUrl)+"/hooks", payload, nil, http.StatusCreated)
+	if err != nil {
+		return errors.Wrapf(err, "something happened while creating webhook")
+	}
+	return nil
+}
+
+// AddAdminRights adds the user as a collaborator of the repository with admin permission
+func (client *GiteaClient) AddAdminRights(userID string, gitRepoConfig GitRepoConfig) error {
+	collaboratorUrl := gitRepoConfig.ConstructRestApiUrl(client.Config.ApiBaseUrl) + "/collaborators/" + url.PathEscape(userID)
+	_, err := client.api().request("PUT", collaboratorUrl, GiteaCollaboratorRequest{Permission: "admin"}, nil, http.StatusNoContent)
+	if err != nil {
+		return errors.Wrapf(err, "unable to apply admin rights for user %s", userID)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package git_client
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestGiteaClient returns a client of a Gitea stand-in that records the requests and bodies it receives
+func newTestGiteaClient(t *testing.T, handler http.HandlerFunc) (*GiteaClient, *[]string) {
+	server, requests := newRecordingServer(t, "Authorization", "token secret", handler)
+
+	config, err := NewGiteaClientConfig("gitea.example.com", "jdoe", "", "secret")
+	assert.Nil(t, err)
+	config.SetBaseUrls("", server.URL+"/api/v1", "")
+	client := NewGiteaClient(&config)
+	return &client, requests
+}
+
+func TestGiteaClient_CreateNewRemoteRepo_User(t *testing.T) {
+	client, requests := newTestGiteaClient(t, routeHandler(map[string]restResponse{
+		"POST /api/v1/user/repos": {status: http.StatusCreated, body: `{"id": 8, "name": "orders", "html_url": "https://gitea.example.com/jdoe/orders"}`},
+	}))
+
+	repoUrl, err := client.CreateNewRemoteRepo(&GiteaRepoConfig{Owner: "JDoe", RepositoryName: "orders"})
+	assert.Nil(t, err)
+	assert.Equal(t, "https://gitea.example.com/jdoe/orders", repoUrl)
+	assert.Equal(t, []string{
+		"GET /api/v1/repos/JDoe/orders ",
+		`POST /api/v1/user/repos {"name":"orders","private":true}`,
+	}, *requests)
+}
+
+func TestGiteaClient_ListAllReposForProjectKey_User(t *testing.T) {
+	client, requests := newTestGiteaClient(t, routeHandler(map[string]restResponse{
+		"GET /api/v1/users/jdoe/repos?limit=50": {body: `[{"name": "dotfiles"}]`},
+	}))
+
+	repositories, err := client.ListAllReposForProjectKey("jdoe")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"dotfiles"}, repositories)
+	assert.Equal(t, "GET /api/v1/orgs/jdoe/repos?limit=50 ", (*requests)[0])
+}
+
+func TestGiteaClientConfig(t *testing.T) {
+	_, err := NewGiteaClientConfig("gitea.example.com", "jdoe", "", "")
+	assert.NotNil(t, err)
+
+	config, err := NewGiteaClientConfig("http://localhost:3000/", "jdoe", "password", "")
+	assert.Nil(t, err)
+	assert.Equal(t, "http://localhost:3000", config.WebBaseUrl)
+	assert.Equal(t, "http://localhost:3000/api/v1", config.ApiBaseUrl)
+
+	client := NewGiteaClient(&config)
+	repoConfig := &GiteaRepoConfig{Owner: "platform", RepositoryName: "orders"}
+	assert.Equal(t, "http://localhost:3000/platform/orders.git", client.CreateScmRepoUrl(repoConfig))
+	assert.Equal(t, "Basic amRvZTpwYXNzd29yZA==", client.api().headers["Authorization"])
+	assert.Equal(t, "password", client.gitAuth().Password)
+}
@@ -1,32 +1,34 @@
 package git_client
 
 import (
-	"bytes"
 	"encoding/base64"
-	"fmt"
 	"github.com/pkg/errors"
-	"gopkg.in/src-d/go-git.v4"
-	"gopkg.in/src-d/go-git.v4/config"
+	gitHttp "gopkg.in/src-d/go-git.v4/plumbing/transport/http"
 	"net/http"
+	"net/url"
 	"os"
-	"time"
+	"strings"
 )
 
-// REST API URL: https://api.github.com/repos/{DOMAIN}/{REPO_NAME}
+// REST API URL: https://api.github.com/repos/{DOMAIN}/{REPO_NAME}, or https://{HOST}/api/v3/repos/{DOMAIN}/{REPO_NAME}
 // clone URL: https://github.com/{DOMAIN}/{REPO_NAME}.git
 // repo URL: https://github.com/{DOMAIN}/{REPO_NAME}
 type GithubRepoConfig struct {
 	Domain         string
 	RepositoryName string
 	Tags           []string
+	// Host of the GitHub instance, such as a GitHub Enterprise Server. Empty for the default instance.
+	Host string
 }
 
 func (g *GithubRepoConfig) GetRepoDomain() string {
 	return g.Domain
 }
 
+// ConstructRestApiUrl returns the REST API URL of the repository, where base is the URL of the API,
+// e.g. https://api.github.com
 func (g *GithubRepoConfig) ConstructRestApiUrl(base string) string {
-	return "https://api." + base + "/repos/" + g.Domain + "/" + g.RepositoryName
+	return strings.TrimSuffix(base, "/") + "/repos/" + g.Domain + "/" + g.RepositoryName
 }
 
 func NewGithubRepoConfig(domain, reponame string) (*GithubRepoConfig, error) {
@@ -39,11 +41,13 @@ func NewGithubRepoConfig(domain, reponame string) (*GithubRepoConfig, error) {
 	}, nil
 }
 
+// ConstructRepoUrl returns the URL of the repository in a browser, where base is the web URL of the
+// GitHub instance, e.g. https://github.com
 func (g *GithubRepoConfig) ConstructRepoUrl(base string) string {
 	if base == "" {
 		return ""
 	}
-	return "git://" + base + "/" + g.Domain + "/" + g.RepositoryName
+	return strings.TrimSuffix(base, "/") + "/" + g.Domain + "/" + g.RepositoryName
 }
 
 func (g *GithubRepoConfig) GetRepoName() string {
@@ -64,6 +68,19 @@ type GitHubClientConfig struct {
 	Password            string `yaml:"password" json:"password"`
 	AccessToken         string `yaml:"access_token" json:"access_token"`
 	AuthenticationToken string
+	// WebBaseUrl, ApiBaseUrl and CloneBaseUrl default to the URLs of github.com, or of a GitHub Enterprise
+	// Server at GitHost
+	RestBaseUrls `yaml:",inline"`
+}
+
+// GitHubBaseUrls returns the web, REST API and clone URLs of a GitHub instance. github.com serves its API at
+// api.github.com, and GitHub Enterprise Server at /api/v3 of its host.
+func GitHubBaseUrls(gitHost string) (webBaseUrl, apiBaseUrl, cloneBaseUrl string) {
+	webBaseUrl = hostBaseUrl(gitHost)
+	if strings.EqualFold(strings.TrimPrefix(webBaseUrl, "https://"), "github.com") {
+		return webBaseUrl, "https://api.github.com", webBaseUrl
+	}
+	return webBaseUrl, webBaseUrl + "/api/v3", webBaseUrl
 }
 
 func NewGitClientConfig(gitHost, username, password, accessToken string) (GitHubClientConfig, error) {
@@ -83,173 +100,147 @@ func NewGitClientConfig(gitHost, username, password, accessToken string) (GitHub
 		authenticationToken = base64.StdEncoding.EncodeToString([]byte(username + ":" + accessToken))
 	}
 
+	webBaseUrl, apiBaseUrl, cloneBaseUrl := GitHubBaseUrls(gitHost)
+
 	return GitHubClientConfig{
 		GitHost:             gitHost,
 		Username:            username,
 		Password:            password,
 		AccessToken:         accessToken,
 		AuthenticationToken: authenticationToken,
+		RestBaseUrls:        RestBaseUrls{WebBaseUrl: webBaseUrl, ApiBaseUrl: apiBaseUrl, CloneBaseUrl: cloneBaseUrl},
 	}, nil
 }
 
-type GitHubClient struct {
-	Config     *GitHubClientConfig
-	RestClient *http.Client
-}
-
-func NewGitHubClient(config *GitHubClientConfig) GitHubClient {
-	return GitHubClient{
-		Config:     config,
-		RestClient: &http.Client{Timeout: time.Duration(1) * time.Second},
+// apiHeaders authenticate with the access token or the password
+func (config *GitHubClientConfig) apiHeaders() map[string]string {
+	authorization := "Basic " + config.AuthenticationToken
+	if config.AccessToken != "" {
+		authorization = "token " + config.AccessToken
 	}
+	return map[string]string{"Authorization": authorization, "Accept": "application/vnd.github.v3+json"}
 }
 
-func (client *GitHubClient) ListAllReposForProjectKey(projectKey string) ([]string, error) {
-	panic("implement me")
-}
-
-func (client *GitHubClient) GetFileFromRepo(filename string, gitRepoConfig GitRepoConfig) (file *os.File, err error) {
-	panic("implement me")
-}
-
-func (client *GitHubClient) InitialCommitProjectToRepo(baseDirectory string, gitRepoConfig GitRepoConfig) (err error) {
-	panic("implement me")
+// gitAuth returns the credentials used over HTTPS, preferring the access token
+func (config *GitHubClientConfig) gitAuth() *gitHttp.BasicAuth {
+	password := config.AccessToken
+	if password == "" {
+		password = config.Password
+	}
+	return &gitHttp.BasicAuth{Username: config.Username, Password: password}
 }
 
-func (client *GitHubClient) InitRepo(gitRepoConfig GitRepoConfig) (directory string, err error) {
-	panic("implement me")
+func (config *GitHubClientConfig) authorEmail() string {
+	return config.Username
 }
 
-func (client *GitHubClient) CreateScmRepoUrl(config GitRepoConfig) string {
-	panic("implement me")
+type GitHubClient struct {
+	Config *GitHubClientConfig
+	restGitClient
 }
 
-// CreateNewRemoteRepo creates a new empty remote repository with the parameters specified
-// in the GitRepoConfig, and returns any errors encountered
-func (client *GitHubClient) CreateNewRemoteRepo(gitRepoConfig GitRepoConfig) (fullRepoUrl string, err error) {
-	url := gitRepoConfig.ConstructRepoUrl(client.Config.GitHost)
-	var repositoryUrl = ""
-
-	// check if repo exists
-	exists, err := client.RepoExists(gitRepoConfig)
-
-	if err != nil {
-		return repositoryUrl, err
+func NewGitHubClient(config *GitHubClientConfig) GitHubClient {
+	return GitHubClient{
+		Config:        config,
+		restGitClient: newRestGitClient("GitHub", config),
 	}
+}
 
-	if exists {
-		return repositoryUrl, errors.New("the repository already exists at " + url)
+// https://api.github.com/orgs/{org}/repos?per_page=100, or https://api.github.com/users/{user}/repos
+// when projectKey is not an organization
+func (client *GitHubClient) ListAllReposForProjectKey(projectKey string) ([]string, error) {
+	var repositories []GitHubRepoResponseItem
+	err := client.api().getAllPages(client.apiUrl("/orgs/"+url.PathEscape(projectKey)+"/repos?per_page=100"), &repositories)
+	if isNotFound(err) {
+		repositories = nil
+		err = client.api().getAllPages(client.apiUrl("/users/"+url.PathEscape(projectKey)+"/repos?per_page=100"), &repositories)
 	}
-
-	repoName := gitRepoConfig.GetRepoName()
-
 	if err != nil {
-		return repositoryUrl, err
+		return nil, errors.Wrapf(err, "Problem retrieving the repositories of %s from the GitHub REST API", projectKey)
 	}
 
-	// initialize simple repository
-	repository, err := git.PlainInit(repoName, false)
-
-	if err != nil {
-		return repositoryUrl, errors.New("unable to init repository: " + err.Error())
+	repoNames := make([]string, len(repositories))
+	for i, repository := range repositories {
+		repoNames[i] = repository.Name
 	}
+	return repoNames, nil
+}
 
-	remote, err := repository.CreateRemote(&config.RemoteConfig{
-		Name: gitRepoConfig.GetRepoName(),
-		URLs: []string{url},
-	})
-
+func (client *GitHubClient) ListBranches(gitRepoConfig GitRepoConfig) ([]string, error) {
+	var branches []GitHubBranchResponseItem
+	err := client.api().getAllPages(gitRepoConfig.ConstructRestApiUrl(client.Config.ApiBaseUrl)+"/branches?per_page=100", &branches)
 	if err != nil {
-		return repositoryUrl, err
+		return nil, errors.Wrapf(err, "Problem retrieving the branches of %s from the GitHub REST API", gitRepoConfig.GetRepoName())
 	}
 
-	fmt.Printf(remote.String())
-
-	err = repository.Push(&git.PushOptions{})
+	branchNames := make([]string, len(branches))
+	for i, branch := range branches {
+		branchNames[i] = branch.Name
+	}
+	return branchNames, nil
+}
 
+// GetFileFromRepo downloads a file from the default branch of the repository into a temporary directory
+func (client *GitHubClient) GetFileFromRepo(filename string, gitRepoConfig GitRepoConfig) (file *os.File, err error) {
+	contentsUrl := gitRepoConfig.ConstructRestApiUrl(client.Config.ApiBaseUrl) + "/contents/" + strings.TrimPrefix(filename, "/")
+	request, err := client.api().newRequest("GET", contentsUrl, nil)
 	if err != nil {
-		return repositoryUrl, err
+		return nil, err
 	}
+	request.Header.Set("Accept", "application/vnd.github.v3.raw")
 
-	return repositoryUrl, err
+	return client.api().downloadFile(request, filename)
 }
 
-func (client *GitHubClient) CloneRepo(gitRepoConfig GitRepoConfig) (directoryPath string, err error) {
+// CreateNewRemoteRepo creates a new empty private repository in the organization of the GitRepoConfig, or for
+// the authenticated user when the domain is the configured username, and returns the URL of the repository
+func (client *GitHubClient) CreateNewRemoteRepo(gitRepoConfig GitRepoConfig) (fullRepoUrl string, err error) {
 	exists, err := client.RepoExists(gitRepoConfig)
 	if err != nil {
 		return "", err
 	}
 
-	if !exists {
-		return "", ErrRepoNotExist
+	if exists {
+		return "", errors.Errorf("the repository already exists at %s", gitRepoConfig.ConstructRepoUrl(client.Config.WebBaseUrl))
 	}
-	repoUrl := gitRepoConfig.ConstructRepoUrl(client.Config.GitHost) + ".git"
-	randomHash := getRandomHash(10)
 
-	directory := "/tmp/" + randomHash + "/"
-
-	// https://github.com/att-cloudnative-labs/go-lambda-template
-	repo, err := git.PlainClone(directory, false, &git.CloneOptions{
-		URL: repoUrl,
-	})
-
-	if err != nil {
-		return "", err
+	// POST /orgs/{org}/repos, or POST /user/repos for the authenticated user
+	createUrl := client.apiUrl("/orgs/" + url.PathEscape(gitRepoConfig.GetRepoDomain()) + "/repos")
+	if strings.EqualFold(gitRepoConfig.GetRepoDomain(), client.Config.Username) {
+		createUrl = client.apiUrl("/user/repos")
 	}
 
-	_, err = repo.Worktree()
-
+	var repository GitHubRepoResponseItem
+	_, err = client.api().request("POST", createUrl, GitHubRepoRequest{Name: gitRepoConfig.GetRepoName(), Private: true}, &repository, http.StatusCreated)
 	if err != nil {
-		return "", errors.Wrapf(err, "something happened while accessing the worktree")
+		return "", errors.Wrapf(err, "something happened while creating repository %s", gitRepoConfig.GetRepoName())
 	}
 
-	return directory, nil
-}
-
-func (client *GitHubClient) CheckoutBranch(branchName string, gitRepoConfig GitRepoConfig) (directoryPath string, err error) {
-	panic("implement me")
-}
-
-func (client *GitHubClient) CheckoutTag(tagName string, gitRepoConfig GitRepoConfig) (directoryPath string, err error) {
-	panic("implement me")
+	return repository.HtmlUrl, nil
 }
 
-func (client *GitHubClient) RepoExists(gitRepoConfig GitRepoConfig) (exists bool, err error) {
-	apiUrl := gitRepoConfig.ConstructRestApiUrl(client.Config.GitHost)
-	apiProjectUrl := apiUrl
-
-	request, _ := http.NewRequest("GET", apiProjectUrl, bytes.NewBuffer([]byte("")))
-	request.Header.Set("Authorization", "Basic "+client.Config.AuthenticationToken)
-
-	response, err := client.RestClient.Do(request)
-
-	if err != nil {
-		fmt.Printf("an error occurred: %s", err)
-	}
-
-	defer response.Body.Close()
-
-	code := response.StatusCode
-
-	if code == http.StatusNotFound {
-		return false, nil
-	}
-
-	if code == http.StatusUnauthorized {
-		return false, errors.New("github account not authorized to access repository")
+// CreateWebhook adds a webhook that is notified of pushes and new tags and branches
+func (client *GitHubClient) CreateWebhook(url string, gitConfig GitRepoConfig) error {
+	payload := GitHubWebHookRequest{
+		Name:   "web",
+		Active: true,
+		Events: []string{"push", "create"},
+		Config: GitHubWebHookConfig{URL: url, ContentType: "json"},
 	}
-
-	if code == http.StatusOK {
-		return true, nil
+	_, err := client.api().request("POST", gitConfig.ConstructRestApiUrl(client.Config.ApiBaseUrl)+"/hooks", payload, nil, http.StatusCreated)
+	if err != nil {
+		return errors.Wrapf(err, "something happened while creating webhook")
 	}
-
-	return true, errors.New("unable to determine if the repository exists")
-}
-
-func (client *GitHubClient) CreateWebhook(url string, gitConfig GitRepoConfig) error {
-	panic("implement me")
+	return nil
 }
 
+// AddAdminRights adds the user as a collaborator of the repository with admin permission. Users outside of
+// the organization receive an invitation.
 func (client *GitHubClient) AddAdminRights(userID string, gitRepoConfig GitRepoConfig) error {
-	panic("implement me")
+	collaboratorUrl := gitRepoConfig.ConstructRestApiUrl(client.Config.ApiBaseUrl) + "/collaborators/" + url.PathEscape(userID)
+	_, err := client.api().request("PUT", collaboratorUrl, GitHubCollaboratorRequest{Permission: "admin"}, nil, http.StatusCreated, http.StatusNoContent)
+	if err != nil {
+		return errors.Wrapf(err, "unable to apply admin rights for user %s", userID)
+	}
+	return nil
 }
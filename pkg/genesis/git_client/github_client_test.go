@@ -0,0 +1,82 @@
+package git_client
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestGitHubClient returns a client of a GitHub stand-in that records the requests and bodies it receives
+func newTestGitHubClient(t *testing.T, handler http.HandlerFunc) (*GitHubClient, *[]string) {
+	server, requests := newRecordingServer(t, "Authorization", "token secret", handler)
+
+	config, err := NewGitClientConfig("github.com", "octocat", "", "secret")
+	assert.Nil(t, err)
+	config.ApiBaseUrl = server.URL
+	client := NewGitHubClient(&config)
+	return &client, requests
+}
+
+func TestGitHubClient_CreateNewRemoteRepo_User(t *testing.T) {
+	client, requests := newTestGitHubClient(t, routeHandler(map[string]restResponse{
+		"POST /user/repos": {status: http.StatusCreated, body: `{"name": "orders", "html_url": "https://github.com/octocat/orders"}`},
+	}))
+
+	repoUrl, err := client.CreateNewRemoteRepo(&GithubRepoConfig{Domain: "OctoCat", RepositoryName: "orders"})
+	assert.Nil(t, err)
+	assert.Equal(t, "https://github.com/octocat/orders", repoUrl)
+	assert.Equal(t, []string{
+		"GET /repos/OctoCat/orders ",
+		`POST /user/repos {"name":"orders","private":true,"auto_init":false}`,
+	}, *requests)
+}
+
+func TestGitHubClient_ListAllReposForProjectKey_User(t *testing.T) {
+	client, requests := newTestGitHubClient(t, routeHandler(map[string]restResponse{
+		"GET /users/octocat/repos?per_page=100": {body: `[{"name": "hello-world"}]`},
+	}))
+
+	repositories, err := client.ListAllReposForProjectKey("octocat")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"hello-world"}, repositories)
+	assert.Equal(t, "GET /orgs/octocat/repos?per_page=100 ", (*requests)[0])
+}
+
+func TestGitHubClient_GetFileFromRepo(t *testing.T) {
+	client, _ := newTestGitHubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/vnd.github.v3.raw", r.Header.Get("Accept"))
+		_, _ = w.Write([]byte("port: 8080\n"))
+	})
+
+	file, err := client.GetFileFromRepo("/config/app.yml", &GithubRepoConfig{Domain: "att", RepositoryName: "orders"})
+	assert.Nil(t, err)
+	_ = file.Close()
+}
+
+func TestGitHubClient_CreateScmRepoUrl(t *testing.T) {
+	client, _ := newTestGitHubClient(t, func(w http.ResponseWriter, r *http.Request) {})
+	assert.Equal(t, "https://github.com/att/orders.git", client.CreateScmRepoUrl(&GithubRepoConfig{Domain: "att", RepositoryName: "orders"}))
+}
+
+func TestGitHubBaseUrls(t *testing.T) {
+	config, err := NewGitClientConfig("github.com", "octocat", "", "secret")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://github.com", config.WebBaseUrl)
+	assert.Equal(t, "https://api.github.com", config.ApiBaseUrl)
+	assert.Equal(t, "https://github.com", config.CloneBaseUrl)
+
+	config, err = NewGitClientConfig("github.example.com", "octocat", "", "secret")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://github.example.com", config.WebBaseUrl)
+	assert.Equal(t, "https://github.example.com/api/v3", config.ApiBaseUrl)
+
+	config.SetBaseUrls("", "https://api.github.example.com/", "https://clone.github.example.com")
+	assert.Equal(t, "https://github.example.com", config.WebBaseUrl)
+	assert.Equal(t, "https://api.github.example.com", config.ApiBaseUrl)
+	client := NewGitHubClient(&config)
+	repoConfig := &GithubRepoConfig{Domain: "att", RepositoryName: "orders"}
+	assert.Equal(t, "https://clone.github.example.com/att/orders.git", client.CreateScmRepoUrl(repoConfig))
+	assert.Equal(t, "https://github.example.com/att/orders", repoConfig.ConstructRepoUrl(config.WebBaseUrl))
+	assert.Equal(t, "https://api.github.example.com/repos/att/orders", repoConfig.ConstructRestApiUrl(config.ApiBaseUrl))
+}
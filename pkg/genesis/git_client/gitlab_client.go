@@ -0,0 +1,243 @@
+package git_client
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	gitHttp "gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// access level of GitLab maintainers, who administer a project
+const gitLabMaintainerAccess = 40
+
+// REST API URL: https://gitlab.com/api/v4/projects/{NAMESPACE%2FPROJECT}
+// clone URL: https://gitlab.com/{NAMESPACE}/{PROJECT}.git
+// repo URL: https://gitlab.com/{NAMESPACE}/{PROJECT}
+type GitLabRepoConfig struct {
+	// Namespace is the full path of a group, e.g. platform/backend, or a username
+	Namespace   string
+	ProjectPath string
+	Tags        []string
+}
+
+func NewGitLabRepoConfig(namespace, projectPath string) (*GitLabRepoConfig, error) {
+	if namespace == "" || projectPath == "" {
+		return &GitLabRepoConfig{}, errors.New("namespace and projectPath are required")
+	}
+	return &GitLabRepoConfig{
+		Namespace:   strings.Trim(namespace, "/"),
+		ProjectPath: projectPath,
+	}, nil
+}
+
+func (g *GitLabRepoConfig) GetRepoDomain() string {
+	return g.Namespace
+}
+
+// ConstructRestApiUrl returns the REST API URL of the project, where base is the URL of the API,
+// e.g. https://gitlab.com/api/v4
+func (g *GitLabRepoConfig) ConstructRestApiUrl(base string) string {
+	return strings.TrimSuffix(base, "/") + "/projects/" + url.PathEscape(g.Namespace+"/"+g.ProjectPath)
+}
+
+// ConstructRepoUrl returns the URL of the project in a browser, where base is the web URL of the instance
+func (g *GitLabRepoConfig) ConstructRepoUrl(base string) string {
+	if base == "" {
+		return ""
+	}
+	return strings.TrimSuffix(base, "/") + "/" + g.Namespace + "/" + g.ProjectPath
+}
+
+func (g *GitLabRepoConfig) GetRepoName() string {
+	return g.ProjectPath
+}
+
+func (g *GitLabRepoConfig) SetRepoName(name string) {
+	g.ProjectPath = name
+}
+
+func (g *GitLabRepoConfig) Validate() bool {
+	return g.Namespace != "" && g.ProjectPath != ""
+}
+
+type GitLabClientConfig struct {
+	GitHost     string `yaml:"git_host" json:"git_host"`
+	Username    string `yaml:"username" json:"username"`
+	AccessToken string `yaml:"access_token" json:"access_token"`
+	// WebBaseUrl, ApiBaseUrl and CloneBaseUrl default to https://{GitHost}, https://{GitHost}/api/v4 and https://{GitHost}
+	RestBaseUrls `yaml:",inline"`
+}
+
+func NewGitLabClientConfig(gitHost, username, accessToken string) (GitLabClientConfig, error) {
+	if gitHost == "" {
+		return GitLabClientConfig{}, errors.New("gitHost must not be empty")
+	}
+	if username == "" {
+		return GitLabClientConfig{}, errors.New("username must not be empty")
+	}
+	if accessToken == "" {
+		return GitLabClientConfig{}, errors.New("accessToken must not be empty")
+	}
+
+	webBaseUrl := hostBaseUrl(gitHost)
+
+	return GitLabClientConfig{
+		GitHost:      gitHost,
+		Username:     username,
+		AccessToken:  accessToken,
+		RestBaseUrls: RestBaseUrls{WebBaseUrl: webBaseUrl, ApiBaseUrl: webBaseUrl + "/api/v4", CloneBaseUrl: webBaseUrl},
+	}, nil
+}
+
+// apiHeaders authenticate with the access token
+func (config *GitLabClientConfig) apiHeaders() map[string]string {
+	return map[string]string{"PRIVATE-TOKEN": config.AccessToken}
+}
+
+// gitAuth returns the credentials used over HTTPS, where GitLab accepts the access token as the password
+func (config *GitLabClientConfig) gitAuth() *gitHttp.BasicAuth {
+	return &gitHttp.BasicAuth{Username: config.Username, Password: config.AccessToken}
+}
+
+func (config *GitLabClientConfig) authorEmail() string {
+	return config.Username
+}
+
+// GitLab implementation of the GitClient interface, for GitLab.com and self-managed instances
+type GitLabClient struct {
+	Config *GitLabClientConfig
+	restGitClient
+}
+
+func NewGitLabClient(config *GitLabClientConfig) GitLabClient {
+	return GitLabClient{
+		Config:        config,
+		restGitClient: newRestGitClient("GitLab", config),
+	}
+}
+
+// ListAllReposForProjectKey lists the projects of a group and its subgroups, or of a user when projectKey is not a group
+func (client *GitLabClient) ListAllReposForProjectKey(projectKey string) ([]string, error) {
+	var projects []GitLabProjectResponseItem
+	err := client.api().getAllPages(client.apiUrl("/groups/"+url.PathEscape(projectKey)+"/projects?per_page=100&include_subgroups=true"), &projects)
+	if isNotFound(err) {
+		projects = nil
+		err = client.api().getAllPages(client.apiUrl("/users/"+url.PathEscape(projectKey)+"/projects?per_page=100"), &projects)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "Problem retrieving the projects of %s from the GitLab REST API", projectKey)
+	}
+
+	// projects of subgroups are named by their path relative to the group
+	projectNames := make([]string, len(projects))
+	for i, project := range projects {
+		projectNames[i] = strings.TrimPrefix(project.PathWithNamespace, strings.Trim(projectKey, "/")+"/")
+		if project.PathWithNamespace == "" {
+			projectNames[i] = project.Path
+		}
+	}
+	return projectNames, nil
+}
+
+func (client *GitLabClient) ListBranches(gitRepoConfig GitRepoConfig) ([]string, error) {
+	var branches []GitLabBranchResponseItem
+	err := client.api().getAllPages(gitRepoConfig.ConstructRestApiUrl(client.Config.ApiBaseUrl)+"/repository/branches?per_page=100", &branches)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Problem retrieving the branches of %s from the GitLab REST API", gitRepoConfig.GetRepoName())
+	}
+
+	branchNames := make([]string, len(branches))
+	for i, branch := range branches {
+		branchNames[i] = branch.Name
+	}
+	return branchNames, nil
+}
+
+// GetFileFromRepo downloads a file from the default branch of the project into a temporary directory
+func (client *GitLabClient) GetFileFromRepo(filename string, gitRepoConfig GitRepoConfig) (file *os.File, err error) {
+	fileUrl := gitRepoConfig.ConstructRestApiUrl(client.Config.ApiBaseUrl) + "/repository/files/" + url.PathEscape(strings.TrimPrefix(filename, "/")) + "/raw?ref=HEAD"
+	request, err := client.api().newRequest("GET", fileUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.api().downloadFile(request, filename)
+}
+
+// CreateNewRemoteRepo creates a new empty private project in the namespace of the GitRepoConfig, a group or the
+// configured user, and returns the URL of the project
+func (client *GitLabClient) CreateNewRemoteRepo(gitRepoConfig GitRepoConfig) (fullRepoUrl string, err error) {
+	exists, err := client.RepoExists(gitRepoConfig)
+	if err != nil {
+		return "", err
+	}
+
+	if exists {
+		return "", errors.Errorf("the repository already exists at %s", gitRepoConfig.ConstructRepoUrl(client.Config.WebBaseUrl))
+	}
+
+	newProject := GitLabProjectRequest{
+		Name:       gitRepoConfig.GetRepoName(),
+		Path:       gitRepoConfig.GetRepoName(),
+		Visibility: "private",
+	}
+	// projects without a namespace are created for the authenticated user
+	if !strings.EqualFold(gitRepoConfig.GetRepoDomain(), client.Config.Username) {
+		var namespace GitLabNamespaceResponseItem
+		_, err = client.api().request("GET", client.apiUrl("/namespaces/"+url.PathEscape(gitRepoConfig.GetRepoDomain())), nil, &namespace, http.StatusOK)
+		if err != nil {
+			return "", errors.Wrapf(err, "unable to find namespace %s", gitRepoConfig.GetRepoDomain())
+		}
+		newProject.NamespaceId = namespace.Id
+	}
+
+	var project GitLabProjectResponseItem
+	_, err = client.api().request("POST", client.apiUrl("/projects"), newProject, &project, http.StatusCreated)
+	if err != nil {
+		return "", errors.Wrapf(err, "something happened while creating repository %s", gitRepoConfig.GetRepoName())
+	}
+
+	return project.WebUrl, nil
+}
+
+// CreateWebhook adds a project hook that is notified of pushes and tags
+func (client *GitLabClient) CreateWebhook(url string, gitConfig GitRepoConfig) error {
+	payload := GitLabHookRequest{
+		URL:                   url,
+		PushEvents:            true,
+		TagPushEvents:         true,
+		EnableSslVerification: true,
+	}
+	_, err := client.api().request("POST", gitConfig.ConstructRestApiUrl(client.Config.ApiBaseUrl)+"/hooks", payload, nil, http.StatusCreated)
+	if err != nil {
+		return errors.Wrapf(err, "something happened while creating webhook")
+	}
+	return nil
+}
+
+// AddAdminRights makes the user a maintainer of the project
+func (client *GitLabClient) AddAdminRights(userID string, gitRepoConfig GitRepoConfig) error {
+	var users []GitLabUserResponseItem
+	_, err := client.api().request("GET", client.apiUrl("/users?username="+url.QueryEscape(userID)), nil, &users, http.StatusOK)
+	if err != nil {
+		return errors.Wrapf(err, "unable to find user %s", userID)
+	}
+	if len(users) == 0 {
+		return errors.Errorf("unable to find user %s", userID)
+	}
+
+	membersUrl := gitRepoConfig.ConstructRestApiUrl(client.Config.ApiBaseUrl) + "/members"
+	member := GitLabMemberRequest{UserId: users[0].Id, AccessLevel: gitLabMaintainerAccess}
+	response, err := client.api().request("POST", membersUrl, member, nil, http.StatusCreated)
+	if response != nil && response.StatusCode == http.StatusConflict {
+		// the user is already a member, with another access level
+		_, err = client.api().request("PUT", fmt.Sprintf("%s/%d", membersUrl, member.UserId), member, nil, http.StatusOK)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "unable to apply admin rights for user %s", userID)
+	}
+	return nil
+}
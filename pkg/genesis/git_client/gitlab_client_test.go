@@ -0,0 +1,67 @@
+package git_client
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestGitLabClient returns a client of a GitLab stand-in that records the requests and bodies it receives
+func newTestGitLabClient(t *testing.T, handler http.HandlerFunc) (*GitLabClient, *[]string) {
+	server, requests := newRecordingServer(t, "PRIVATE-TOKEN", "secret", handler)
+
+	config, err := NewGitLabClientConfig("gitlab.example.com", "jdoe", "secret")
+	assert.Nil(t, err)
+	config.SetBaseUrls("", server.URL+"/api/v4", "")
+	client := NewGitLabClient(&config)
+	return &client, requests
+}
+
+func TestGitLabClient_CreateNewRemoteRepo_User(t *testing.T) {
+	client, requests := newTestGitLabClient(t, routeHandler(map[string]restResponse{
+		"POST /api/v4/projects": {status: http.StatusCreated, body: `{"id": 7, "web_url": "https://gitlab.example.com/jdoe/orders"}`},
+	}))
+
+	repoUrl, err := client.CreateNewRemoteRepo(&GitLabRepoConfig{Namespace: "jdoe", ProjectPath: "orders"})
+	assert.Nil(t, err)
+	assert.Equal(t, "https://gitlab.example.com/jdoe/orders", repoUrl)
+	assert.Equal(t, []string{
+		"GET /api/v4/projects/jdoe%2Forders ",
+		`POST /api/v4/projects {"name":"orders","path":"orders","visibility":"private"}`,
+	}, *requests)
+
+	_, err = client.CreateNewRemoteRepo(&GitLabRepoConfig{Namespace: "missing", ProjectPath: "orders"})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "unable to find namespace missing")
+}
+
+func TestGitLabClient_ListAllReposForProjectKey_User(t *testing.T) {
+	client, _ := newTestGitLabClient(t, routeHandler(map[string]restResponse{
+		"GET /api/v4/users/jdoe/projects?per_page=100": {body: `[{"path": "dotfiles", "path_with_namespace": "jdoe/dotfiles"}]`},
+	}))
+
+	projects, err := client.ListAllReposForProjectKey("jdoe")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"dotfiles"}, projects)
+}
+
+func TestGitLabClient_AddAdminRights_Member(t *testing.T) {
+	client, requests := newTestGitLabClient(t, routeHandler(map[string]restResponse{
+		"GET /api/v4/users?username=bjones":                {body: `[{"id": 6, "username": "bjones"}]`},
+		"POST /api/v4/projects/platform%2Forders/members":  {status: http.StatusConflict},
+		"PUT /api/v4/projects/platform%2Forders/members/6": {},
+	}))
+
+	assert.Nil(t, client.AddAdminRights("bjones", &GitLabRepoConfig{Namespace: "platform", ProjectPath: "orders"}))
+	assert.Equal(t, []string{
+		"GET /api/v4/users?username=bjones ",
+		`POST /api/v4/projects/platform%2Forders/members {"user_id":6,"access_level":40}`,
+		`PUT /api/v4/projects/platform%2Forders/members/6 {"user_id":6,"access_level":40}`,
+	}, *requests)
+}
+
+func TestGitLabClient_CreateScmRepoUrl(t *testing.T) {
+	client, _ := newTestGitLabClient(t, routeHandler(nil))
+	assert.Equal(t, "https://gitlab.example.com/platform/orders.git", client.CreateScmRepoUrl(&GitLabRepoConfig{Namespace: "platform", ProjectPath: "orders"}))
+}
@@ -0,0 +1,351 @@
+package git_client
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// extensions of the tarballs of local templates, stripped from their name
+var tarballExtensions = []string{".tar.gz", ".tgz", ".tar"}
+
+// LocalTemplateConfig is a template repository on the filesystem: a directory, or a tarball (.tar, .tar.gz or .tgz)
+// of one. Local templates are only template sources, and are never pushed to.
+type LocalTemplateConfig struct {
+	Path string
+	Tags []string
+}
+
+func NewLocalTemplateConfig(path string) (*LocalTemplateConfig, error) {
+	if path == "" {
+		return &LocalTemplateConfig{}, errors.New("path is required")
+	}
+	return &LocalTemplateConfig{Path: filepath.Clean(path)}, nil
+}
+
+// GetRepoDomain returns the directory that contains the template
+func (l *LocalTemplateConfig) GetRepoDomain() string {
+	return filepath.Dir(l.Path)
+}
+
+// ConstructRestApiUrl returns an empty URL, as local templates have no REST API
+func (l *LocalTemplateConfig) ConstructRestApiUrl(base string) string {
+	return ""
+}
+
+// ConstructRepoUrl returns the path of the template, which does not depend on base
+func (l *LocalTemplateConfig) ConstructRepoUrl(base string) string {
+	return l.Path
+}
+
+// GetRepoName returns the name of the directory or of the tarball without its extension
+func (l *LocalTemplateConfig) GetRepoName() string {
+	name := filepath.Base(l.Path)
+	for _, extension := range tarballExtensions {
+		if strings.HasSuffix(name, extension) {
+			return strings.TrimSuffix(name, extension)
+		}
+	}
+	return name
+}
+
+func (l *LocalTemplateConfig) SetRepoName(name string) {
+	l.Path = filepath.Join(filepath.Dir(l.Path), name)
+}
+
+func (l *LocalTemplateConfig) Validate() bool {
+	return l.Path != ""
+}
+
+// Local implementation of the GitClient interface, which reads templates from the filesystem. A template is copied,
+// or extracted from its tarball, into a temporary directory, so that rendering never changes the original.
+// The operations of target repositories are not supported.
+type LocalClient struct{}
+
+func NewLocalClient() LocalClient {
+	return LocalClient{}
+}
+
+func (client *LocalClient) RepoExists(gitRepoConfig GitRepoConfig) (exists bool, err error) {
+	_, err = os.Stat(client.CreateScmRepoUrl(gitRepoConfig))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "unable to determine if the template exists")
+	}
+	return true, nil
+}
+
+// GetFileFromRepo opens a file of a template directory, or of the extracted tarball
+func (client *LocalClient) GetFileFromRepo(filename string, gitRepoConfig GitRepoConfig) (file *os.File, err error) {
+	directory := client.CreateScmRepoUrl(gitRepoConfig)
+	info, err := os.Stat(directory)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read template %s", directory)
+	}
+	if !info.IsDir() {
+		directory, err = client.CloneRepo(gitRepoConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+	file, err = os.Open(filepath.Join(directory, filepath.FromSlash(strings.TrimPrefix(filename, "/"))))
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to open %s in template %s", filename, gitRepoConfig.GetRepoName())
+	}
+	return file, nil
+}
+
+// CloneRepo copies the template directory, or extracts the tarball, into a temporary directory. A tarball with a
+// single top-level directory, such as one created with `tar czf template.tgz template/`, is extracted to its contents.
+func (client *LocalClient) CloneRepo(gitRepoConfig GitRepoConfig) (directoryPath string, err error) {
+	source := client.CreateScmRepoUrl(gitRepoConfig)
+	info, err := os.Stat(source)
+	if os.IsNotExist(err) {
+		return "", ErrRepoNotExist
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to read template %s", source)
+	}
+
+	directory := "/tmp/" + getRandomHash(10) + "/"
+	if info.IsDir() {
+		err = copyDirectory(source, directory)
+		if err != nil {
+			return "", errors.Wrapf(err, "unable to copy template %s", source)
+		}
+		return directory, nil
+	}
+
+	err = extractTarball(source, directory)
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to extract template %s", source)
+	}
+	return singleTopLevelDirectory(directory), nil
+}
+
+func (client *LocalClient) CheckoutBranch(branchName string, gitRepoConfig GitRepoConfig) (directoryPath string, err error) {
+	return "", errors.Errorf("local template %s has no branch %s", gitRepoConfig.GetRepoName(), branchName)
+}
+
+func (client *LocalClient) CheckoutTag(tagName string, gitRepoConfig GitRepoConfig) (directoryPath string, err error) {
+	return "", errors.Errorf("local template %s has no tag %s", gitRepoConfig.GetRepoName(), tagName)
+}
+
+func (client *LocalClient) CreateNewRemoteRepo(gitRepoConfig GitRepoConfig) (fullRepoUrl string, err error) {
+	return "", errLocalTarget(gitRepoConfig)
+}
+
+func (client *LocalClient) InitRepo(gitRepoConfig GitRepoConfig) (directory string, err error) {
+	return "", errLocalTarget(gitRepoConfig)
+}
+
+func (client *LocalClient) InitialCommitProjectToRepo(baseDirectory string, gitRepoConfig GitRepoConfig) (err error) {
+	return errLocalTarget(gitRepoConfig)
+}
+
+func (client *LocalClient) CommitAndPushBranch(directoryPath, branchName, message string, gitRepoConfig GitRepoConfig) (err error) {
+	return errLocalTarget(gitRepoConfig)
+}
+
+// CreateScmRepoUrl returns the path of the template
+func (client *LocalClient) CreateScmRepoUrl(config GitRepoConfig) string {
+	return config.ConstructRepoUrl("")
+}
+
+func (client *LocalClient) CreateWebhook(url string, gitConfig GitRepoConfig) error {
+	return errLocalTarget(gitConfig)
+}
+
+func (client *LocalClient) ListAllReposForProjectKey(projectKey string) ([]string, error) {
+	return nil, errors.Errorf("local templates cannot be listed")
+}
+
+func (client *LocalClient) ListBranches(gitRepoConfig GitRepoConfig) ([]string, error) {
+	return nil, errors.Errorf("local template %s has no branches", gitRepoConfig.GetRepoName())
+}
+
+func (client *LocalClient) AddAdminRights(userID string, gitRepoConfig GitRepoConfig) error {
+	return errLocalTarget(gitRepoConfig)
+}
+
+func errLocalTarget(gitRepoConfig GitRepoConfig) error {
+	return errors.Errorf("local template %s can only be a template source", gitRepoConfig.GetRepoName())
+}
+
+// copyDirectory copies the files, directories and symbolic links under source to target, including .git so that
+// the commit of a working copy is recorded in the manifest of generated projects. Links that resolve outside of
+// source are rejected, and absolute links within it are copied as relative links to the copy.
+func copyDirectory(source, target string) error {
+	resolvedSource, err := filepath.EvalSymlinks(source)
+	if err != nil {
+		return err
+	}
+	return filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+		targetPath := filepath.Join(target, relativePath)
+
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(targetPath, info.Mode().Perm()|0700)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := copiedLink(resolvedSource, filepath.Join(resolvedSource, relativePath))
+			if err != nil {
+				return errors.Wrapf(err, "link %s", relativePath)
+			}
+			return os.Symlink(link, targetPath)
+		case info.Mode().IsRegular():
+			sourceFile, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer sourceFile.Close()
+			return writeFile(targetPath, sourceFile, info.Mode().Perm())
+		}
+		return nil
+	})
+}
+
+// copiedLink returns the destination of the link at path for a copy of directory, relative to the link when the
+// destination is absolute, or an error when the link, or a link it points to, resolves outside of directory
+func copiedLink(directory, path string) (string, error) {
+	link, err := os.Readlink(path)
+	if err != nil {
+		return "", err
+	}
+	destination := link
+	if !filepath.IsAbs(link) {
+		destination = filepath.Join(filepath.Dir(path), link)
+	}
+	if !withinDirectory(directory, destination) {
+		return "", errors.Errorf("points outside of the template")
+	}
+
+	// links to links are followed to their final destination, unless the destination does not exist
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	if err == nil && !withinDirectory(directory, resolved) {
+		return "", errors.Errorf("points outside of the template")
+	}
+
+	if filepath.IsAbs(link) {
+		return filepath.Rel(filepath.Dir(path), destination)
+	}
+	return link, nil
+}
+
+// extractTarball extracts a tarball, compressed with gzip or not, into directory. Entries outside of directory,
+// entries below symbolic links and absolute links or links outside of directory are rejected.
+func extractTarball(tarball, directory string) error {
+	file, err := os.Open(tarball)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var reader io.Reader = bufio.NewReader(file)
+	if magic, err := reader.(*bufio.Reader).Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return err
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return err
+	}
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		targetPath := filepath.Join(directory, header.Name)
+		if !withinDirectory(directory, targetPath) {
+			return errors.Errorf("entry %s is outside of the template", header.Name)
+		}
+		if linkedParent(directory, targetPath) {
+			return errors.Errorf("entry %s is below a symbolic link", header.Name)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(targetPath, os.FileMode(header.Mode).Perm()|0700)
+		case tar.TypeReg, tar.TypeRegA:
+			err = writeFile(targetPath, tarReader, os.FileMode(header.Mode).Perm())
+		case tar.TypeSymlink:
+			// the cleaned link only climbs out of its parent, which is not a link, before descending into the template
+			link := filepath.Clean(header.Linkname)
+			if filepath.IsAbs(link) || !withinDirectory(directory, filepath.Join(filepath.Dir(targetPath), link)) {
+				return errors.Errorf("link %s points outside of the template", header.Name)
+			}
+			if err = os.MkdirAll(filepath.Dir(targetPath), 0755); err == nil {
+				err = os.Symlink(link, targetPath)
+			}
+		}
+		if err != nil {
+			return errors.Wrapf(err, "unable to extract %s", header.Name)
+		}
+	}
+}
+
+func writeFile(path string, content io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode|0600)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(file, content); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// linkedParent tells whether one of the existing parents of path below directory is a symbolic link, through
+// which an entry could be written outside of directory
+func linkedParent(directory, path string) bool {
+	for parent := filepath.Dir(path); withinDirectory(directory, parent) && parent != filepath.Clean(directory); parent = filepath.Dir(parent) {
+		if info, err := os.Lstat(parent); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func withinDirectory(directory, path string) bool {
+	relativePath, err := filepath.Rel(directory, path)
+	return err == nil && relativePath != ".." && !strings.HasPrefix(relativePath, ".."+string(filepath.Separator))
+}
+
+// singleTopLevelDirectory returns the only entry of directory when it is a directory, and directory otherwise
+func singleTopLevelDirectory(directory string) string {
+	entries, err := ioutil.ReadDir(directory)
+	if err != nil || len(entries) != 1 || !entries[0].IsDir() {
+		return directory
+	}
+	return filepath.Join(directory, entries[0].Name()) + "/"
+}
@@ -0,0 +1,205 @@
+package git_client
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeTarball writes a gzipped tarball of files, keyed by their name in the tarball
+func writeTarball(t *testing.T, path string, files map[string]string) {
+	file, err := os.Create(path)
+	assert.Nil(t, err)
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for name, content := range files {
+		assert.Nil(t, tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err = tarWriter.Write([]byte(content))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, tarWriter.Close())
+	assert.Nil(t, gzipWriter.Close())
+	assert.Nil(t, file.Close())
+}
+
+// writeLinkTarball writes a tarball of symbolic links and files, a link having a target and a file not
+func writeLinkTarball(t *testing.T, path string, entries [][2]string) {
+	file, err := os.Create(path)
+	assert.Nil(t, err)
+	tarWriter := tar.NewWriter(file)
+	for _, entry := range entries {
+		if entry[1] != "" {
+			assert.Nil(t, tarWriter.WriteHeader(&tar.Header{Name: entry[0], Linkname: entry[1], Mode: 0777, Typeflag: tar.TypeSymlink}))
+			continue
+		}
+		assert.Nil(t, tarWriter.WriteHeader(&tar.Header{Name: entry[0], Mode: 0644, Size: 1, Typeflag: tar.TypeReg}))
+		_, err = tarWriter.Write([]byte("x"))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, tarWriter.Close())
+	assert.Nil(t, file.Close())
+}
+
+func TestLocalClient_CloneDirectory(t *testing.T) {
+	directory, err := ioutil.TempDir("", "local-template")
+	assert.Nil(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(directory) })
+	assert.Nil(t, os.MkdirAll(filepath.Join(directory, "base"), 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(directory, ".genesis.yml"), []byte("projects: []\n"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(directory, "base", "run.sh"), []byte("#!/bin/sh\n"), 0755))
+	client := NewLocalClient()
+
+	repoConfig, err := NewLocalTemplateConfig(directory + "/")
+	assert.Nil(t, err)
+	exists, err := client.RepoExists(repoConfig)
+	assert.Nil(t, err)
+	assert.True(t, exists)
+
+	copyPath, err := client.CloneRepo(repoConfig)
+	assert.Nil(t, err)
+	assert.NotEqual(t, directory, copyPath)
+	info, err := os.Stat(filepath.Join(copyPath, "base", "run.sh"))
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+	file, err := client.GetFileFromRepo(".genesis.yml", repoConfig)
+	assert.Nil(t, err)
+	content, _ := ioutil.ReadAll(file)
+	_ = file.Close()
+	assert.Equal(t, "projects: []\n", string(content))
+}
+
+func TestLocalClient_CloneDirectoryLinks(t *testing.T) {
+	directory, err := ioutil.TempDir("", "local-template")
+	assert.Nil(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(directory) })
+	template := filepath.Join(directory, "template")
+	assert.Nil(t, os.MkdirAll(filepath.Join(template, "base"), 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(template, "base", "README.md"), []byte("# {{name}}\n"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(directory, "secret.txt"), []byte("do-not-copy"), 0644))
+	assert.Nil(t, os.Symlink("README.md", filepath.Join(template, "base", "relative.md")))
+	assert.Nil(t, os.Symlink(filepath.Join(template, "base", "README.md"), filepath.Join(template, "base", "absolute.md")))
+	client := NewLocalClient()
+
+	copyPath, err := client.CloneRepo(&LocalTemplateConfig{Path: template})
+	assert.Nil(t, err)
+	for _, name := range []string{"relative.md", "absolute.md"} {
+		link, err := os.Readlink(filepath.Join(copyPath, "base", name))
+		assert.Nil(t, err)
+		assert.Equal(t, "README.md", link, "links within the template should point to the copy")
+	}
+
+	// a link outside of the template, directly or through another link, is rejected
+	assert.Nil(t, os.Symlink("../../secret.txt", filepath.Join(template, "base", "secret.txt")))
+	_, err = client.CloneRepo(&LocalTemplateConfig{Path: template})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "points outside of the template")
+
+	assert.Nil(t, os.Remove(filepath.Join(template, "base", "secret.txt")))
+	assert.Nil(t, os.Symlink(directory, filepath.Join(template, "parent")))
+	assert.Nil(t, os.Symlink("parent/secret.txt", filepath.Join(template, "base.txt")))
+	_, err = client.CloneRepo(&LocalTemplateConfig{Path: template})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "points outside of the template")
+}
+
+func TestLocalClient_CloneTarball(t *testing.T) {
+	directory, err := ioutil.TempDir("", "local-template")
+	assert.Nil(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(directory) })
+	client := NewLocalClient()
+
+	// a single top-level directory is extracted to its contents
+	writeTarball(t, filepath.Join(directory, "go-service.tgz"), map[string]string{
+		"go-service/.genesis.yml":   "projects: []\n",
+		"go-service/base/README.md": "# {{name}}\n",
+	})
+	repoConfig, err := NewLocalTemplateConfig(filepath.Join(directory, "go-service.tgz"))
+	assert.Nil(t, err)
+	assert.Equal(t, "go-service", repoConfig.GetRepoName())
+
+	extractedPath, err := client.CloneRepo(repoConfig)
+	assert.Nil(t, err)
+	readme, err := ioutil.ReadFile(filepath.Join(extractedPath, "base", "README.md"))
+	assert.Nil(t, err)
+	assert.Equal(t, "# {{name}}\n", string(readme))
+
+	file, err := client.GetFileFromRepo(".genesis.yml", repoConfig)
+	assert.Nil(t, err)
+	_ = file.Close()
+
+	writeTarball(t, filepath.Join(directory, "escape.tar.gz"), map[string]string{"../outside.txt": "x"})
+	_, err = client.CloneRepo(&LocalTemplateConfig{Path: filepath.Join(directory, "escape.tar.gz")})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "outside of the template")
+}
+
+func TestLocalClient_Errors(t *testing.T) {
+	client := NewLocalClient()
+	repoConfig := &LocalTemplateConfig{Path: "/does/not/exist"}
+
+	exists, err := client.RepoExists(repoConfig)
+	assert.Nil(t, err)
+	assert.False(t, exists)
+
+	_, err = client.CloneRepo(repoConfig)
+	assert.Equal(t, ErrRepoNotExist, err)
+
+	_, err = client.CheckoutTag("v1.0.0", repoConfig)
+	assert.NotNil(t, err)
+	_, err = client.CreateNewRemoteRepo(repoConfig)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "can only be a template source")
+
+	_, err = NewLocalTemplateConfig("")
+	assert.NotNil(t, err)
+}
+
+func TestLocalClient_CloneTarballLinks(t *testing.T) {
+	directory, err := ioutil.TempDir("", "local-template")
+	assert.Nil(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(directory) })
+	outside := filepath.Join(directory, "outside")
+	assert.Nil(t, os.Mkdir(outside, 0755))
+	client := NewLocalClient()
+
+	writeLinkTarball(t, filepath.Join(directory, "links.tar"), [][2]string{
+		{"go-service/base/README.md", ""},
+		{"go-service/docs/README.md", "../base/README.md"},
+		{"go-service/latest", "./base/../docs"},
+	})
+	extractedPath, err := client.CloneRepo(&LocalTemplateConfig{Path: filepath.Join(directory, "links.tar")})
+	assert.Nil(t, err)
+	readme, err := ioutil.ReadFile(filepath.Join(extractedPath, "latest", "README.md"))
+	assert.Nil(t, err)
+	assert.Equal(t, "x", string(readme))
+	link, err := os.Readlink(filepath.Join(extractedPath, "latest"))
+	assert.Nil(t, err)
+	assert.Equal(t, "docs", link)
+
+	writeLinkTarball(t, filepath.Join(directory, "absolute.tar"), [][2]string{
+		{"x", outside},
+		{"x/evil", ""},
+	})
+	_, err = client.CloneRepo(&LocalTemplateConfig{Path: filepath.Join(directory, "absolute.tar")})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "link x points outside of the template")
+
+	writeLinkTarball(t, filepath.Join(directory, "chained.tar"), [][2]string{
+		{"d/e", ".."},
+		{"d/e/f", ".."},
+		{"d/e/f/evil", ""},
+	})
+	_, err = client.CloneRepo(&LocalTemplateConfig{Path: filepath.Join(directory, "chained.tar")})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "entry d/e/f is below a symbolic link")
+
+	entries, err := ioutil.ReadDir(outside)
+	assert.Nil(t, err)
+	assert.Empty(t, entries)
+}
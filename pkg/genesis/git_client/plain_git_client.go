@@ -0,0 +1,320 @@
+package git_client
+
+import (
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/config"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+	gitHttp "gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+	gitSsh "gopkg.in/src-d/go-git.v4/plumbing/transport/ssh"
+	"gopkg.in/src-d/go-git.v4/storage/memory"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// clone URL: any URL supported by git, e.g. https://git.example.com/platform/orders.git,
+// ssh://git@git.example.com/platform/orders.git, file:///srv/git/orders.git or /srv/git/orders.git
+// repo URL: the clone URL
+type PlainGitRepoConfig struct {
+	Url  string
+	Tags []string
+}
+
+func NewPlainGitRepoConfig(repoUrl string) (*PlainGitRepoConfig, error) {
+	if repoUrl == "" {
+		return &PlainGitRepoConfig{}, errors.New("repoUrl is required")
+	}
+	return &PlainGitRepoConfig{Url: strings.TrimSuffix(repoUrl, "/")}, nil
+}
+
+// GetRepoDomain returns the URL up to the name of the repository, e.g. https://git.example.com/platform
+func (g *PlainGitRepoConfig) GetRepoDomain() string {
+	domain, _ := g.split()
+	return domain
+}
+
+// ConstructRestApiUrl returns an empty URL, as plain git repositories have no REST API
+func (g *PlainGitRepoConfig) ConstructRestApiUrl(base string) string {
+	return ""
+}
+
+// ConstructRepoUrl returns the URL of the repository, which does not depend on base
+func (g *PlainGitRepoConfig) ConstructRepoUrl(base string) string {
+	return g.Url
+}
+
+// GetRepoName returns the last element of the URL without .git, e.g. orders
+func (g *PlainGitRepoConfig) GetRepoName() string {
+	_, name := g.split()
+	return strings.TrimSuffix(name, ".git")
+}
+
+// SetRepoName replaces the last element of the URL, keeping its .git suffix
+func (g *PlainGitRepoConfig) SetRepoName(name string) {
+	domain, oldName := g.split()
+	if strings.HasSuffix(oldName, ".git") && !strings.HasSuffix(name, ".git") {
+		name += ".git"
+	}
+	if domain == "" {
+		g.Url = name
+		return
+	}
+	g.Url = domain + g.Url[len(domain):len(domain)+1] + name
+}
+
+func (g *PlainGitRepoConfig) Validate() bool {
+	return g.Url != "" && g.GetRepoName() != ""
+}
+
+// split splits the URL at the separator before its last element, a / or the : of an scp-like URL such as
+// git@git.example.com:orders.git
+func (g *PlainGitRepoConfig) split() (domain, name string) {
+	index := strings.LastIndexAny(g.Url, "/:")
+	if index < 0 {
+		return "", g.Url
+	}
+	return g.Url[:index], g.Url[index+1:]
+}
+
+type PlainGitClientConfig struct {
+	// Username and Password authenticate over HTTPS, and Username defaults to git over SSH
+	Username string `yaml:"username" json:"username"`
+	Password string `yaml:"password" json:"password"`
+	// SshKeyPath is a private key used over SSH instead of the SSH agent
+	SshKeyPath     string `yaml:"ssh_key_path" json:"ssh_key_path"`
+	SshKeyPassword string `yaml:"ssh_key_password" json:"ssh_key_password"`
+	// Email of the author of the commits
+	Email string `yaml:"email" json:"email"`
+}
+
+// Plain git implementation of the GitClient interface, which clones and pushes repositories at any git URL
+// without a REST API. Repositories are not created, so projects are pushed to existing empty repositories.
+// Local paths and file:// URLs require git on the PATH.
+type PlainGitClient struct {
+	Config *PlainGitClientConfig
+}
+
+func NewPlainGitClient(config *PlainGitClientConfig) PlainGitClient {
+	return PlainGitClient{Config: config}
+}
+
+// ListAllReposForProjectKey lists the repositories in a local directory, the only kind of location that can be
+// listed without a REST API
+func (client *PlainGitClient) ListAllReposForProjectKey(projectKey string) ([]string, error) {
+	directory := strings.TrimPrefix(projectKey, "file://")
+	if strings.Contains(directory, "://") || strings.Contains(directory, "@") {
+		return nil, errors.Errorf("repositories of %s cannot be listed without a REST API", projectKey)
+	}
+
+	entries, err := ioutil.ReadDir(directory)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to list the repositories in %s", directory)
+	}
+	var repoNames []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := git.PlainOpen(filepath.Join(directory, entry.Name())); err == nil {
+			repoNames = append(repoNames, strings.TrimSuffix(entry.Name(), ".git"))
+		}
+	}
+	return repoNames, nil
+}
+
+func (client *PlainGitClient) ListBranches(gitRepoConfig GitRepoConfig) ([]string, error) {
+	repoUrl := client.CreateScmRepoUrl(gitRepoConfig)
+	auth, err := client.gitAuth(repoUrl)
+	if err != nil {
+		return nil, err
+	}
+	return listRemoteBranches(repoUrl, auth)
+}
+
+// GetFileFromRepo clones the default branch of the repository into a temporary directory and opens the file in it
+func (client *PlainGitClient) GetFileFromRepo(filename string, gitRepoConfig GitRepoConfig) (file *os.File, err error) {
+	directory, err := client.CloneRepo(gitRepoConfig)
+	if err != nil {
+		return nil, err
+	}
+	file, err = os.Open(filepath.Join(directory, filepath.FromSlash(strings.TrimPrefix(filename, "/"))))
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to open %s in repository %s", filename, gitRepoConfig.GetRepoName())
+	}
+	return file, nil
+}
+
+func (client *PlainGitClient) InitialCommitProjectToRepo(baseDirectory string, gitRepoConfig GitRepoConfig) error {
+	repoUrl := client.CreateScmRepoUrl(gitRepoConfig)
+	auth, err := client.gitAuth(repoUrl)
+	if err != nil {
+		return err
+	}
+	author := object.Signature{Name: "Genesis API", Email: client.Config.Email}
+	err = initialCommitAndPush(baseDirectory, repoUrl, "Initial Commit by Genesis API", author, auth)
+	if err != nil {
+		return errors.Wrapf(err, "unable to push project %s", gitRepoConfig.GetRepoName())
+	}
+	return nil
+}
+
+func (client *PlainGitClient) CommitAndPushBranch(directoryPath, branchName, message string, gitRepoConfig GitRepoConfig) error {
+	auth, err := client.gitAuth(client.CreateScmRepoUrl(gitRepoConfig))
+	if err != nil {
+		return err
+	}
+	author := object.Signature{Name: "Genesis API", Email: client.Config.Email}
+	err = commitAndPushBranch(directoryPath, branchName, message, author, auth)
+	if err != nil {
+		return errors.Wrapf(err, "unable to push branch %s to repository %s", branchName, gitRepoConfig.GetRepoName())
+	}
+	return nil
+}
+
+func (client *PlainGitClient) InitRepo(gitRepoConfig GitRepoConfig) (directory string, err error) {
+	directory = "/tmp/" + getRandomHash(10) + "/"
+
+	_, err = git.PlainInit(directory, false)
+	if err != nil {
+		return "", errors.Wrapf(err, "something happened while running `git init` for project %s", gitRepoConfig.GetRepoName())
+	}
+
+	return directory, nil
+}
+
+// CreateScmRepoUrl returns the URL of the repository
+func (client *PlainGitClient) CreateScmRepoUrl(config GitRepoConfig) string {
+	return config.ConstructRepoUrl("")
+}
+
+// CreateNewRemoteRepo does not create a repository, but verifies that the repository exists and is empty,
+// and returns its URL
+func (client *PlainGitClient) CreateNewRemoteRepo(gitRepoConfig GitRepoConfig) (fullRepoUrl string, err error) {
+	repoUrl := client.CreateScmRepoUrl(gitRepoConfig)
+	references, err := client.listReferences(repoUrl)
+	if err == transport.ErrRepositoryNotFound {
+		return "", errors.Errorf("the repository %s does not exist, and must be created before a project is pushed to it", repoUrl)
+	}
+	if err == transport.ErrEmptyRemoteRepository {
+		return repoUrl, nil
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to determine if the repository %s is empty", repoUrl)
+	}
+	for _, reference := range references {
+		if reference.Name().IsBranch() || reference.Name().IsTag() {
+			return "", errors.Errorf("the repository %s is not empty", repoUrl)
+		}
+	}
+	return repoUrl, nil
+}
+
+func (client *PlainGitClient) CloneRepo(gitRepoConfig GitRepoConfig) (directoryPath string, err error) {
+	return client.cloneRepo(gitRepoConfig, "")
+}
+
+func (client *PlainGitClient) CheckoutBranch(branchName string, gitRepoConfig GitRepoConfig) (directoryPath string, err error) {
+	return client.cloneRepo(gitRepoConfig, plumbing.NewBranchReferenceName(branchName))
+}
+
+func (client *PlainGitClient) CheckoutTag(tagName string, gitRepoConfig GitRepoConfig) (directoryPath string, err error) {
+	return client.cloneRepo(gitRepoConfig, tagReference(tagName))
+}
+
+func (client *PlainGitClient) RepoExists(gitRepoConfig GitRepoConfig) (exists bool, err error) {
+	_, err = client.listReferences(client.CreateScmRepoUrl(gitRepoConfig))
+	if err == transport.ErrRepositoryNotFound {
+		return false, nil
+	}
+	if err != nil && err != transport.ErrEmptyRemoteRepository {
+		return false, errors.Wrapf(err, "unable to determine if the repository exists")
+	}
+	return true, nil
+}
+
+// CreateWebhook is not supported, as webhooks are configured on the git server
+func (client *PlainGitClient) CreateWebhook(url string, gitConfig GitRepoConfig) error {
+	return errors.Errorf("webhooks cannot be created for repository %s without a REST API", gitConfig.GetRepoName())
+}
+
+// AddAdminRights does nothing, as access to the repository is managed by the git server
+func (client *PlainGitClient) AddAdminRights(userID string, gitRepoConfig GitRepoConfig) error {
+	return nil
+}
+
+func (client *PlainGitClient) cloneRepo(gitRepoConfig GitRepoConfig, reference plumbing.ReferenceName) (string, error) {
+	exists, err := client.RepoExists(gitRepoConfig)
+	if err != nil {
+		return "", err
+	}
+
+	if !exists {
+		return "", ErrRepoNotExist
+	}
+
+	repoUrl := client.CreateScmRepoUrl(gitRepoConfig)
+	auth, err := client.gitAuth(repoUrl)
+	if err != nil {
+		return "", err
+	}
+	return cloneToTempDirectory(repoUrl, reference, auth)
+}
+
+// listReferences returns the references of a remote repository, like `git ls-remote`. The errors of the
+// transport, such as transport.ErrRepositoryNotFound, are returned unwrapped.
+func (client *PlainGitClient) listReferences(repoUrl string) ([]*plumbing.Reference, error) {
+	auth, err := client.gitAuth(repoUrl)
+	if err != nil {
+		return nil, err
+	}
+	repository, err := git.Init(memory.NewStorage(), nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "something happened while creating an in-memory repository")
+	}
+	remote, err := repository.CreateRemote(&config.RemoteConfig{
+		Name: git.DefaultRemoteName,
+		URLs: []string{repoUrl},
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "something happened while creating remote %s", repoUrl)
+	}
+	return remote.List(&git.ListOptions{Auth: auth})
+}
+
+// gitAuth returns the credentials for the protocol of the URL. SSH uses the key file when one is configured,
+// or else the SSH agent, and local repositories need no credentials.
+func (client *PlainGitClient) gitAuth(repoUrl string) (transport.AuthMethod, error) {
+	endpoint, err := transport.NewEndpoint(repoUrl)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid repository url %s", repoUrl)
+	}
+
+	switch endpoint.Protocol {
+	case "http", "https":
+		if client.Config.Username == "" {
+			return nil, nil
+		}
+		return &gitHttp.BasicAuth{Username: client.Config.Username, Password: client.Config.Password}, nil
+	case "ssh":
+		if client.Config.SshKeyPath == "" {
+			return nil, nil
+		}
+		user := endpoint.User
+		if user == "" {
+			user = client.Config.Username
+		}
+		if user == "" {
+			user = "git"
+		}
+		auth, err := gitSsh.NewPublicKeysFromFile(user, client.Config.SshKeyPath, client.Config.SshKeyPassword)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read ssh key %s", client.Config.SshKeyPath)
+		}
+		return auth, nil
+	}
+	return nil, nil
+}
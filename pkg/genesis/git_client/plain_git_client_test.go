@@ -0,0 +1,129 @@
+package git_client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4"
+)
+
+// newBareRepo creates an empty bare repository in a temporary directory and returns its path
+func newBareRepo(t *testing.T, directory, name string) string {
+	repoPath := filepath.Join(directory, name)
+	_, err := git.PlainInit(repoPath, true)
+	assert.Nil(t, err)
+	return repoPath
+}
+
+func newTestPlainGitClient() *PlainGitClient {
+	client := NewPlainGitClient(&PlainGitClientConfig{Email: "genesis@example.com"})
+	return &client
+}
+
+func TestPlainGitClient_PushAndClone(t *testing.T) {
+	directory, err := ioutil.TempDir("", "plain-git")
+	assert.Nil(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(directory) })
+	client := newTestPlainGitClient()
+
+	repoConfig, err := NewPlainGitRepoConfig("file://" + newBareRepo(t, directory, "orders.git"))
+	assert.Nil(t, err)
+	repoUrl, err := client.CreateNewRemoteRepo(repoConfig)
+	assert.Nil(t, err)
+	assert.Equal(t, repoConfig.Url, repoUrl)
+
+	project, err := ioutil.TempDir("", "plain-git-project")
+	assert.Nil(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(project) })
+	assert.Nil(t, os.MkdirAll(filepath.Join(project, "config"), 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(project, "config", "app.yml"), []byte("port: 8080\n"), 0644))
+	assert.Nil(t, client.InitialCommitProjectToRepo(project, repoConfig))
+
+	_, err = client.CreateNewRemoteRepo(repoConfig)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "is not empty")
+
+	branches, err := client.ListBranches(repoConfig)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"master"}, branches)
+
+	file, err := client.GetFileFromRepo("config/app.yml", repoConfig)
+	assert.Nil(t, err)
+	content, _ := ioutil.ReadAll(file)
+	_ = file.Close()
+	assert.Equal(t, "port: 8080\n", string(content))
+
+	// a bare repository is also addressed by its path
+	clonePath, err := client.CheckoutBranch("master", &PlainGitRepoConfig{Url: filepath.Join(directory, "orders.git")})
+	assert.Nil(t, err)
+	_, err = os.Stat(filepath.Join(clonePath, "config", "app.yml"))
+	assert.Nil(t, err)
+
+	_, err = client.CheckoutTag("v1.0.0", repoConfig)
+	assert.Equal(t, ErrReferenceNotExist, errors.Cause(err), "a missing tag should be told apart from other clone errors")
+}
+
+func TestPlainGitClient_Errors(t *testing.T) {
+	directory, err := ioutil.TempDir("", "plain-git")
+	assert.Nil(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(directory) })
+	client := newTestPlainGitClient()
+	repoConfig := &PlainGitRepoConfig{Url: filepath.Join(directory, "missing.git")}
+
+	exists, err := client.RepoExists(repoConfig)
+	assert.Nil(t, err)
+	assert.False(t, exists)
+
+	_, err = client.CreateNewRemoteRepo(repoConfig)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "does not exist")
+
+	_, err = client.CloneRepo(repoConfig)
+	assert.Equal(t, ErrRepoNotExist, err)
+
+	assert.NotNil(t, client.CreateWebhook("https://jenkins.example.com/git/notifyCommit", repoConfig))
+	assert.Nil(t, client.AddAdminRights("jdoe", repoConfig))
+}
+
+func TestPlainGitClient_ListAllReposForProjectKey(t *testing.T) {
+	directory, err := ioutil.TempDir("", "plain-git")
+	assert.Nil(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(directory) })
+	newBareRepo(t, directory, "orders.git")
+	newBareRepo(t, directory, "payments.git")
+	assert.Nil(t, os.MkdirAll(filepath.Join(directory, "notes"), 0755))
+	client := newTestPlainGitClient()
+
+	repositories, err := client.ListAllReposForProjectKey(directory)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"orders", "payments"}, repositories)
+
+	_, err = client.ListAllReposForProjectKey("https://git.example.com/platform")
+	assert.NotNil(t, err)
+}
+
+func TestPlainGitRepoConfig(t *testing.T) {
+	for repoUrl, expected := range map[string][2]string{
+		"https://git.example.com/platform/orders.git": {"https://git.example.com/platform", "orders"},
+		"ssh://git@git.example.com:2222/orders":       {"ssh://git@git.example.com:2222", "orders"},
+		"git@git.example.com:orders.git":              {"git@git.example.com", "orders"},
+		"/srv/git/orders.git/":                        {"/srv/git", "orders"},
+	} {
+		repoConfig, err := NewPlainGitRepoConfig(repoUrl)
+		assert.Nil(t, err)
+		assert.True(t, repoConfig.Validate())
+		assert.Equal(t, expected[0], repoConfig.GetRepoDomain(), repoUrl)
+		assert.Equal(t, expected[1], repoConfig.GetRepoName(), repoUrl)
+	}
+
+	repoConfig := &PlainGitRepoConfig{Url: "git@git.example.com:orders.git"}
+	repoConfig.SetRepoName("payments")
+	assert.Equal(t, "git@git.example.com:payments.git", repoConfig.Url)
+
+	_, err := NewPlainGitRepoConfig("")
+	assert.NotNil(t, err)
+}
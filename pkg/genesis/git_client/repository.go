@@ -0,0 +1,214 @@
+package git_client
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/config"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+	"gopkg.in/src-d/go-git.v4/storage/memory"
+)
+
+// HeadReference returns the commit checked out in a cloned repository, and the name of the
+// branch when HEAD points to one
+func HeadReference(directoryPath string) (commit, branch string, err error) {
+	repository, err := git.PlainOpen(directoryPath)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "something happened while opening repository %s", directoryPath)
+	}
+	head, err := repository.Head()
+	if err != nil {
+		return "", "", errors.Wrapf(err, "something happened while resolving HEAD of repository %s", directoryPath)
+	}
+	if head.Name().IsBranch() {
+		branch = head.Name().Short()
+	}
+	return head.Hash().String(), branch, nil
+}
+
+// IsNotRepository reports whether the error is returned for a directory that is not a git repository, such as
+// a template extracted from a tarball
+func IsNotRepository(err error) bool {
+	return errors.Cause(err) == git.ErrRepositoryNotExists
+}
+
+// CheckoutCommit checks out a commit of a cloned repository, leaving HEAD detached
+func CheckoutCommit(directoryPath, commit string) error {
+	repository, err := git.PlainOpen(directoryPath)
+	if err != nil {
+		return errors.Wrapf(err, "something happened while opening repository %s", directoryPath)
+	}
+	worktree, err := repository.Worktree()
+	if err != nil {
+		return errors.Wrapf(err, "something happened while getting the worktree from the repository object")
+	}
+	err = worktree.Checkout(&git.CheckoutOptions{Hash: plumbing.NewHash(commit), Force: true})
+	if err != nil {
+		return errors.Wrapf(err, "something happened while checking out commit %s", commit)
+	}
+	return nil
+}
+
+// commitAndPushBranch commits every change in the worktree of a cloned repository, including
+// deleted files, to a new branch and pushes the branch to origin
+func commitAndPushBranch(directoryPath, branchName, message string, author object.Signature, auth transport.AuthMethod) error {
+	repository, err := git.PlainOpen(directoryPath)
+	if err != nil {
+		return errors.Wrapf(err, "something happened while opening repository %s", directoryPath)
+	}
+	worktree, err := repository.Worktree()
+	if err != nil {
+		return errors.Wrapf(err, "something happened while getting the worktree from the repository object")
+	}
+
+	status, err := worktree.Status()
+	if err != nil {
+		return errors.Wrapf(err, "something happened while running `git status` in %s", directoryPath)
+	}
+	for path, fileStatus := range status {
+		if fileStatus.Worktree == git.Deleted {
+			_, err = worktree.Remove(path)
+		} else {
+			_, err = worktree.Add(path)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "something happened while adding file %s to worktree", path)
+		}
+	}
+
+	author.When = time.Now()
+	commit, err := worktree.Commit(message, &git.CommitOptions{Author: &author})
+	if err != nil {
+		return errors.Wrapf(err, "something happened while running `git commit` in %s", directoryPath)
+	}
+
+	// point the new branch at the commit instead of moving the checked out branch
+	branch := plumbing.NewBranchReferenceName(branchName)
+	err = repository.Storer.SetReference(plumbing.NewHashReference(branch, commit))
+	if err != nil {
+		return errors.Wrapf(err, "something happened while creating branch %s", branchName)
+	}
+
+	err = repository.Push(&git.PushOptions{
+		RemoteName: git.DefaultRemoteName,
+		RefSpecs:   []config.RefSpec{config.RefSpec(branch + ":" + branch)},
+		Auth:       auth,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "something happened while running `git push` for branch %s", branchName)
+	}
+	return nil
+}
+
+// initialCommitAndPush initializes a repository in baseDirectory, commits every file in it and pushes
+// the commit to remoteUrl
+func initialCommitAndPush(baseDirectory, remoteUrl, message string, author object.Signature, auth transport.AuthMethod) error {
+	// check if directory exists
+	if _, err := os.Stat(baseDirectory); os.IsNotExist(err) {
+		return errors.Wrapf(err, "Base directory %s does not exist", baseDirectory)
+	}
+
+	// git init
+	repository, err := git.PlainInit(baseDirectory, false)
+	if err != nil {
+		return errors.Wrapf(err, "something happened while running `git init` in %s", baseDirectory)
+	}
+
+	// git remote add origin {URL}
+	_, err = repository.CreateRemote(&config.RemoteConfig{
+		Name: git.DefaultRemoteName,
+		URLs: []string{remoteUrl},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "something happened while creating remote %s", remoteUrl)
+	}
+
+	worktree, err := repository.Worktree()
+	if err != nil {
+		return errors.Wrapf(err, "something happened while getting the worktree from the repository object")
+	}
+
+	// recursively add files to commit
+	err = addFilesToGit(baseDirectory, worktree)
+	if err != nil {
+		return err
+	}
+
+	author.When = time.Now()
+	_, err = worktree.Commit(message, &git.CommitOptions{Author: &author})
+	if err != nil {
+		return errors.Wrapf(err, "something happened while running `git commit` for project")
+	}
+
+	err = repository.Push(&git.PushOptions{
+		RemoteName: git.DefaultRemoteName,
+		Auth:       auth,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "something happened while running `git push` to %s", remoteUrl)
+	}
+	return nil
+}
+
+// cloneToTempDirectory clones a repository into a new temporary directory. The reference, a branch or a tag,
+// is checked out when it is not empty.
+func cloneToTempDirectory(repoUrl string, reference plumbing.ReferenceName, auth transport.AuthMethod) (string, error) {
+	directory := "/tmp/" + getRandomHash(10) + "/"
+
+	options := &git.CloneOptions{URL: repoUrl, Auth: auth}
+	if reference != "" {
+		options.ReferenceName = reference
+		options.SingleBranch = true
+	}
+	repo, err := git.PlainClone(directory, false, options)
+	err = verifyRepo(repo, err)
+	if err != nil {
+		_ = os.RemoveAll(directory)
+		return "", err
+	}
+	return directory, nil
+}
+
+// isReferenceNotExist reports whether a clone failed because the remote has no such branch or tag
+func isReferenceNotExist(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "couldn't find remote ref")
+}
+
+// tagReference returns the reference name of a tag
+func tagReference(tagName string) plumbing.ReferenceName {
+	return plumbing.ReferenceName(fmt.Sprintf("refs/tags/%s", tagName))
+}
+
+// listRemoteBranches returns the names of the branches of a remote repository, like `git ls-remote --heads`
+func listRemoteBranches(repoUrl string, auth transport.AuthMethod) ([]string, error) {
+	repository, err := git.Init(memory.NewStorage(), nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "something happened while creating an in-memory repository")
+	}
+	remote, err := repository.CreateRemote(&config.RemoteConfig{
+		Name: git.DefaultRemoteName,
+		URLs: []string{repoUrl},
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "something happened while creating remote %s", repoUrl)
+	}
+	references, err := remote.List(&git.ListOptions{Auth: auth})
+	if err != nil {
+		return nil, errors.Wrapf(err, "something happened while listing the branches of %s", repoUrl)
+	}
+	var branches []string
+	for _, reference := range references {
+		if reference.Name().IsBranch() {
+			branches = append(branches, reference.Name().Short())
+		}
+	}
+	sort.Strings(branches)
+	return branches, nil
+}
@@ -0,0 +1,359 @@
+package git_client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	gitHttp "gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+)
+
+// RestBaseUrls are the web, REST API and clone URLs of a git provider with a REST API
+type RestBaseUrls struct {
+	WebBaseUrl   string `yaml:"web_base_url" json:"web_base_url"`
+	ApiBaseUrl   string `yaml:"api_base_url" json:"api_base_url"`
+	CloneBaseUrl string `yaml:"clone_base_url" json:"clone_base_url"`
+}
+
+// hostBaseUrl returns the web URL of a git host, https://{gitHost} unless gitHost includes the scheme
+func hostBaseUrl(gitHost string) string {
+	webBaseUrl := "https://" + gitHost
+	if strings.Contains(gitHost, "://") {
+		webBaseUrl = gitHost
+	}
+	return strings.TrimSuffix(webBaseUrl, "/")
+}
+
+// SetBaseUrls overrides the web, REST API and clone URLs that are not empty
+func (urls *RestBaseUrls) SetBaseUrls(webBaseUrl, apiBaseUrl, cloneBaseUrl string) {
+	if webBaseUrl != "" {
+		urls.WebBaseUrl = strings.TrimSuffix(webBaseUrl, "/")
+	}
+	if apiBaseUrl != "" {
+		urls.ApiBaseUrl = strings.TrimSuffix(apiBaseUrl, "/")
+	}
+	if cloneBaseUrl != "" {
+		urls.CloneBaseUrl = strings.TrimSuffix(cloneBaseUrl, "/")
+	}
+}
+
+func (urls *RestBaseUrls) baseUrls() *RestBaseUrls {
+	return urls
+}
+
+// restClientConfig is the configuration of a client of a git provider with a REST API
+type restClientConfig interface {
+	baseUrls() *RestBaseUrls
+	// apiHeaders are set on every request to the REST API, such as the authorization
+	apiHeaders() map[string]string
+	// gitAuth returns the credentials used to clone and push over HTTPS
+	gitAuth() *gitHttp.BasicAuth
+	// authorEmail is the email of the author of the commits pushed by Genesis
+	authorEmail() string
+}
+
+// restGitClient implements the parts of the GitClient interface that are the same for every git provider with
+// a REST API: repositories are looked up with the REST API, and cloned and pushed over HTTPS at the clone base URL.
+// The clients of the providers embed it and implement the remaining endpoints.
+type restGitClient struct {
+	RestClient *http.Client
+	// name of the provider, used in error messages
+	name   string
+	config restClientConfig
+}
+
+func newRestGitClient(name string, config restClientConfig) restGitClient {
+	return restGitClient{
+		RestClient: &http.Client{Timeout: time.Duration(10) * time.Second},
+		name:       name,
+		config:     config,
+	}
+}
+
+func (client *restGitClient) InitialCommitProjectToRepo(baseDirectory string, gitRepoConfig GitRepoConfig) error {
+	author := object.Signature{Name: "Genesis API", Email: client.config.authorEmail()}
+	err := initialCommitAndPush(baseDirectory, client.CreateScmRepoUrl(gitRepoConfig), "Initial Commit by Genesis API", author, client.gitAuth())
+	if err != nil {
+		return errors.Wrapf(err, "unable to push project %s", gitRepoConfig.GetRepoName())
+	}
+	return nil
+}
+
+func (client *restGitClient) CommitAndPushBranch(directoryPath, branchName, message string, gitRepoConfig GitRepoConfig) error {
+	author := object.Signature{Name: "Genesis API", Email: client.config.authorEmail()}
+	err := commitAndPushBranch(directoryPath, branchName, message, author, client.gitAuth())
+	if err != nil {
+		return errors.Wrapf(err, "unable to push branch %s to repository %s", branchName, gitRepoConfig.GetRepoName())
+	}
+	return nil
+}
+
+func (client *restGitClient) InitRepo(gitRepoConfig GitRepoConfig) (directory string, err error) {
+	directory = "/tmp/" + getRandomHash(10) + "/"
+
+	_, err = git.PlainInit(directory, false)
+	if err != nil {
+		return "", errors.Wrapf(err, "something happened while running `git init` for project %s", gitRepoConfig.GetRepoName())
+	}
+
+	return directory, nil
+}
+
+// {clone base}/{domain}/{repo_name}.git
+func (client *restGitClient) CreateScmRepoUrl(config GitRepoConfig) string {
+	return client.config.baseUrls().CloneBaseUrl + "/" + config.GetRepoDomain() + "/" + config.GetRepoName() + ".git"
+}
+
+func (client *restGitClient) CloneRepo(gitRepoConfig GitRepoConfig) (directoryPath string, err error) {
+	return client.cloneRepo(gitRepoConfig, "")
+}
+
+func (client *restGitClient) CheckoutBranch(branchName string, gitRepoConfig GitRepoConfig) (directoryPath string, err error) {
+	return client.cloneRepo(gitRepoConfig, plumbing.NewBranchReferenceName(branchName))
+}
+
+func (client *restGitClient) CheckoutTag(tagName string, gitRepoConfig GitRepoConfig) (directoryPath string, err error) {
+	return client.cloneRepo(gitRepoConfig, tagReference(tagName))
+}
+
+func (client *restGitClient) RepoExists(gitRepoConfig GitRepoConfig) (exists bool, err error) {
+	_, err = client.api().request("GET", gitRepoConfig.ConstructRestApiUrl(client.config.baseUrls().ApiBaseUrl), nil, nil, http.StatusOK)
+	if isNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "unable to determine if the repository exists")
+	}
+	return true, nil
+}
+
+func (client *restGitClient) cloneRepo(gitRepoConfig GitRepoConfig, reference plumbing.ReferenceName) (string, error) {
+	exists, err := client.RepoExists(gitRepoConfig)
+	if err != nil {
+		return "", err
+	}
+
+	if !exists {
+		return "", ErrRepoNotExist
+	}
+
+	return cloneToTempDirectory(client.CreateScmRepoUrl(gitRepoConfig), reference, client.gitAuth())
+}
+
+func (client *restGitClient) gitAuth() *gitHttp.BasicAuth {
+	return client.config.gitAuth()
+}
+
+func (client *restGitClient) apiUrl(path string) string {
+	return strings.TrimSuffix(client.config.baseUrls().ApiBaseUrl, "/") + path
+}
+
+// api returns the REST API of the provider, with the headers of the configuration
+func (client *restGitClient) api() restApi {
+	return restApi{
+		name:       client.name,
+		httpClient: client.RestClient,
+		headers:    client.config.apiHeaders(),
+	}
+}
+
+// restApi sends JSON requests to the REST API of a git provider
+type restApi struct {
+	// name of the provider, used in error messages
+	name       string
+	httpClient *http.Client
+	// headers set on every request, such as the authorization
+	headers map[string]string
+}
+
+// restError is returned for responses with an unexpected status
+type restError struct {
+	StatusCode int
+	message    string
+}
+
+func (e restError) Error() string {
+	return e.message
+}
+
+// isNotFound reports whether the error is a REST API response for a resource that does not exist
+func isNotFound(err error) bool {
+	cause, ok := errors.Cause(err).(restError)
+	return ok && cause.StatusCode == http.StatusNotFound
+}
+
+func (api restApi) newRequest(method, url string, payload interface{}) (*http.Request, error) {
+	var body io.Reader
+	if payload != nil {
+		jsonValue, err := json.Marshal(payload)
+		if err != nil {
+			return nil, errors.Wrapf(err, "problem marshaling request payload")
+		}
+		body = bytes.NewBuffer(jsonValue)
+	}
+	request, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid request url %s", url)
+	}
+	for key, value := range api.headers {
+		request.Header.Set(key, value)
+	}
+	if payload != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	return request, nil
+}
+
+// request sends a request and decodes the response into result, when it is not nil. Responses with a
+// status other than the expected ones are returned as a restError.
+func (api restApi) request(method, url string, payload, result interface{}, expected ...int) (*http.Response, error) {
+	request, err := api.newRequest(method, url, payload)
+	if err != nil {
+		return nil, err
+	}
+	return api.do(request, result, expected...)
+}
+
+func (api restApi) do(request *http.Request, result interface{}, expected ...int) (*http.Response, error) {
+	response, err := api.httpClient.Do(request)
+
+	if response != nil {
+		defer func() {
+			if err := response.Body.Close(); err != nil {
+				fmt.Printf("error closing response body %+v\n", err)
+			}
+		}()
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "Problem performing request to %s REST API %s", api.name, request.URL)
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return response, errors.Wrapf(err, "Problem reading result body")
+	}
+
+	ok := false
+	for _, code := range expected {
+		ok = ok || response.StatusCode == code
+	}
+	if !ok {
+		return response, api.responseError(response, body)
+	}
+
+	if result != nil {
+		err = json.Unmarshal(body, result)
+		if err != nil {
+			return response, errors.Wrapf(err, "Problem unmarshalling body")
+		}
+	}
+	return response, nil
+}
+
+// downloadFile sends a request for the raw content of a file and writes the response into a temporary directory.
+// The returned file is open at its start.
+func (api restApi) downloadFile(request *http.Request, filename string) (*os.File, error) {
+	response, err := api.httpClient.Do(request)
+	if err != nil {
+		return nil, errors.Wrapf(err, "something happened while downloading %s", filename)
+	}
+	defer func() {
+		if err := response.Body.Close(); err != nil {
+			fmt.Printf("error closing response body %+v\n", err)
+		}
+	}()
+	if response.StatusCode != http.StatusOK {
+		return nil, api.responseError(response, nil)
+	}
+
+	directory := "/tmp/" + getRandomHash(10) + "/"
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return nil, errors.Wrapf(err, "unable to create directory %s", directory)
+	}
+	file, err := os.Create(directory + path.Base(filename))
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to create file for %s", filename)
+	}
+	if _, err = io.Copy(file, response.Body); err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		_ = file.Close()
+		return nil, errors.Wrapf(err, "something happened while writing %s", filename)
+	}
+	return file, nil
+}
+
+// getAllPages appends the items of every page of a list endpoint to result, following the Link headers
+func (api restApi) getAllPages(pageUrl string, result interface{}) error {
+	var all []json.RawMessage
+	for pageUrl != "" {
+		var page []json.RawMessage
+		response, err := api.request("GET", pageUrl, nil, &page, http.StatusOK)
+		if err != nil {
+			return err
+		}
+		all = append(all, page...)
+		pageUrl = nextPageUrl(response.Header.Get("Link"))
+	}
+
+	content, err := json.Marshal(all)
+	if err != nil {
+		return errors.Wrapf(err, "Problem marshalling pages")
+	}
+	return json.Unmarshal(content, result)
+}
+
+var linkNextPattern = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// nextPageUrl returns the URL of the next page from a Link header, e.g. <https://api.github.com/...&page=2>; rel="next"
+func nextPageUrl(link string) string {
+	match := linkNextPattern.FindStringSubmatch(link)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// responseError returns an error with the status of a failed response, and the message of its body
+// when there is one, e.g. {"message": "Not Found"} or {"error": {"message": "..."}}
+func (api restApi) responseError(response *http.Response, body []byte) error {
+	message := response.Status
+	var errorResponse struct {
+		Message json.RawMessage `json:"message"`
+		Error   json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(body, &errorResponse) == nil {
+		detail := errorResponse.Message
+		if len(detail) == 0 {
+			detail = errorResponse.Error
+		}
+		var text string
+		var nested struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(detail, &text) != nil {
+			text = string(detail)
+			if json.Unmarshal(detail, &nested) == nil && nested.Message != "" {
+				text = nested.Message
+			}
+		}
+		if text != "" {
+			message = fmt.Sprintf("%s: %s", response.Status, text)
+		}
+	}
+	return restError{StatusCode: response.StatusCode, message: fmt.Sprintf("%s REST API returned %s", api.name, message)}
+}
@@ -0,0 +1,352 @@
+package git_client
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newRecordingServer returns a stand-in of a REST API that checks the authentication header of every request and
+// records the method, URI and body of the requests it receives, before passing them to the handler
+func newRecordingServer(t *testing.T, header, expected string, handler http.HandlerFunc) (*httptest.Server, *[]string) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, expected, r.Header.Get(header))
+		body, _ := ioutil.ReadAll(r.Body)
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		requests = append(requests, fmt.Sprintf("%s %s %s", r.Method, r.URL.RequestURI(), body))
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+// restResponse is the answer of a REST API stand-in to a request, in which {server} is replaced by the URL of the
+// stand-in. A zero status answers 200 OK.
+type restResponse struct {
+	status int
+	header map[string]string
+	body   string
+}
+
+// routeHandler answers the requests of the routes, keyed by method and URI, and any other request with 404 Not Found
+func routeHandler(routes map[string]restResponse) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response, ok := routes[r.Method+" "+r.URL.RequestURI()]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		server := "http://" + r.Host
+		for name, value := range response.header {
+			w.Header().Set(name, strings.Replace(value, "{server}", server, -1))
+		}
+		if response.status != 0 {
+			w.WriteHeader(response.status)
+		}
+		_, _ = w.Write([]byte(strings.Replace(response.body, "{server}", server, -1)))
+	}
+}
+
+// withRoute returns a copy of the routes in which the route answers the response
+func withRoute(routes map[string]restResponse, route string, response restResponse) map[string]restResponse {
+	copied := map[string]restResponse{route: response}
+	for key, value := range routes {
+		if key != route {
+			copied[key] = value
+		}
+	}
+	return copied
+}
+
+// restClientTest describes how a REST git client talks to its provider about the repository orders
+type restClientTest struct {
+	name       string
+	newClient  func(t *testing.T, handler http.HandlerFunc) (GitClient, *[]string)
+	repoConfig GitRepoConfig
+
+	// repoRoute answers whether the repository exists
+	repoRoute string
+	// createRoutes create the repository at repoUrl with the createRequests, createRoute being the last of them
+	createRoutes   map[string]restResponse
+	createRoute    string
+	createRequests []string
+	repoUrl        string
+	// createError answers createRoute when the provider refuses the repository, with the error createMessage
+	createError   restResponse
+	createMessage string
+
+	// listRoutes answer the repositories of projectKey in two pages
+	projectKey   string
+	listRoutes   map[string]restResponse
+	repositories []string
+
+	// adminRoutes grant admin rights to adminUser and add the webhook, but not to the user missing
+	adminUser     string
+	webhookUrl    string
+	adminRoutes   map[string]restResponse
+	adminRequests []string
+
+	// fileRoutes answer the branches main and develop and the file config/app.yml
+	fileRoutes map[string]restResponse
+}
+
+var restClientTests = []restClientTest{
+	{
+		name: "Gitea",
+		newClient: func(t *testing.T, handler http.HandlerFunc) (GitClient, *[]string) {
+			return newTestGiteaClient(t, handler)
+		},
+		repoConfig: &GiteaRepoConfig{Owner: "platform", RepositoryName: "orders"},
+
+		repoRoute: "GET /api/v1/repos/platform/orders",
+		createRoutes: map[string]restResponse{
+			"POST /api/v1/orgs/platform/repos": {status: http.StatusCreated, body: `{"id": 7, "name": "orders", "html_url": "https://gitea.example.com/platform/orders"}`},
+		},
+		createRoute: "POST /api/v1/orgs/platform/repos",
+		createRequests: []string{
+			"GET /api/v1/repos/platform/orders ",
+			`POST /api/v1/orgs/platform/repos {"name":"orders","private":true}`,
+		},
+		repoUrl:       "https://gitea.example.com/platform/orders",
+		createError:   restResponse{status: http.StatusConflict, body: `{"message": "The repository with the same name already exists."}`},
+		createMessage: "409 Conflict: The repository with the same name already exists.",
+
+		projectKey: "platform",
+		listRoutes: map[string]restResponse{
+			"GET /api/v1/orgs/platform/repos?limit=50": {
+				header: map[string]string{"Link": `<{server}/api/v1/orgs/platform/repos?limit=50&page=2>; rel="next"`},
+				body:   `[{"name": "orders"}, {"name": "payments"}]`,
+			},
+			"GET /api/v1/orgs/platform/repos?limit=50&page=2": {body: `[{"name": "refunds"}]`},
+		},
+		repositories: []string{"orders", "payments", "refunds"},
+
+		adminUser:  "asmith",
+		webhookUrl: "https://jenkins.example.com/gitea-webhook/post",
+		adminRoutes: map[string]restResponse{
+			"PUT /api/v1/repos/platform/orders/collaborators/asmith": {status: http.StatusNoContent},
+			"POST /api/v1/repos/platform/orders/hooks":               {status: http.StatusCreated},
+		},
+		adminRequests: []string{
+			`PUT /api/v1/repos/platform/orders/collaborators/asmith {"permission":"admin"}`,
+			`PUT /api/v1/repos/platform/orders/collaborators/missing {"permission":"admin"}`,
+			`POST /api/v1/repos/platform/orders/hooks {"type":"gitea","active":true,"events":["push","create"],"config":{"url":"https://jenkins.example.com/gitea-webhook/post","content_type":"json"}}`,
+		},
+
+		fileRoutes: map[string]restResponse{
+			"GET /api/v1/repos/platform/orders/branches?limit=50":  {body: `[{"name": "main"}, {"name": "develop"}]`},
+			"GET /api/v1/repos/platform/orders/raw/config/app.yml": {body: "port: 8080\n"},
+		},
+	},
+	{
+		name: "GitHub",
+		newClient: func(t *testing.T, handler http.HandlerFunc) (GitClient, *[]string) {
+			return newTestGitHubClient(t, handler)
+		},
+		repoConfig: &GithubRepoConfig{Domain: "att", RepositoryName: "orders"},
+
+		repoRoute: "GET /repos/att/orders",
+		createRoutes: map[string]restResponse{
+			"POST /orgs/att/repos": {status: http.StatusCreated, body: `{"name": "orders", "html_url": "https://github.com/att/orders"}`},
+		},
+		createRoute: "POST /orgs/att/repos",
+		createRequests: []string{
+			"GET /repos/att/orders ",
+			`POST /orgs/att/repos {"name":"orders","private":true,"auto_init":false}`,
+		},
+		repoUrl:       "https://github.com/att/orders",
+		createError:   restResponse{status: http.StatusUnprocessableEntity, body: `{"message": "Repository creation failed."}`},
+		createMessage: "422 Unprocessable Entity: Repository creation failed.",
+
+		projectKey: "att",
+		listRoutes: map[string]restResponse{
+			"GET /orgs/att/repos?per_page=100": {
+				header: map[string]string{"Link": `<{server}/orgs/att/repos?per_page=100&page=2>; rel="next", <{server}/orgs/att/repos?per_page=100&page=2>; rel="last"`},
+				body:   `[{"name": "orders"}, {"name": "payments"}]`,
+			},
+			"GET /orgs/att/repos?per_page=100&page=2": {body: `[{"name": "refunds"}]`},
+		},
+		repositories: []string{"orders", "payments", "refunds"},
+
+		adminUser:  "jdoe",
+		webhookUrl: "https://jenkins.example.com/github-webhook/",
+		adminRoutes: map[string]restResponse{
+			"PUT /repos/att/orders/collaborators/jdoe": {status: http.StatusCreated},
+			"POST /repos/att/orders/hooks":             {status: http.StatusCreated},
+		},
+		adminRequests: []string{
+			`PUT /repos/att/orders/collaborators/jdoe {"permission":"admin"}`,
+			`PUT /repos/att/orders/collaborators/missing {"permission":"admin"}`,
+			`POST /repos/att/orders/hooks {"name":"web","active":true,"events":["push","create"],"config":{"url":"https://jenkins.example.com/github-webhook/","content_type":"json"}}`,
+		},
+
+		fileRoutes: map[string]restResponse{
+			"GET /repos/att/orders/branches?per_page=100":   {body: `[{"name": "main"}, {"name": "develop"}]`},
+			"GET /repos/att/orders/contents/config/app.yml": {body: "port: 8080\n"},
+		},
+	},
+	{
+		name: "GitLab",
+		newClient: func(t *testing.T, handler http.HandlerFunc) (GitClient, *[]string) {
+			return newTestGitLabClient(t, handler)
+		},
+		repoConfig: &GitLabRepoConfig{Namespace: "platform/backend", ProjectPath: "orders"},
+
+		repoRoute: "GET /api/v4/projects/platform%2Fbackend%2Forders",
+		createRoutes: map[string]restResponse{
+			"GET /api/v4/namespaces/platform%2Fbackend": {body: `{"id": 42, "full_path": "platform/backend", "kind": "group"}`},
+			"POST /api/v4/projects":                     {status: http.StatusCreated, body: `{"id": 7, "web_url": "https://gitlab.example.com/platform/backend/orders"}`},
+		},
+		createRoute: "POST /api/v4/projects",
+		createRequests: []string{
+			"GET /api/v4/projects/platform%2Fbackend%2Forders ",
+			"GET /api/v4/namespaces/platform%2Fbackend ",
+			`POST /api/v4/projects {"name":"orders","path":"orders","namespace_id":42,"visibility":"private"}`,
+		},
+		repoUrl:       "https://gitlab.example.com/platform/backend/orders",
+		createError:   restResponse{status: http.StatusBadRequest, body: `{"message": {"path": ["has already been taken"]}}`},
+		createMessage: `400 Bad Request: {"path": ["has already been taken"]}`,
+
+		projectKey: "platform",
+		listRoutes: map[string]restResponse{
+			"GET /api/v4/groups/platform/projects?per_page=100&include_subgroups=true": {
+				header: map[string]string{"Link": `<{server}/api/v4/groups/platform/projects?page=2&per_page=100&include_subgroups=true>; rel="next"`},
+				body:   `[{"path": "orders", "path_with_namespace": "platform/orders"}]`,
+			},
+			"GET /api/v4/groups/platform/projects?page=2&per_page=100&include_subgroups=true": {body: `[{"path": "api", "path_with_namespace": "platform/backend/api"}]`},
+		},
+		repositories: []string{"orders", "backend/api"},
+
+		adminUser:  "asmith",
+		webhookUrl: "https://jenkins.example.com/project/orders",
+		adminRoutes: map[string]restResponse{
+			"GET /api/v4/users?username=asmith":                         {body: `[{"id": 5, "username": "asmith"}]`},
+			"GET /api/v4/users?username=missing":                        {body: `[]`},
+			"POST /api/v4/projects/platform%2Fbackend%2Forders/members": {status: http.StatusCreated},
+			"POST /api/v4/projects/platform%2Fbackend%2Forders/hooks":   {status: http.StatusCreated},
+		},
+		adminRequests: []string{
+			"GET /api/v4/users?username=asmith ",
+			`POST /api/v4/projects/platform%2Fbackend%2Forders/members {"user_id":5,"access_level":40}`,
+			"GET /api/v4/users?username=missing ",
+			`POST /api/v4/projects/platform%2Fbackend%2Forders/hooks {"url":"https://jenkins.example.com/project/orders","push_events":true,"tag_push_events":true,"enable_ssl_verification":true}`,
+		},
+
+		fileRoutes: map[string]restResponse{
+			"GET /api/v4/projects/platform%2Fbackend%2Forders/repository/branches?per_page=100":               {body: `[{"name": "main"}, {"name": "develop"}]`},
+			"GET /api/v4/projects/platform%2Fbackend%2Forders/repository/files/config%2Fapp.yml/raw?ref=HEAD": {body: "port: 8080\n"},
+		},
+	},
+	{
+		name: "BitBucketCloud",
+		newClient: func(t *testing.T, handler http.HandlerFunc) (GitClient, *[]string) {
+			return newTestBitBucketCloudClient(t, handler)
+		},
+		repoConfig: &BitBucketCloudRepoConfig{Workspace: "acme", ProjectKey: "APPS", RepositorySlug: "orders"},
+
+		repoRoute: "GET /2.0/repositories/acme/orders",
+		createRoutes: map[string]restResponse{
+			"GET /2.0/workspaces/acme/projects/APPS": {body: `{"key": "APPS"}`},
+			"POST /2.0/repositories/acme/orders":     {body: `{"slug": "orders", "links": {"html": {"href": "https://bitbucket.org/acme/orders"}}}`},
+		},
+		createRoute: "POST /2.0/repositories/acme/orders",
+		createRequests: []string{
+			"GET /2.0/repositories/acme/orders ",
+			"GET /2.0/workspaces/acme/projects/APPS ",
+			`POST /2.0/repositories/acme/orders {"scm":"git","is_private":true,"project":{"key":"APPS"}}`,
+		},
+		repoUrl:       "https://bitbucket.org/acme/orders",
+		createError:   restResponse{status: http.StatusBadRequest, body: `{"type": "error", "error": {"message": "Repository with this Slug and Owner already exists."}}`},
+		createMessage: "400 Bad Request: Repository with this Slug and Owner already exists.",
+
+		projectKey: "acme",
+		listRoutes: map[string]restResponse{
+			"GET /2.0/repositories/acme?pagelen=100":        {body: `{"values": [{"slug": "orders"}, {"slug": "payments"}], "next": "{server}/2.0/repositories/acme?pagelen=100&page=2"}`},
+			"GET /2.0/repositories/acme?pagelen=100&page=2": {body: `{"values": [{"slug": "refunds"}]}`},
+		},
+		repositories: []string{"orders", "payments", "refunds"},
+
+		adminUser:  "{5f1e}",
+		webhookUrl: "https://jenkins.example.com/bitbucket-hook/",
+		adminRoutes: map[string]restResponse{
+			"PUT /2.0/repositories/acme/orders/permissions-config/users/%7B5f1e%7D": {body: `{"permission": "admin"}`},
+			"POST /2.0/repositories/acme/orders/hooks":                              {status: http.StatusCreated},
+		},
+		adminRequests: []string{
+			`PUT /2.0/repositories/acme/orders/permissions-config/users/%7B5f1e%7D {"permission":"admin"}`,
+			`PUT /2.0/repositories/acme/orders/permissions-config/users/missing {"permission":"admin"}`,
+			`POST /2.0/repositories/acme/orders/hooks {"description":"Genesis API","url":"https://jenkins.example.com/bitbucket-hook/","active":true,"events":["repo:push"]}`,
+		},
+
+		fileRoutes: map[string]restResponse{
+			"GET /2.0/repositories/acme/orders/refs/branches?pagelen=100": {body: `{"values": [{"name": "main"}, {"name": "develop"}]}`},
+			"GET /2.0/repositories/acme/orders/src/HEAD/config/app.yml":   {body: "port: 8080\n"},
+		},
+	},
+}
+
+func TestRestGitClients(t *testing.T) {
+	for _, test := range restClientTests {
+		test := test
+		t.Run(test.name+"/CreateNewRemoteRepo", func(t *testing.T) {
+			client, requests := test.newClient(t, routeHandler(test.createRoutes))
+			repoUrl, err := client.CreateNewRemoteRepo(test.repoConfig)
+			assert.Nil(t, err)
+			assert.Equal(t, test.repoUrl, repoUrl)
+			assert.Equal(t, test.createRequests, *requests)
+		})
+
+		t.Run(test.name+"/CreateNewRemoteRepo_Exists", func(t *testing.T) {
+			client, requests := test.newClient(t, routeHandler(withRoute(test.createRoutes, test.repoRoute, restResponse{body: `{"id": 7}`})))
+			_, err := client.CreateNewRemoteRepo(test.repoConfig)
+			assert.NotNil(t, err)
+			assert.Contains(t, err.Error(), "already exists at "+test.repoUrl)
+			assert.Equal(t, test.createRequests[:1], *requests)
+		})
+
+		t.Run(test.name+"/CreateNewRemoteRepo_Refused", func(t *testing.T) {
+			client, _ := test.newClient(t, routeHandler(withRoute(test.createRoutes, test.createRoute, test.createError)))
+			_, err := client.CreateNewRemoteRepo(test.repoConfig)
+			assert.NotNil(t, err)
+			assert.Contains(t, err.Error(), test.createMessage)
+		})
+
+		t.Run(test.name+"/ListAllReposForProjectKey", func(t *testing.T) {
+			client, _ := test.newClient(t, routeHandler(test.listRoutes))
+			repositories, err := client.ListAllReposForProjectKey(test.projectKey)
+			assert.Nil(t, err)
+			assert.Equal(t, test.repositories, repositories)
+		})
+
+		t.Run(test.name+"/AddAdminRightsAndCreateWebhook", func(t *testing.T) {
+			client, requests := test.newClient(t, routeHandler(test.adminRoutes))
+			assert.Nil(t, client.AddAdminRights(test.adminUser, test.repoConfig))
+			assert.NotNil(t, client.AddAdminRights("missing", test.repoConfig))
+			assert.Nil(t, client.CreateWebhook(test.webhookUrl, test.repoConfig))
+			assert.Equal(t, test.adminRequests, *requests)
+		})
+
+		t.Run(test.name+"/ListBranchesAndGetFile", func(t *testing.T) {
+			client, _ := test.newClient(t, routeHandler(test.fileRoutes))
+			branches, err := client.ListBranches(test.repoConfig)
+			assert.Nil(t, err)
+			assert.Equal(t, []string{"main", "develop"}, branches)
+
+			file, err := client.GetFileFromRepo("config/app.yml", test.repoConfig)
+			assert.Nil(t, err)
+			content, _ := ioutil.ReadAll(file)
+			_ = file.Close()
+			assert.Equal(t, "port: 8080\n", string(content))
+
+			_, err = client.GetFileFromRepo("missing.yml", test.repoConfig)
+			assert.NotNil(t, err)
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package git_client
 
+import "encoding/json"
+
 type BitBucketRepoResponse struct {
 	Size       int                         `json:"size"`
 	Limit      int                         `json:"limit"`
@@ -36,3 +38,165 @@ func (b *BitBucketRepoResponse) GetRepositoryNames() []string {
 
 	return repoNames
 }
+
+type GitHubRepoRequest struct {
+	Name     string `json:"name"`
+	Private  bool   `json:"private"`
+	AutoInit bool   `json:"auto_init"`
+}
+
+type GitHubRepoResponseItem struct {
+	Id       int    `json:"id"`
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+	HtmlUrl  string `json:"html_url"`
+	CloneUrl string `json:"clone_url"`
+	Private  bool   `json:"private"`
+}
+
+type GitHubBranchResponseItem struct {
+	Name string `json:"name"`
+}
+
+type GitHubCollaboratorRequest struct {
+	Permission string `json:"permission"`
+}
+
+type GitHubWebHookRequest struct {
+	Name   string              `json:"name"`
+	Active bool                `json:"active"`
+	Events []string            `json:"events"`
+	Config GitHubWebHookConfig `json:"config"`
+}
+
+type GitHubWebHookConfig struct {
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+}
+
+type GitLabProjectRequest struct {
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	NamespaceId int    `json:"namespace_id,omitempty"`
+	Visibility  string `json:"visibility"`
+}
+
+type GitLabProjectResponseItem struct {
+	Id                int    `json:"id"`
+	Name              string `json:"name"`
+	Path              string `json:"path"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	WebUrl            string `json:"web_url"`
+	HttpUrlToRepo     string `json:"http_url_to_repo"`
+}
+
+type GitLabNamespaceResponseItem struct {
+	Id       int    `json:"id"`
+	Path     string `json:"path"`
+	FullPath string `json:"full_path"`
+	Kind     string `json:"kind"`
+}
+
+type GitLabUserResponseItem struct {
+	Id       int    `json:"id"`
+	Username string `json:"username"`
+}
+
+type GitLabMemberRequest struct {
+	UserId      int `json:"user_id"`
+	AccessLevel int `json:"access_level"`
+}
+
+type GitLabHookRequest struct {
+	URL                   string `json:"url"`
+	PushEvents            bool   `json:"push_events"`
+	TagPushEvents         bool   `json:"tag_push_events"`
+	EnableSslVerification bool   `json:"enable_ssl_verification"`
+}
+
+type GitLabBranchResponseItem struct {
+	Name string `json:"name"`
+}
+
+type GiteaRepoRequest struct {
+	Name    string `json:"name"`
+	Private bool   `json:"private"`
+}
+
+type GiteaRepoResponseItem struct {
+	Id       int    `json:"id"`
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+	HtmlUrl  string `json:"html_url"`
+	CloneUrl string `json:"clone_url"`
+}
+
+type GiteaCollaboratorRequest struct {
+	Permission string `json:"permission"`
+}
+
+type GiteaHookRequest struct {
+	Type   string          `json:"type"`
+	Active bool            `json:"active"`
+	Events []string        `json:"events"`
+	Config GiteaHookConfig `json:"config"`
+}
+
+type GiteaHookConfig struct {
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+}
+
+type GiteaBranchResponseItem struct {
+	Name string `json:"name"`
+}
+
+// BitBucketCloudPage is a page of a list endpoint of the BitBucket Cloud REST API
+type BitBucketCloudPage struct {
+	Values []json.RawMessage `json:"values"`
+	Next   string            `json:"next"`
+}
+
+type BitBucketCloudRepoRequest struct {
+	Scm       string                          `json:"scm"`
+	IsPrivate bool                            `json:"is_private"`
+	Project   *BitBucketCloudProjectReference `json:"project,omitempty"`
+}
+
+type BitBucketCloudProjectReference struct {
+	Key string `json:"key"`
+}
+
+type BitBucketCloudRepoResponseItem struct {
+	Slug     string `json:"slug"`
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+	Links    struct {
+		Html BitBucketCloudLink `json:"html"`
+	} `json:"links"`
+}
+
+type BitBucketCloudLink struct {
+	Href string `json:"href"`
+}
+
+type BitBucketCloudProjectRequest struct {
+	Key       string `json:"key"`
+	Name      string `json:"name"`
+	IsPrivate bool   `json:"is_private"`
+}
+
+type BitBucketCloudHookRequest struct {
+	Description string   `json:"description"`
+	URL         string   `json:"url"`
+	Active      bool     `json:"active"`
+	Events      []string `json:"events"`
+}
+
+type BitBucketCloudPermissionRequest struct {
+	Permission string `json:"permission"`
+}
+
+type BitBucketCloudBranchResponseItem struct {
+	Name string `json:"name"`
+}
@@ -6,15 +6,40 @@ import (
 	"github.com/att-cloudnative-labs/template-api/pkg/genesis/git_client"
 	"github.com/att-cloudnative-labs/template-api/pkg/genesis/template"
 	"github.com/pkg/errors"
+	"net/url"
+	"os"
 	"reflect"
+	"strings"
+	"time"
 )
 
 const github = "github"
 const bitbucket = "bitbucket"
+const gitlab = "gitlab"
+const gitea = "gitea"
+const bitbucketCloud = "bitbucket-cloud"
+const plainGit = "git"
+const local = "local"
 
 type TemplateOrchestrator struct {
 	RemoteTemplateMap map[string]git_client.GitRepoConfig
 	GitClientMap      map[string]git_client.GitClient
+	HookPolicy        template.HookPolicy
+	OptionsResolver   *template.OptionsUrlResolver
+}
+
+// GenerationReport describes the outcome of generating a project
+type GenerationReport struct {
+	RepoUrl string                `json:"repoUrl" yaml:"repoUrl"`
+	Hooks   []template.HookResult `json:"hooks,omitempty" yaml:"hooks,omitempty"`
+}
+
+// UpdateReport describes the branch pushed when a generated project is updated to a newer template version. Hooks
+// lists the results of the hooks of the previous version, followed by those of the new version.
+type UpdateReport struct {
+	Branch                string `json:"branch" yaml:"branch"`
+	template.UpdateResult `yaml:",inline"`
+	Hooks                 []template.HookResult `json:"hooks,omitempty" yaml:"hooks,omitempty"`
 }
 
 type TemplateName struct {
@@ -27,10 +52,82 @@ func NewTemplateOrchestrator(runtimeConfiguration *genesis_config.AppConfig) *Te
 	orchestrator.RemoteTemplateMap = make(map[string]git_client.GitRepoConfig)
 	orchestrator.GitClientMap = make(map[string]git_client.GitClient)
 	orchestrator.initTemplates(runtimeConfiguration.BitBucketTemplateRepositories, runtimeConfiguration.GitHubTemplateRepositories)
+	orchestrator.initGitLabTemplates(runtimeConfiguration.GitLabTemplateRepositories)
+	orchestrator.initGiteaTemplates(runtimeConfiguration.GiteaTemplateRepositories)
+	orchestrator.initBitBucketCloudTemplates(runtimeConfiguration.BitBucketCloudTemplateRepositories)
+	orchestrator.initGitTemplates(runtimeConfiguration.GitTemplateRepositories)
+	orchestrator.initLocalTemplates(runtimeConfiguration.LocalTemplateRepositories)
 	orchestrator.initClients(runtimeConfiguration)
+	orchestrator.HookPolicy = template.HookPolicy{
+		Enabled:    runtimeConfiguration.HooksEnabled,
+		AllowedEnv: runtimeConfiguration.HookEnvAllowList,
+		MaxTimeout: time.Duration(runtimeConfiguration.HookTimeout) * time.Second,
+	}
+	orchestrator.initOptionsResolver(time.Duration(runtimeConfiguration.OptionsCacheTime) * time.Second)
 	return orchestrator
 }
 
+// initOptionsResolver registers the built-in sources of form field options:
+//
+//	genesis://repos?project=KEY&provider=github lists the repositories of a BitBucket project, GitHub organization,
+//	GitLab group, Gitea organization, BitBucket Cloud workspace or, with provider git, a local directory
+//	genesis://branches?domain=KEY&repo=slug&provider=gitlab lists the branches of a repository
+//
+// GitHub sources take the host of a configured GitHub instance with &host=
+func (templateOrchestrator *TemplateOrchestrator) initOptionsResolver(cacheTime time.Duration) {
+	templateOrchestrator.OptionsResolver = template.NewOptionsUrlResolver(nil, cacheTime)
+
+	templateOrchestrator.OptionsResolver.RegisterSource("repos", func(query url.Values) ([]template.SelectOption, error) {
+		projectKey := query.Get("project")
+		if projectKey == "" {
+			return nil, errors.Errorf("genesis://repos requires a project")
+		}
+		gitClient := templateOrchestrator.GitClientMap[bitbucket]
+		switch query.Get("provider") {
+		case github:
+			gitClient = templateOrchestrator.GitClientMap[gitHubClientName(query.Get("host"))]
+		case gitlab, gitea, bitbucketCloud, plainGit:
+			gitClient = templateOrchestrator.GitClientMap[query.Get("provider")]
+		}
+		if gitClient == nil {
+			return nil, errors.Errorf("git client %s %s is not configured", query.Get("provider"), query.Get("host"))
+		}
+		repositories, err := gitClient.ListAllReposForProjectKey(projectKey)
+		if err != nil {
+			return nil, err
+		}
+		return toSelectOptions(repositories), nil
+	})
+
+	templateOrchestrator.OptionsResolver.RegisterSource("branches", func(query url.Values) ([]template.SelectOption, error) {
+		domain := query.Get("domain")
+		if domain == "" {
+			domain = query.Get("project")
+		}
+		repository := template.GenesisGitRepository{Domain: domain, Name: query.Get("repo"), Provider: query.Get("provider"), Host: query.Get("host")}
+		if repository.Domain == "" || repository.Name == "" {
+			return nil, errors.Errorf("genesis://branches requires a domain and a repo")
+		}
+		gitClient, repoConfig, err := templateOrchestrator.getRepositoryClient(repository)
+		if err != nil {
+			return nil, err
+		}
+		branches, err := gitClient.ListBranches(repoConfig)
+		if err != nil {
+			return nil, err
+		}
+		return toSelectOptions(branches), nil
+	})
+}
+
+func toSelectOptions(values []string) []template.SelectOption {
+	options := make([]template.SelectOption, len(values))
+	for i, value := range values {
+		options[i] = template.SelectOption{Value: value, DisplayValue: value}
+	}
+	return options
+}
+
 func (templateOrchestrator *TemplateOrchestrator) initTemplates(bitBucketTemplates []genesis_config.BitBucketTemplateRepository, gitHubTemplates []genesis_config.GitHubTemplateRepository) {
 
 	for _, bitBucketTemplate := range bitBucketTemplates {
@@ -48,10 +145,61 @@ func (templateOrchestrator *TemplateOrchestrator) initTemplates(bitBucketTemplat
 		if err != nil {
 			fmt.Printf("Error creating GithubRepoConfig: %+v\n", err)
 		}
+		gitHubRepoConfig.Host = gitHubTemplate.Host
 		templateOrchestrator.RemoteTemplateMap[gitHubTemplate.Name] = gitHubRepoConfig
 	}
 }
 
+func (templateOrchestrator *TemplateOrchestrator) initGitLabTemplates(gitLabTemplates []genesis_config.GitLabTemplateRepository) {
+	for _, gitLabTemplate := range gitLabTemplates {
+		gitLabRepoConfig, err := git_client.NewGitLabRepoConfig(gitLabTemplate.Namespace, gitLabTemplate.ProjectPath)
+		if err != nil {
+			fmt.Printf("Error creating GitLabRepoConfig: %+v\n", err)
+		}
+		templateOrchestrator.RemoteTemplateMap[gitLabTemplate.Name] = gitLabRepoConfig
+	}
+}
+
+func (templateOrchestrator *TemplateOrchestrator) initGiteaTemplates(giteaTemplates []genesis_config.GiteaTemplateRepository) {
+	for _, giteaTemplate := range giteaTemplates {
+		giteaRepoConfig, err := git_client.NewGiteaRepoConfig(giteaTemplate.Owner, giteaTemplate.RepoName)
+		if err != nil {
+			fmt.Printf("Error creating GiteaRepoConfig: %+v\n", err)
+		}
+		templateOrchestrator.RemoteTemplateMap[giteaTemplate.Name] = giteaRepoConfig
+	}
+}
+
+func (templateOrchestrator *TemplateOrchestrator) initBitBucketCloudTemplates(bitBucketCloudTemplates []genesis_config.BitBucketCloudTemplateRepository) {
+	for _, bitBucketCloudTemplate := range bitBucketCloudTemplates {
+		bitBucketCloudRepoConfig, err := git_client.NewBitBucketCloudRepoConfig(bitBucketCloudTemplate.Workspace, bitBucketCloudTemplate.RepositorySlug)
+		if err != nil {
+			fmt.Printf("Error creating BitBucketCloudRepoConfig: %+v\n", err)
+		}
+		templateOrchestrator.RemoteTemplateMap[bitBucketCloudTemplate.Name] = bitBucketCloudRepoConfig
+	}
+}
+
+func (templateOrchestrator *TemplateOrchestrator) initGitTemplates(gitTemplates []genesis_config.GitTemplateRepository) {
+	for _, gitTemplate := range gitTemplates {
+		gitRepoConfig, err := git_client.NewPlainGitRepoConfig(gitTemplate.Url)
+		if err != nil {
+			fmt.Printf("Error creating PlainGitRepoConfig: %+v\n", err)
+		}
+		templateOrchestrator.RemoteTemplateMap[gitTemplate.Name] = gitRepoConfig
+	}
+}
+
+func (templateOrchestrator *TemplateOrchestrator) initLocalTemplates(localTemplates []genesis_config.LocalTemplateRepository) {
+	for _, localTemplate := range localTemplates {
+		localTemplateConfig, err := git_client.NewLocalTemplateConfig(localTemplate.Path)
+		if err != nil {
+			fmt.Printf("Error creating LocalTemplateConfig: %+v\n", err)
+		}
+		templateOrchestrator.RemoteTemplateMap[localTemplate.Name] = localTemplateConfig
+	}
+}
+
 func (templateOrchestrator *TemplateOrchestrator) initClients(appConfig *genesis_config.AppConfig) {
 
 	bitBucketClientConfig, err := git_client.NewBitBucketClientConfig(
@@ -66,21 +214,87 @@ func (templateOrchestrator *TemplateOrchestrator) initClients(appConfig *genesis
 		fmt.Printf("Error while initializing BitBucketClientConfig: %+v\n", err)
 	}
 
-	gitHubConfig, err := git_client.NewGitClientConfig(
-		"github.com",
-		appConfig.GitHubUser,
-		appConfig.GitHubPassword,
-		appConfig.GitHubToken)
+	tempBitBucketClient := git_client.NewBitBucketClient(&bitBucketClientConfig)
+	templateOrchestrator.GitClientMap[bitbucket] = &tempBitBucketClient
+
+	gitHubHost := appConfig.GitHubHost
+	if gitHubHost == "" {
+		gitHubHost = "github.com"
+	}
+	templateOrchestrator.GitClientMap[github] = newGitHubClient(genesis_config.GitHubHost{
+		Host:     gitHubHost,
+		WebUrl:   appConfig.GitHubWebUrl,
+		ApiUrl:   appConfig.GitHubApiUrl,
+		CloneUrl: appConfig.GitHubCloneUrl,
+		User:     appConfig.GitHubUser,
+		Password: appConfig.GitHubPassword,
+		Token:    appConfig.GitHubToken,
+	})
+	for _, host := range appConfig.GitHubHosts {
+		templateOrchestrator.GitClientMap[gitHubClientName(host.Host)] = newGitHubClient(host)
+	}
+
+	if appConfig.GitLabUser != "" {
+		gitLabConfig, err := git_client.NewGitLabClientConfig(appConfig.GitLabHost, appConfig.GitLabUser, appConfig.GitLabToken)
+		if err != nil {
+			fmt.Printf("Error while initializing GitLabClientConfig: %+v\n", err)
+		}
+		gitLabConfig.SetBaseUrls("", appConfig.GitLabApiUrl, "")
+		tempGitLabClient := git_client.NewGitLabClient(&gitLabConfig)
+		templateOrchestrator.GitClientMap[gitlab] = &tempGitLabClient
+	}
+
+	if appConfig.GiteaUser != "" {
+		giteaConfig, err := git_client.NewGiteaClientConfig(appConfig.GiteaHost, appConfig.GiteaUser, appConfig.GiteaPassword, appConfig.GiteaToken)
+		if err != nil {
+			fmt.Printf("Error while initializing GiteaClientConfig: %+v\n", err)
+		}
+		giteaConfig.SetBaseUrls("", appConfig.GiteaApiUrl, "")
+		tempGiteaClient := git_client.NewGiteaClient(&giteaConfig)
+		templateOrchestrator.GitClientMap[gitea] = &tempGiteaClient
+	}
+
+	if appConfig.BitBucketCloudUser != "" || appConfig.BitBucketCloudToken != "" {
+		bitBucketCloudConfig, err := git_client.NewBitBucketCloudClientConfig(appConfig.BitBucketCloudUser, appConfig.BitBucketCloudAppPassword, appConfig.BitBucketCloudToken)
+		if err != nil {
+			fmt.Printf("Error while initializing BitBucketCloudClientConfig: %+v\n", err)
+		}
+		tempBitBucketCloudClient := git_client.NewBitBucketCloudClient(&bitBucketCloudConfig)
+		templateOrchestrator.GitClientMap[bitbucketCloud] = &tempBitBucketCloudClient
+	}
+
+	// plain git repositories need no REST API, and local repositories no credentials
+	tempPlainGitClient := git_client.NewPlainGitClient(&git_client.PlainGitClientConfig{
+		Username:       appConfig.GitUser,
+		Password:       appConfig.GitPassword,
+		SshKeyPath:     appConfig.GitSshKeyPath,
+		SshKeyPassword: appConfig.GitSshKeyPassword,
+		Email:          appConfig.GitUserEmail,
+	})
+	templateOrchestrator.GitClientMap[plainGit] = &tempPlainGitClient
+
+	tempLocalClient := git_client.NewLocalClient()
+	templateOrchestrator.GitClientMap[local] = &tempLocalClient
+}
+
+func newGitHubClient(host genesis_config.GitHubHost) *git_client.GitHubClient {
+	gitHubConfig, err := git_client.NewGitClientConfig(host.Host, host.User, host.Password, host.Token)
 
 	if err != nil {
-		fmt.Printf("Error while initializing GitHubClientConfig: %+v\n", err)
+		fmt.Printf("Error while initializing GitHubClientConfig for %s: %+v\n", host.Host, err)
 	}
+	gitHubConfig.SetBaseUrls(host.WebUrl, host.ApiUrl, host.CloneUrl)
 
-	tempBitBucketClient := git_client.NewBitBucketClient(&bitBucketClientConfig)
-	templateOrchestrator.GitClientMap[bitbucket] = &tempBitBucketClient
+	gitHubClient := git_client.NewGitHubClient(&gitHubConfig)
+	return &gitHubClient
+}
 
-	tempGitHubClient := git_client.NewGitHubClient(&gitHubConfig)
-	templateOrchestrator.GitClientMap[github] = &tempGitHubClient
+// gitHubClientName returns the name of the client of a GitHub host, the default client when the host is empty
+func gitHubClientName(host string) string {
+	if host == "" {
+		return github
+	}
+	return github + ":" + host
 }
 
 // Get the names of the available Genesis Templates
@@ -101,8 +315,13 @@ func (templateOrchestrator *TemplateOrchestrator) GetTemplateNames() ([]Template
 	return templateNames, nil
 }
 
+// GetTemplates parses the templates of a template repository. The clone of the repository is removed once parsed, so the
+// templates describe their options and forms, and are not rendered.
 func (templateOrchestrator *TemplateOrchestrator) GetTemplates(projectName string) ([]template.ProjectTemplate, error) {
 	templateRepoConfig := templateOrchestrator.RemoteTemplateMap[projectName]
+	if templateRepoConfig == nil {
+		return nil, errors.Errorf("the template name [%s] is invalid", projectName)
+	}
 	clientName, err := templateOrchestrator.getGitClient(templateRepoConfig)
 	if err != nil {
 		return nil, err
@@ -114,7 +333,8 @@ func (templateOrchestrator *TemplateOrchestrator) GetTemplates(projectName strin
 		return nil, err
 	}
 
-	genesisTemplateApi := template.NewGenesisTemplateApi(dirName)
+	genesisTemplateApi := templateOrchestrator.newTemplateApi(dirName)
+	defer cleanupTemplateApi(genesisTemplateApi)
 
 	genesisProject, err := genesisTemplateApi.GetProjectsFromRepo()
 
@@ -130,8 +350,13 @@ func (templateOrchestrator *TemplateOrchestrator) GetTemplates(projectName strin
 	return projectTemplates, nil
 }
 
+// GetTemplate parses a template of a template repository. The clone of the repository is removed once parsed, so the
+// template describes its options and form, and is not rendered.
 func (templateOrchestrator *TemplateOrchestrator) GetTemplate(projectName, templateName string) (template.ProjectTemplate, error) {
 	templateRepoConfig := templateOrchestrator.RemoteTemplateMap[projectName]
+	if templateRepoConfig == nil {
+		return &template.GenesisTemplate{}, errors.Errorf("the template name [%s] is invalid", projectName)
+	}
 	clientName, err := templateOrchestrator.getGitClient(templateRepoConfig)
 	if err != nil {
 		return &template.GenesisTemplate{}, err
@@ -143,7 +368,8 @@ func (templateOrchestrator *TemplateOrchestrator) GetTemplate(projectName, templ
 		return &template.GenesisTemplate{}, err
 	}
 
-	genesisTemplateApi := template.NewGenesisTemplateApi(dirName)
+	genesisTemplateApi := templateOrchestrator.newTemplateApi(dirName)
+	defer cleanupTemplateApi(genesisTemplateApi)
 
 	projectTemplate, err := genesisTemplateApi.GetProjectFromRepo(templateName)
 
@@ -154,6 +380,116 @@ func (templateOrchestrator *TemplateOrchestrator) GetTemplate(projectName, templ
 	return projectTemplate, nil
 }
 
+func cleanupTemplateApi(genesisTemplateApi *template.GenesisTemplateApi) {
+	err := genesisTemplateApi.Cleanup()
+	if err != nil {
+		fmt.Printf("failed to remove the cloned template repository, but moving on. Err: %+v\n", err)
+	}
+}
+
+// newTemplateApi creates a template api for a cloned directory that can resolve templates in other template repositories,
+// and the options of form fields
+func (templateOrchestrator *TemplateOrchestrator) newTemplateApi(dirName string) *template.GenesisTemplateApi {
+	genesisTemplateApi := template.NewGenesisTemplateApi(dirName)
+	genesisTemplateApi.Resolver = templateOrchestrator
+	if templateOrchestrator.OptionsResolver != nil {
+		genesisTemplateApi.OptionsResolver = templateOrchestrator.OptionsResolver
+	}
+	return genesisTemplateApi
+}
+
+// CloneTemplateRepository clones the template repository registered under templateKey
+func (templateOrchestrator *TemplateOrchestrator) CloneTemplateRepository(templateKey string) (string, error) {
+	templateRepoConfig := templateOrchestrator.RemoteTemplateMap[templateKey]
+	if templateRepoConfig == nil {
+		return "", errors.Errorf("the template name [%s] is invalid", templateKey)
+	}
+	clientName, err := templateOrchestrator.getGitClient(templateRepoConfig)
+	if err != nil {
+		return "", err
+	}
+	return templateOrchestrator.GitClientMap[clientName].CloneRepo(templateRepoConfig)
+}
+
+// FetchGitRepository clones a repository included by a template at its tag, or else its branch. Without a ref, the
+// default branch is cloned.
+func (templateOrchestrator *TemplateOrchestrator) FetchGitRepository(repository template.GenesisGitRepository) (string, error) {
+	gitClient, repoConfig, err := templateOrchestrator.getRepositoryClient(repository)
+	if err != nil {
+		return "", err
+	}
+	return checkoutRef(gitClient, repoConfig, repository.Ref)
+}
+
+// checkoutRef clones a repository at a tag, or else at a branch, or at its default branch when ref is empty. Only a
+// missing tag falls back to the branch, so that other errors, such as invalid credentials, are reported as they are.
+func checkoutRef(gitClient git_client.GitClient, repoConfig git_client.GitRepoConfig, ref string) (string, error) {
+	if ref == "" {
+		return gitClient.CloneRepo(repoConfig)
+	}
+	directoryPath, err := gitClient.CheckoutTag(ref, repoConfig)
+	if errors.Cause(err) != git_client.ErrReferenceNotExist {
+		return directoryPath, err
+	}
+	directoryPath, err = gitClient.CheckoutBranch(ref, repoConfig)
+	if errors.Cause(err) == git_client.ErrReferenceNotExist {
+		return "", errors.Errorf("%s is neither a tag nor a branch of repository %s", ref, repoConfig.GetRepoName())
+	}
+	return directoryPath, err
+}
+
+// getRepositoryClient returns the git client and configuration of a repository that is not a registered template repository
+func (templateOrchestrator *TemplateOrchestrator) getRepositoryClient(repository template.GenesisGitRepository) (git_client.GitClient, git_client.GitRepoConfig, error) {
+	var repoConfig git_client.GitRepoConfig
+	clientName := repository.Provider
+	switch clientName {
+	case "", bitbucket:
+		clientName = bitbucket
+		repoConfig = git_client.NewBitBucketRepoConfig(repository.Domain, repository.Name, "", "")
+	case github:
+		gitHubRepoConfig, err := git_client.NewGithubRepoConfig(repository.Domain, repository.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		gitHubRepoConfig.Host = repository.Host
+		clientName = gitHubClientName(repository.Host)
+		repoConfig = gitHubRepoConfig
+	case gitlab:
+		gitLabRepoConfig, err := git_client.NewGitLabRepoConfig(repository.Domain, repository.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		repoConfig = gitLabRepoConfig
+	case gitea:
+		giteaRepoConfig, err := git_client.NewGiteaRepoConfig(repository.Domain, repository.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		repoConfig = giteaRepoConfig
+	case bitbucketCloud:
+		bitBucketCloudRepoConfig, err := git_client.NewBitBucketCloudRepoConfig(repository.Domain, repository.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		repoConfig = bitBucketCloudRepoConfig
+	case plainGit:
+		// the domain is the URL up to the name of the repository
+		gitRepoConfig, err := git_client.NewPlainGitRepoConfig(strings.TrimSuffix(repository.Domain, "/") + "/" + repository.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		repoConfig = gitRepoConfig
+	default:
+		return nil, nil, errors.Errorf("git provider %s is not supported", repository.Provider)
+	}
+
+	gitClient := templateOrchestrator.GitClientMap[clientName]
+	if gitClient == nil {
+		return nil, nil, errors.Errorf("git client %s is not configured", clientName)
+	}
+	return gitClient, repoConfig, nil
+}
+
 func (templateOrchestrator *TemplateOrchestrator) GetListOfRepositoriesForProject(projectKey string) ([]string, error) {
 	gitClient := templateOrchestrator.GitClientMap[bitbucket]
 
@@ -162,57 +498,60 @@ func (templateOrchestrator *TemplateOrchestrator) GetListOfRepositoriesForProjec
 
 // Pulls a template repository, performs variable replacement, and commits new project to targetRepo
 // Template and Target repositories can be from different Git Hosts (eg. Template in BitBucket and Target in GitHub)
-func (templateOrchestrator *TemplateOrchestrator) GenerateFromTemplateAndCommit(userID, templateKey, templateName, jenkinsUrl string, optionsMap map[string]string, targetRepo git_client.GitRepoConfig, createWebhook bool) (repoUrl string, err error) {
+func (templateOrchestrator *TemplateOrchestrator) GenerateFromTemplateAndCommit(userID, templateKey, templateName, jenkinsUrl string, optionsMap template.OptionValues, targetRepo git_client.GitRepoConfig, createWebhook bool) (report GenerationReport, err error) {
 
 	targetGitClient, templateGitClient, templateRepoConfig, err := templateOrchestrator.getTargetClient(templateKey, targetRepo)
 
 	if err != nil {
-		return "", err
+		return GenerationReport{}, err
 	}
 
 	dirName, err := templateGitClient.CloneRepo(templateRepoConfig)
 
 	if err != nil {
-		return "", err
+		return GenerationReport{}, err
 	}
 
-	return templateOrchestrator.processTemplate(userID, dirName, templateName, jenkinsUrl, optionsMap, targetGitClient, targetRepo, createWebhook)
+	source := template.ManifestTemplate{Repository: templateKey, Name: templateName}
+	return templateOrchestrator.processTemplate(userID, dirName, source, jenkinsUrl, optionsMap, targetGitClient, targetRepo, createWebhook)
 }
 
 // Orchestrate a repository clone for a specific branch
-func (templateOrchestrator *TemplateOrchestrator) GenerateFromTemplateBranchAndCommit(userID, templateKey, templateName, branchName, jenkinsUrl string, optionsMap map[string]string, targetRepo git_client.GitRepoConfig, createWebhook bool) (repoUrl string, err error) {
+func (templateOrchestrator *TemplateOrchestrator) GenerateFromTemplateBranchAndCommit(userID, templateKey, templateName, branchName, jenkinsUrl string, optionsMap template.OptionValues, targetRepo git_client.GitRepoConfig, createWebhook bool) (report GenerationReport, err error) {
 
 	targetGitClient, templateGitClient, templateRepoConfig, err := templateOrchestrator.getTargetClient(templateKey, targetRepo)
 
 	if err != nil {
-		return "", err
+		return GenerationReport{}, err
 	}
 
 	dirName, err := templateGitClient.CheckoutBranch(branchName, templateRepoConfig)
 
 	if err != nil {
-		return "", err
+		return GenerationReport{}, err
 	}
 
-	return templateOrchestrator.processTemplate(userID, dirName, templateName, jenkinsUrl, optionsMap, targetGitClient, targetRepo, createWebhook)
+	source := template.ManifestTemplate{Repository: templateKey, Name: templateName, Ref: branchName}
+	return templateOrchestrator.processTemplate(userID, dirName, source, jenkinsUrl, optionsMap, targetGitClient, targetRepo, createWebhook)
 }
 
 // Orchestrate a repository clone for a specific tag
-func (templateOrchestrator *TemplateOrchestrator) GenerateFromTemplateTagAndCommit(userID, templateKey, templateName, tagName, jenkinsUrl string, optionsMap map[string]string, targetRepo git_client.GitRepoConfig, createWebhook bool) (repoUrl string, err error) {
+func (templateOrchestrator *TemplateOrchestrator) GenerateFromTemplateTagAndCommit(userID, templateKey, templateName, tagName, jenkinsUrl string, optionsMap template.OptionValues, targetRepo git_client.GitRepoConfig, createWebhook bool) (report GenerationReport, err error) {
 
 	targetGitClient, templateGitClient, templateRepoConfig, err := templateOrchestrator.getTargetClient(templateKey, targetRepo)
 
 	if err != nil {
-		return "", err
+		return GenerationReport{}, err
 	}
 
 	dirName, err := templateGitClient.CheckoutTag(tagName, templateRepoConfig)
 
 	if err != nil {
-		return "", err
+		return GenerationReport{}, err
 	}
 
-	return templateOrchestrator.processTemplate(userID, dirName, templateName, jenkinsUrl, optionsMap, targetGitClient, targetRepo, createWebhook)
+	source := template.ManifestTemplate{Repository: templateKey, Name: templateName, Ref: tagName}
+	return templateOrchestrator.processTemplate(userID, dirName, source, jenkinsUrl, optionsMap, targetGitClient, targetRepo, createWebhook)
 }
 
 func (templateOrchestrator *TemplateOrchestrator) getTargetClient(templateKey string, targetRepo git_client.GitRepoConfig) (targetGitClient git_client.GitClient, templateGitClient git_client.GitClient, templateRepoConfig git_client.GitRepoConfig, err error) {
@@ -249,31 +588,37 @@ func (templateOrchestrator *TemplateOrchestrator) getTargetClient(templateKey st
 	return targetGitClient, templateGitClient, templateRepoConfig, nil
 }
 
-func (templateOrchestrator *TemplateOrchestrator) processTemplate(userID, dirName, templateName, jenkinsUrl string, optionsMap map[string]string, targetGitClient git_client.GitClient, targetRepo git_client.GitRepoConfig, createWebhook bool) (repoUrl string, err error) {
-
-	genesisTemplateApi := template.NewGenesisTemplateApi(dirName)
-
-	projectTemplate, err := genesisTemplateApi.GetProjectFromRepo(templateName)
+func (templateOrchestrator *TemplateOrchestrator) processTemplate(userID, dirName string, source template.ManifestTemplate, jenkinsUrl string, optionsMap template.OptionValues, targetGitClient git_client.GitClient, targetRepo git_client.GitRepoConfig, createWebhook bool) (report GenerationReport, err error) {
 
+	// run hooks before creating the repository so that a failing hook does not leave an empty repository behind
+	rootPath, projectTemplate, hooks, err := templateOrchestrator.renderTemplate(templateOrchestrator.newTemplateApi(dirName), source.Name, optionsMap)
+	report.Hooks = hooks
 	if err != nil {
-		return "", err
+		return report, err
 	}
 
-	err = genesisTemplateApi.GenerateFromTemplate(projectTemplate, optionsMap)
-
+	// record the template version and options that produced the project. Templates read from a tarball
+	// have no commit.
+	commit, branch, err := git_client.HeadReference(dirName)
+	if err != nil && !git_client.IsNotRepository(err) {
+		fmt.Printf("failed to resolve the template commit, but moving on. Err: %+v", err)
+	}
+	source.Commit = commit
+	if source.Ref == "" {
+		source.Ref = branch
+	}
+	manifest, err := template.NewManifest(projectTemplate, source, rootPath)
 	if err != nil {
-		return "", err
+		return report, err
 	}
-
-	root, err := projectTemplate.GetRoot()
-
+	err = template.WriteManifest(rootPath, manifest)
 	if err != nil {
-		return "", err
+		return report, err
 	}
 
-	repoUrl, err = targetGitClient.CreateNewRemoteRepo(targetRepo)
+	report.RepoUrl, err = targetGitClient.CreateNewRemoteRepo(targetRepo)
 	if err != nil {
-		return "", err
+		return report, err
 	}
 
 	err = targetGitClient.AddAdminRights(userID, targetRepo)
@@ -282,35 +627,203 @@ func (templateOrchestrator *TemplateOrchestrator) processTemplate(userID, dirNam
 		fmt.Printf("failed to add admin rights, but moving on. Err: %+v", err)
 	}
 
-	err = targetGitClient.InitialCommitProjectToRepo(dirName+"/"+root, targetRepo)
+	err = targetGitClient.InitialCommitProjectToRepo(rootPath, targetRepo)
 	if err != nil {
-		return "", err
+		return report, err
 	}
 
 	if createWebhook {
 		err = targetGitClient.CreateWebhook(jenkinsUrl, targetRepo)
 
 		if err != nil {
-			return "", err
+			return report, err
 		}
 	}
 
-	return repoUrl, nil
+	return report, nil
+}
+
+// UpdateProject upgrades a generated project to the templateRef version of its template (the default branch
+// when empty). The template version recorded in the project's manifest and the new version are rendered with
+// the recorded options, updated by optionsMap, and the changes between them are merged into the project.
+// The result, including any conflict markers, is pushed to branchName for review.
+func (templateOrchestrator *TemplateOrchestrator) UpdateProject(targetRepo git_client.GitRepoConfig, templateRef, branchName string, optionsMap template.OptionValues) (report UpdateReport, err error) {
+	if !targetRepo.Validate() {
+		return report, errors.Errorf("target repository configuration is invalid")
+	}
+	targetGitClientName, err := templateOrchestrator.getGitClient(targetRepo)
+	if err != nil {
+		return report, err
+	}
+	targetGitClient := templateOrchestrator.GitClientMap[targetGitClientName]
+
+	projectDir, err := targetGitClient.CloneRepo(targetRepo)
+	if err != nil {
+		return report, err
+	}
+	defer os.RemoveAll(projectDir)
+	manifest, err := template.ReadManifest(projectDir)
+	if err != nil {
+		return report, err
+	}
+	options, err := manifest.UpdateOptions(optionsMap)
+	if err != nil {
+		return report, err
+	}
+
+	// render the version the project was generated from. Its hooks run as well, so that files created by hooks,
+	// such as a go.sum, are part of the merge base and are not reported as added by the new version.
+	previous := manifest.Template
+	var previousDir string
+	if previous.Commit != "" {
+		previousDir, err = templateOrchestrator.checkoutTemplate(previous.Repository, "")
+		if err == nil {
+			err = git_client.CheckoutCommit(previousDir, previous.Commit)
+		}
+	} else {
+		previousDir, err = templateOrchestrator.checkoutTemplate(previous.Repository, previous.Ref)
+	}
+	if previousDir != "" {
+		defer os.RemoveAll(previousDir)
+	}
+	if err != nil {
+		return report, err
+	}
+	previousApi := templateOrchestrator.newTemplateApi(previousDir)
+	defer cleanupTemplateApi(previousApi)
+	previousRoot, _, hooks, err := templateOrchestrator.renderTemplate(previousApi, previous.Name, options)
+	report.Hooks = append(report.Hooks, hooks...)
+	if err != nil {
+		return report, err
+	}
+
+	// render the new version
+	next := template.ManifestTemplate{Repository: previous.Repository, Name: previous.Name, Ref: templateRef}
+	nextDir, err := templateOrchestrator.checkoutTemplate(next.Repository, templateRef)
+	if err != nil {
+		return report, err
+	}
+	defer os.RemoveAll(nextDir)
+	nextApi := templateOrchestrator.newTemplateApi(nextDir)
+	defer cleanupTemplateApi(nextApi)
+	nextRoot, nextTemplate, hooks, err := templateOrchestrator.renderTemplate(nextApi, next.Name, options)
+	report.Hooks = append(report.Hooks, hooks...)
+	if err != nil {
+		return report, err
+	}
+
+	report.UpdateResult, err = template.MergeTemplateUpdate(previousRoot, nextRoot, projectDir)
+	if err != nil {
+		return report, err
+	}
+
+	commit, branch, err := git_client.HeadReference(nextDir)
+	if err != nil && !git_client.IsNotRepository(err) {
+		fmt.Printf("failed to resolve the template commit, but moving on. Err: %+v", err)
+	}
+	next.Commit = commit
+	if next.Ref == "" {
+		next.Ref = branch
+	}
+	nextManifest, err := template.NewManifest(nextTemplate, next, nextRoot)
+	if err != nil {
+		return report, err
+	}
+	err = template.WriteManifest(projectDir, nextManifest)
+	if err != nil {
+		return report, err
+	}
+
+	report.Branch = branchName
+	if report.Branch == "" {
+		report.Branch = "genesis-update-" + next.Ref
+	}
+	message := fmt.Sprintf("Update to %s template %s", next.Name, next.Ref)
+	if report.HasConflicts() {
+		message += fmt.Sprintf(" with %d conflicts", len(report.Conflicts))
+	}
+	err = targetGitClient.CommitAndPushBranch(projectDir, report.Branch, message, targetRepo)
+	if err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// checkoutTemplate clones the template repository registered under templateKey at a tag or branch,
+// or at its default branch when ref is empty
+func (templateOrchestrator *TemplateOrchestrator) checkoutTemplate(templateKey, ref string) (string, error) {
+	templateRepoConfig := templateOrchestrator.RemoteTemplateMap[templateKey]
+	if templateRepoConfig == nil {
+		return "", errors.Errorf("the template name [%s] is invalid", templateKey)
+	}
+	clientName, err := templateOrchestrator.getGitClient(templateRepoConfig)
+	if err != nil {
+		return "", err
+	}
+	return checkoutRef(templateOrchestrator.GitClientMap[clientName], templateRepoConfig, ref)
+}
+
+// renderTemplate renders a template of the cloned template repository of genesisTemplateApi and runs its hooks,
+// returning the rendered root
+func (templateOrchestrator *TemplateOrchestrator) renderTemplate(genesisTemplateApi *template.GenesisTemplateApi, templateName string, optionsMap template.OptionValues) (string, template.ProjectTemplate, []template.HookResult, error) {
+	projectTemplate, err := genesisTemplateApi.GetProjectFromRepo(templateName)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	err = genesisTemplateApi.GenerateFromTemplate(projectTemplate, optionsMap)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	root, err := projectTemplate.GetRoot()
+	if err != nil {
+		return "", nil, nil, err
+	}
+	rootPath := genesisTemplateApi.DirectoryPath + "/" + root
+	validatedOptions, err := projectTemplate.GetValidatedOptions()
+	if err != nil {
+		return "", nil, nil, err
+	}
+	hooks, err := template.RunHooks(rootPath, projectTemplate.GetHooks().PostRender, validatedOptions, templateOrchestrator.HookPolicy)
+	if err != nil {
+		return "", nil, hooks, err
+	}
+	return rootPath, projectTemplate, hooks, nil
 }
 
 func (templateOrchestrator *TemplateOrchestrator) getGitClient(gitRepoConfig git_client.GitRepoConfig) (string, error) {
 	bitBucketClz := reflect.TypeOf(git_client.BitBucketRepoConfig{}).Name()
 	gitHubClz := reflect.TypeOf(git_client.GithubRepoConfig{}).Name()
+	gitLabClz := reflect.TypeOf(git_client.GitLabRepoConfig{}).Name()
+	giteaClz := reflect.TypeOf(git_client.GiteaRepoConfig{}).Name()
+	bitBucketCloudClz := reflect.TypeOf(git_client.BitBucketCloudRepoConfig{}).Name()
+	plainGitClz := reflect.TypeOf(git_client.PlainGitRepoConfig{}).Name()
+	localClz := reflect.TypeOf(git_client.LocalTemplateConfig{}).Name()
 	myClz := reflect.TypeOf(gitRepoConfig)
 
+	var clientName string
 	switch myClz.Elem().Name() {
 	case bitBucketClz:
-		return bitbucket, nil
+		clientName = bitbucket
 	case gitHubClz:
-		return github, nil
+		clientName = gitHubClientName(gitRepoConfig.(*git_client.GithubRepoConfig).Host)
+	case gitLabClz:
+		clientName = gitlab
+	case giteaClz:
+		clientName = gitea
+	case bitBucketCloudClz:
+		clientName = bitbucketCloud
+	case plainGitClz:
+		clientName = plainGit
+	case localClz:
+		clientName = local
 	default:
 		return "", errors.Errorf("git client is not supported for repo %s", gitRepoConfig.GetRepoName())
 	}
+
+	if templateOrchestrator.GitClientMap[clientName] == nil {
+		return "", errors.Errorf("git client %s is not configured", clientName)
+	}
+	return clientName, nil
 }
 
 // TODO - how to version template repositories, advertise supported versions, and expose versions to template api
@@ -0,0 +1,241 @@
+package genesis
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/att-cloudnative-labs/template-api/pkg/genesis/git_client"
+	"github.com/att-cloudnative-labs/template-api/pkg/genesis/template"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4"
+)
+
+const testGenesisYaml = `projects:
+  - name: "Service"
+    root: "base"
+    language:
+      name: "Go"
+      version: "1.14"
+    options:
+      - name: "service_name"
+        required: true
+`
+
+// newTestRepositories creates a bare repository with a template, and an empty bare target repository
+func newTestRepositories(t *testing.T, client git_client.GitClient) (templateRepo, targetRepo *git_client.PlainGitRepoConfig) {
+	directory, err := ioutil.TempDir("", "orchestrator")
+	assert.Nil(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(directory) })
+
+	for _, name := range []string{"template.git", "target.git"} {
+		_, err = git.PlainInit(filepath.Join(directory, name), true)
+		assert.Nil(t, err)
+	}
+	templateRepo = &git_client.PlainGitRepoConfig{Url: filepath.Join(directory, "template.git")}
+	targetRepo = &git_client.PlainGitRepoConfig{Url: "file://" + filepath.Join(directory, "target.git")}
+
+	workDirectory := filepath.Join(directory, "work")
+	writeTestTemplate(t, workDirectory)
+	assert.Nil(t, client.InitialCommitProjectToRepo(workDirectory, templateRepo))
+	return templateRepo, targetRepo
+}
+
+// writeTestTemplate writes a template with a README.md rendered from the service_name option
+func writeTestTemplate(t *testing.T, directory string) {
+	files := map[string]string{
+		".genesis.yml":   testGenesisYaml,
+		"base/README.md": "# {{service_name}}\n",
+	}
+	for name, content := range files {
+		assert.Nil(t, os.MkdirAll(filepath.Dir(filepath.Join(directory, name)), 0755))
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(directory, name), []byte(content), 0644))
+	}
+}
+
+func TestTemplateOrchestrator_GenerateFromLocalRepositories(t *testing.T) {
+	client := git_client.NewPlainGitClient(&git_client.PlainGitClientConfig{Email: "genesis@example.com"})
+	templateRepo, targetRepo := newTestRepositories(t, &client)
+	orchestrator := &TemplateOrchestrator{
+		RemoteTemplateMap: map[string]git_client.GitRepoConfig{"Local Templates": templateRepo},
+		GitClientMap:      map[string]git_client.GitClient{plainGit: &client},
+	}
+
+	options := template.OptionValues{"service_name": "orders"}
+	report, err := orchestrator.GenerateFromTemplateAndCommit("jdoe", "Local Templates", "Service", "", options, targetRepo, false)
+	assert.Nil(t, err)
+	assert.Equal(t, targetRepo.Url, report.RepoUrl)
+
+	projectDirectory, err := client.CloneRepo(targetRepo)
+	assert.Nil(t, err)
+	readme, err := ioutil.ReadFile(filepath.Join(projectDirectory, "README.md"))
+	assert.Nil(t, err)
+	assert.Equal(t, "# orders\n", string(readme))
+	_, err = os.Stat(filepath.Join(projectDirectory, ".genesis", "answers.yml"))
+	assert.Nil(t, err)
+
+	// the target repository is no longer empty
+	_, err = orchestrator.GenerateFromTemplateAndCommit("jdoe", "Local Templates", "Service", "", options, targetRepo, false)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "is not empty")
+}
+
+func TestTemplateOrchestrator_GenerateFromLocalDirectory(t *testing.T) {
+	gitClient := git_client.NewPlainGitClient(&git_client.PlainGitClientConfig{Email: "genesis@example.com"})
+	localClient := git_client.NewLocalClient()
+	_, targetRepo := newTestRepositories(t, &gitClient)
+	templateDirectory, err := ioutil.TempDir("", "local-template")
+	assert.Nil(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(templateDirectory) })
+	writeTestTemplate(t, templateDirectory)
+
+	templateConfig, err := git_client.NewLocalTemplateConfig(templateDirectory)
+	assert.Nil(t, err)
+	orchestrator := &TemplateOrchestrator{
+		RemoteTemplateMap: map[string]git_client.GitRepoConfig{"Local Templates": templateConfig},
+		GitClientMap:      map[string]git_client.GitClient{plainGit: &gitClient, local: &localClient},
+	}
+
+	names, err := orchestrator.GetTemplateNames()
+	assert.Nil(t, err)
+	assert.Equal(t, []TemplateName{{Name: "Local Templates", ProjectNames: []string{"Service"}}}, names)
+
+	options := template.OptionValues{"service_name": "orders"}
+	_, err = orchestrator.GenerateFromTemplateAndCommit("jdoe", "Local Templates", "Service", "", options, targetRepo, false)
+	assert.Nil(t, err)
+
+	projectDirectory, err := gitClient.CloneRepo(targetRepo)
+	assert.Nil(t, err)
+	readme, err := ioutil.ReadFile(filepath.Join(projectDirectory, "README.md"))
+	assert.Nil(t, err)
+	assert.Equal(t, "# orders\n", string(readme))
+
+	// the template is rendered from a copy
+	readme, err = ioutil.ReadFile(filepath.Join(templateDirectory, "base", "README.md"))
+	assert.Nil(t, err)
+	assert.Equal(t, "# {{service_name}}\n", string(readme))
+}
+
+func TestTemplateOrchestrator_FetchGitRepositoryDefaultBranch(t *testing.T) {
+	client := git_client.NewPlainGitClient(&git_client.PlainGitClientConfig{Email: "genesis@example.com"})
+	templateRepo, _ := newTestRepositories(t, &client)
+	orchestrator := &TemplateOrchestrator{GitClientMap: map[string]git_client.GitClient{plainGit: &client}}
+
+	repository := template.GenesisGitRepository{Provider: plainGit, Domain: filepath.Dir(templateRepo.Url), Name: "template.git"}
+	directoryPath, err := orchestrator.FetchGitRepository(repository)
+	assert.Nil(t, err)
+	_, err = os.Stat(filepath.Join(directoryPath, "base", "README.md"))
+	assert.Nil(t, err)
+
+	repository.Ref = "v1.0.0"
+	_, err = orchestrator.FetchGitRepository(repository)
+	assert.NotNil(t, err, "there should be an error because the ref does not exist")
+	assert.Contains(t, err.Error(), "v1.0.0 is neither a tag nor a branch")
+
+	// other errors are not reported as a missing ref
+	repository.Name = "missing.git"
+	_, err = orchestrator.FetchGitRepository(repository)
+	assert.Equal(t, git_client.ErrRepoNotExist, errors.Cause(err))
+}
+
+func TestTemplateOrchestrator_UpdateProject(t *testing.T) {
+	plainGitClient := git_client.NewPlainGitClient(&git_client.PlainGitClientConfig{Email: "genesis@example.com"})
+	client := &cloneRecordingClient{PlainGitClient: &plainGitClient}
+	templateRepo, targetRepo := newTestRepositories(t, client)
+	orchestrator := &TemplateOrchestrator{
+		RemoteTemplateMap: map[string]git_client.GitRepoConfig{"Local Templates": templateRepo},
+		GitClientMap:      map[string]git_client.GitClient{plainGit: client},
+	}
+	_, err := orchestrator.GenerateFromTemplateAndCommit("jdoe", "Local Templates", "Service", "", template.OptionValues{"service_name": "orders"}, targetRepo, false)
+	assert.Nil(t, err)
+
+	// the project adds its own file
+	projectDirectory, err := client.CloneRepo(targetRepo)
+	assert.Nil(t, err)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(projectDirectory, "NOTES.md"), []byte("notes\n"), 0644))
+	assert.Nil(t, client.CommitAndPushBranch(projectDirectory, "master", "Add notes", targetRepo))
+
+	// the template changes the README.md and adds a file in a new version
+	templateDirectory, err := client.CloneRepo(templateRepo)
+	assert.Nil(t, err)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(templateDirectory, "base", "README.md"), []byte("# {{service_name}}\n\nVersion 2\n"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(templateDirectory, "base", "VERSION"), []byte("2\n"), 0644))
+	assert.Nil(t, client.CommitAndPushBranch(templateDirectory, "v2", "Release v2", templateRepo))
+
+	client.clones = nil
+	report, err := orchestrator.UpdateProject(targetRepo, "v2", "", template.OptionValues{})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(client.clones), "the project and both template versions should be cloned")
+	for _, directoryPath := range client.clones {
+		_, err = os.Stat(directoryPath)
+		assert.True(t, os.IsNotExist(err), "the clones should be removed once the update is pushed")
+	}
+	assert.Equal(t, "genesis-update-v2", report.Branch)
+	assert.Equal(t, []string{"README.md"}, report.Updated)
+	assert.Equal(t, []string{"VERSION"}, report.Added)
+	assert.False(t, report.HasConflicts())
+
+	updatedDirectory, err := client.CheckoutBranch("genesis-update-v2", targetRepo)
+	assert.Nil(t, err)
+	readme, err := ioutil.ReadFile(filepath.Join(updatedDirectory, "README.md"))
+	assert.Nil(t, err)
+	assert.Equal(t, "# orders\n\nVersion 2\n", string(readme))
+	for _, name := range []string{"VERSION", "NOTES.md"} {
+		_, err = os.Stat(filepath.Join(updatedDirectory, name))
+		assert.Nil(t, err, name)
+	}
+	manifest, err := template.ReadManifest(updatedDirectory)
+	assert.Nil(t, err)
+	assert.Equal(t, "v2", manifest.Template.Ref)
+	assert.Equal(t, "orders", manifest.Options["service_name"])
+}
+
+// cloneRecordingClient records the directories of the repositories it clones
+type cloneRecordingClient struct {
+	*git_client.PlainGitClient
+	clones []string
+}
+
+func (client *cloneRecordingClient) CloneRepo(gitRepoConfig git_client.GitRepoConfig) (string, error) {
+	directoryPath, err := client.PlainGitClient.CloneRepo(gitRepoConfig)
+	client.clones = append(client.clones, directoryPath)
+	return directoryPath, err
+}
+
+func (client *cloneRecordingClient) CheckoutBranch(branchName string, gitRepoConfig git_client.GitRepoConfig) (string, error) {
+	directoryPath, err := client.PlainGitClient.CheckoutBranch(branchName, gitRepoConfig)
+	if err == nil {
+		client.clones = append(client.clones, directoryPath)
+	}
+	return directoryPath, err
+}
+
+func TestTemplateOrchestrator_GetTemplate(t *testing.T) {
+	plainGitClient := git_client.NewPlainGitClient(&git_client.PlainGitClientConfig{Email: "genesis@example.com"})
+	client := &cloneRecordingClient{PlainGitClient: &plainGitClient}
+	templateRepo, _ := newTestRepositories(t, client)
+	orchestrator := &TemplateOrchestrator{
+		RemoteTemplateMap: map[string]git_client.GitRepoConfig{"Local Templates": templateRepo},
+		GitClientMap:      map[string]git_client.GitClient{plainGit: client},
+	}
+
+	projectTemplate, err := orchestrator.GetTemplate("Local Templates", "Service")
+	assert.Nil(t, err)
+	assert.Equal(t, "Service", projectTemplate.GetName())
+	names, err := orchestrator.GetTemplateNames()
+	assert.Nil(t, err)
+	assert.Equal(t, []TemplateName{{Name: "Local Templates", ProjectNames: []string{"Service"}}}, names)
+
+	assert.Equal(t, 2, len(client.clones))
+	for _, directoryPath := range client.clones {
+		_, err = os.Stat(directoryPath)
+		assert.True(t, os.IsNotExist(err), "the clone should be removed once parsed")
+	}
+
+	_, err = orchestrator.GetTemplate("Missing Templates", "Service")
+	assert.NotNil(t, err)
+	_, err = orchestrator.GetTemplates("Missing Templates")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,55 @@
+package template
+
+import (
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// EnvOptionPrefix is the prefix of the environment variables that pass options, e.g. GENESIS_OPT_name=orders
+const EnvOptionPrefix = "GENESIS_OPT_"
+
+// ReadAnswersFile reads option values from a YAML or JSON file with one key per option. Values may be
+// lists and maps, and are parsed into the declared option types during validation.
+func ReadAnswersFile(path string) (OptionValues, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read answers file %s", path)
+	}
+	// JSON documents are also YAML documents
+	answers := make(map[string]interface{})
+	if err := yaml.Unmarshal(content, &answers); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse answers file %s", path)
+	}
+	return NormalizeOptionValues(answers), nil
+}
+
+// EnvOptionValues returns the options passed as GENESIS_OPT_<option name> environment variables.
+// environ is formatted like os.Environ.
+func EnvOptionValues(environ []string) OptionValues {
+	values := make(OptionValues)
+	for _, variable := range environ {
+		if !strings.HasPrefix(variable, EnvOptionPrefix) {
+			continue
+		}
+		pair := strings.SplitN(strings.TrimPrefix(variable, EnvOptionPrefix), "=", 2)
+		if len(pair) == 2 && pair[0] != "" {
+			values[pair[0]] = pair[1]
+		}
+	}
+	return values
+}
+
+// MergeOptionValues merges option values in increasing order of precedence, so that values
+// of later arguments replace values of earlier ones
+func MergeOptionValues(values ...OptionValues) OptionValues {
+	merged := make(OptionValues)
+	for _, layer := range values {
+		for key, value := range layer {
+			merged[key] = value
+		}
+	}
+	return merged
+}
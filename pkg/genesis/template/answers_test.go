@@ -0,0 +1,41 @@
+package template
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadAnswersFile(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"answers.yml":  "name: orders\ndescription: \"Orders, payments and refunds\"\nports: [8080, 9090]\nlabels:\n  team: checkout\n",
+		"answers.json": `{"name": "orders", "docker": true, "labels": {"team": "checkout"}}`,
+		"invalid.yml":  "- name\n",
+	})
+
+	answers, err := ReadAnswersFile(dir + "answers.yml")
+	assert.Nil(t, err)
+	assert.Equal(t, OptionValues{
+		"name":        "orders",
+		"description": "Orders, payments and refunds",
+		"ports":       []interface{}{8080, 9090},
+		"labels":      map[string]interface{}{"team": "checkout"},
+	}, answers)
+
+	answers, err = ReadAnswersFile(dir + "answers.json")
+	assert.Nil(t, err)
+	assert.Equal(t, OptionValues{"name": "orders", "docker": true, "labels": map[string]interface{}{"team": "checkout"}}, answers)
+
+	_, err = ReadAnswersFile(dir + "invalid.yml")
+	assert.NotNil(t, err)
+	_, err = ReadAnswersFile(dir + "missing.yml")
+	assert.NotNil(t, err)
+}
+
+func TestMergeOptionValues(t *testing.T) {
+	env := EnvOptionValues([]string{"HOME=/root", "GENESIS_OPT_name=payments", "GENESIS_OPT_description=a=b, c", "GENESIS_OPT_="})
+	assert.Equal(t, OptionValues{"name": "payments", "description": "a=b, c"}, env)
+
+	merged := MergeOptionValues(OptionValues{"name": "orders", "port": 8080}, env, OptionValues{"port": "9090"})
+	assert.Equal(t, OptionValues{"name": "payments", "description": "a=b, c", "port": "9090"}, merged)
+}
@@ -0,0 +1,235 @@
+package template
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// RenderDocument evaluates the block helpers in a document and then replaces all variables.
+//
+// Supported blocks:
+//
+//	{{#if condition}} ... {{else}} ... {{/if}}
+//	{{#unless condition}} ... {{/unless}}
+//	{{#each key}} ... {{/each}}
+//
+// Conditions are an option name or an expression, e.g. {{#if database != none}}.
+// Inside an each block, {{this}} is the current element, {{@index}} its position in a list,
+// {{@key}} its key in a map, and {{this.field}} a field of a map element.
+func RenderDocument(document []byte, options OptionValues) ([]byte, error) {
+	output, err := renderBlocks(document, options)
+	if err != nil {
+		return nil, err
+	}
+	return RecursiveReplace(output, options)
+}
+
+func renderBlocks(document []byte, options OptionValues) ([]byte, error) {
+	offset := 0
+	for {
+		next := bytes.Index(document[offset:], []byte("{{#"))
+		if next == -1 {
+			return document, nil
+		}
+		start := offset + next
+		closeIdx := bytes.Index(document[start:], []byte("}}"))
+		if closeIdx == -1 {
+			return nil, errors.Errorf("Malformed input: found opening tags, but not closing tags.")
+		}
+		openEnd := start + closeIdx + 2
+		tag := strings.TrimSpace(string(document[start+3 : openEnd-2]))
+		if tag == "" {
+			return nil, errors.Errorf("Malformed input: block helper name is missing")
+		}
+		name := strings.Fields(tag)[0]
+		arg := strings.TrimSpace(tag[len(name):])
+
+		elseStart, elseEnd, closeStart, closeEnd, err := findBlockEnd(document, openEnd, name)
+		if err != nil {
+			return nil, err
+		}
+
+		// standalone tags consume their whole line
+		start, openEnd = expandStandalone(document, start, openEnd)
+		closeStart, closeEnd = expandStandalone(document, closeStart, closeEnd)
+		body := document[openEnd:closeStart]
+		var elseBody []byte
+		if elseStart != -1 {
+			elseStart, elseEnd = expandStandalone(document, elseStart, elseEnd)
+			body = document[openEnd:elseStart]
+			elseBody = document[elseEnd:closeStart]
+		}
+
+		var rendered []byte
+		switch name {
+		case "if", "unless":
+			if arg == "" {
+				return nil, errors.Errorf("Malformed input: {{#%s}} requires a condition", name)
+			}
+			condition, err := evaluateBlockCondition(arg, options)
+			if err != nil {
+				return nil, err
+			}
+			if name == "unless" {
+				condition = !condition
+			}
+			chosen := elseBody
+			if condition {
+				chosen = body
+			}
+			rendered, err = renderBlocks(copyBytes(chosen), options)
+			if err != nil {
+				return nil, err
+			}
+		case "each":
+			value, _ := lookupValue(options, arg)
+			rendered, err = renderEach(body, elseBody, value, options)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, errors.Errorf("Malformed input: unknown block helper {{#%s}}", name)
+		}
+
+		output := make([]byte, 0, len(document)-(closeEnd-start)+len(rendered))
+		output = append(output, document[:start]...)
+		output = append(output, rendered...)
+		output = append(output, document[closeEnd:]...)
+		document = output
+		offset = start + len(rendered)
+	}
+}
+
+func evaluateBlockCondition(arg string, options OptionValues) (bool, error) {
+	return EvaluateCondition(arg, options)
+}
+
+func renderEach(body, elseBody []byte, value interface{}, options OptionValues) ([]byte, error) {
+	var output []byte
+	appendItem := func(bindings OptionValues) error {
+		scope := make(OptionValues, len(options)+len(bindings))
+		for key, v := range options {
+			scope[key] = v
+		}
+		for key, v := range bindings {
+			scope[key] = v
+		}
+		rendered, err := RenderDocument(copyBytes(body), scope)
+		if err != nil {
+			return err
+		}
+		output = append(output, rendered...)
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []interface{}:
+		for i, item := range v {
+			err := appendItem(OptionValues{"this": item, "@index": i, "@first": i == 0, "@last": i == len(v)-1})
+			if err != nil {
+				return nil, err
+			}
+		}
+	case map[string]interface{}:
+		for i, key := range sortedKeys(v) {
+			err := appendItem(OptionValues{"this": v[key], "@key": key, "@index": i})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(output) == 0 && elseBody != nil {
+		return renderBlocks(copyBytes(elseBody), options)
+	}
+	return output, nil
+}
+
+// findBlockEnd locates the {{else}} and closing tag that belong to the block opened before `from`
+func findBlockEnd(document []byte, from int, name string) (elseStart, elseEnd, closeStart, closeEnd int, err error) {
+	elseStart, elseEnd = -1, -1
+	depth := 0
+	i := from
+	for {
+		next := bytes.Index(document[i:], []byte("{{"))
+		if next == -1 {
+			return 0, 0, 0, 0, errors.Errorf("Malformed input: block {{#%s}} is never closed", name)
+		}
+		tokenStart := i + next
+		end := bytes.Index(document[tokenStart:], []byte("}}"))
+		if end == -1 {
+			return 0, 0, 0, 0, errors.Errorf("Malformed input: found opening tags, but not closing tags.")
+		}
+		tokenEnd := tokenStart + end + 2
+		inner := strings.TrimSpace(string(document[tokenStart+2 : tokenEnd-2]))
+		switch {
+		case strings.HasPrefix(inner, "#"):
+			depth++
+		case strings.HasPrefix(inner, "/"):
+			if depth == 0 {
+				if closing := strings.TrimSpace(inner[1:]); closing != name {
+					return 0, 0, 0, 0, errors.Errorf("Malformed input: expected {{/%s}} but found {{/%s}}", name, closing)
+				}
+				return elseStart, elseEnd, tokenStart, tokenEnd, nil
+			}
+			depth--
+		case inner == "else" && depth == 0:
+			elseStart, elseEnd = tokenStart, tokenEnd
+		}
+		i = tokenEnd
+	}
+}
+
+// expandStandalone widens the range of a tag to its whole line when nothing but whitespace
+// surrounds it, so that block tags do not leave blank lines behind.
+func expandStandalone(document []byte, start, end int) (int, int) {
+	lineStart := bytes.LastIndexByte(document[:start], '\n') + 1
+	if len(bytes.TrimSpace(document[lineStart:start])) > 0 {
+		return start, end
+	}
+	lineEnd := bytes.IndexByte(document[end:], '\n')
+	if lineEnd == -1 {
+		if len(bytes.TrimSpace(document[end:])) > 0 {
+			return start, end
+		}
+		return lineStart, len(document)
+	}
+	if len(bytes.TrimSpace(document[end:end+lineEnd])) > 0 {
+		return start, end
+	}
+	return lineStart, end + lineEnd + 1
+}
+
+// lookupValue finds a value by key, descending into maps for dotted keys such as this.name
+func lookupValue(options OptionValues, key string) (interface{}, bool) {
+	if value, ok := options[key]; ok {
+		return value, true
+	}
+	parts := strings.Split(key, ".")
+	if len(parts) < 2 {
+		return nil, false
+	}
+	value, ok := options[parts[0]]
+	if !ok {
+		return nil, false
+	}
+	for _, part := range parts[1:] {
+		m, isMap := value.(map[string]interface{})
+		if !isMap {
+			return nil, false
+		}
+		value, ok = m[part]
+		if !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+func copyBytes(b []byte) []byte {
+	c := make([]byte, len(b))
+	copy(c, b)
+	return c
+}
@@ -0,0 +1,109 @@
+package template
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// directories that never contain templates, such as the dependencies of a template repository's tooling
+var ignoredDirectories = map[string]bool{".git": true, "node_modules": true, "vendor": true}
+
+// FindGenesisFiles returns the paths of the .genesis.yml files in a template repository, relative
+// to it, with the file at the repository root first. Files inside the roots, module roots, partials
+// and tests of another descriptor belong to that template, so they are not descriptors themselves.
+// .git, node_modules and vendor directories are not searched.
+func FindGenesisFiles(directoryPath string) ([]string, error) {
+	var candidates []string
+	err := filepath.Walk(directoryPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && ignoredDirectories[info.Name()] {
+			return filepath.SkipDir
+		}
+		if !info.IsDir() && info.Name() == genesisFileName {
+			rel, err := filepath.Rel(directoryPath, path)
+			if err != nil {
+				return err
+			}
+			candidates = append(candidates, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to search %s for %s files", directoryPath, genesisFileName)
+	}
+
+	// parents claim their directories before the descriptors nested in them are considered
+	sort.SliceStable(candidates, func(i, j int) bool {
+		depthI, depthJ := strings.Count(candidates[i], "/"), strings.Count(candidates[j], "/")
+		if depthI != depthJ {
+			return depthI < depthJ
+		}
+		return candidates[i] < candidates[j]
+	})
+
+	var descriptors, claimed []string
+	for _, candidate := range candidates {
+		if isClaimed(candidate, claimed) {
+			continue
+		}
+		descriptors = append(descriptors, candidate)
+
+		namespace := descriptorNamespace(candidate)
+		claimed = append(claimed, joinNamespace(namespace, partialsDirectoryName), joinNamespace(namespace, goldenTestsDirectoryName))
+		file, err := ioutil.ReadFile(filepath.Join(directoryPath, candidate))
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to read file with name %s", candidate)
+		}
+		// invalid descriptors are reported when they are loaded
+		var projects GenesisProject
+		if yaml.Unmarshal(file, &projects) != nil {
+			continue
+		}
+		for _, project := range projects.Projects {
+			if project.Root != "" {
+				claimed = append(claimed, joinNamespace(namespace, project.Root))
+			}
+			for _, module := range project.Modules {
+				if module.Root != "" {
+					claimed = append(claimed, joinNamespace(namespace, module.Root))
+				}
+			}
+		}
+	}
+	return descriptors, nil
+}
+
+// descriptorNamespace returns the directory of a descriptor relative to the repository, or "" at the root
+func descriptorNamespace(descriptor string) string {
+	namespace := filepath.ToSlash(filepath.Dir(descriptor))
+	if namespace == "." {
+		return ""
+	}
+	return namespace
+}
+
+// joinNamespace prefixes a template name or path with the namespace of its descriptor
+func joinNamespace(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
+
+func isClaimed(descriptor string, claimed []string) bool {
+	for _, directory := range claimed {
+		directory = strings.TrimSuffix(filepath.ToSlash(filepath.Clean(directory)), "/")
+		if directory == "." || strings.HasPrefix(descriptor, directory+"/") {
+			return true
+		}
+	}
+	return false
+}
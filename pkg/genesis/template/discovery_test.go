@@ -0,0 +1,97 @@
+package template
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindGenesisFiles(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		".genesis.yml":                       "projects:\n  - name: \"Root\"\n    root: \"base\"\n",
+		"base/.genesis.yml":                  "projects: []\n",
+		"services/api/.genesis.yml":          "projects:\n  - name: \"Service\"\n    root: \"template\"\n",
+		"services/api/template/.genesis.yml": "projects: []\n",
+		"services/api/tests/x/.genesis.yml":  "projects: []\n",
+		"tools/cli/.genesis.yml":             "projects:\n  - name: \"Cli\"\n    root: \"template\"\n",
+		".git/.genesis.yml":                  "projects: []\n",
+		"web/node_modules/x/.genesis.yml":    "projects: []\n",
+		"vendor/x/.genesis.yml":              "projects: []\n",
+	})
+	defer os.RemoveAll(dir)
+
+	descriptors, err := FindGenesisFiles(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{".genesis.yml", "services/api/.genesis.yml", "tools/cli/.genesis.yml"}, descriptors)
+}
+
+func TestGenesisTemplateApi_GetProjectsFromRepo_Nested(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"services/api/.genesis.yml":             "projects:\n  - name: \"Common\"\n    root: \"common\"\n    options:\n      - name: \"name\"\n  - name: \"Service\"\n    extends: \"Common\"\n    root: \"template\"\n",
+		"services/api/common/README.md":         "# {{name}}\n",
+		"services/api/template/{{name}}.go":     "package {{name}}\n{{> header.txt}}",
+		"services/api/partials/header.txt":      "// api\n",
+		"services/worker/.genesis.yml":          "projects:\n  - name: \"Service\"\n    root: \"template\"\n",
+		"services/worker/template/worker.txt":   "worker\n",
+		"services/worker/template/.genesis.yml": "not a descriptor\n",
+		"services/worker/partials/.genesis.yml": "not a descriptor\n",
+	})
+	defer os.RemoveAll(dir)
+	api := NewGenesisTemplateApi(dir)
+
+	names, err := api.GetProjectNames()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"services/api/Common", "services/api/Service", "services/worker/Service"}, names)
+
+	project, err := api.GetProjectFromRepo("services/api/Service")
+	assert.Nil(t, err)
+	root, err := project.GetRoot()
+	assert.Nil(t, err)
+	assert.Equal(t, "services/api/template", root)
+
+	err = api.GenerateFromTemplate(project, OptionValues{"name": "orders"})
+	assert.Nil(t, err)
+	assert.Equal(t, "package orders\n// api", readFile(t, dir+"services/api/template/orders.go"))
+	assert.Equal(t, "# orders\n", readFile(t, dir+"services/api/template/README.md"))
+}
+
+func TestGenesisTemplateApi_ValidateGenesisProject_Nested(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		".genesis.yml":              "projects:\n  - name: \"Root\"\n    root: \"base\"\n",
+		"services/api/.genesis.yml": "projects:\n  - name: \"Service\"\n    rooot: \"template\"\n",
+	})
+	defer os.RemoveAll(dir)
+
+	valid, err := NewGenesisTemplateApi(dir).ValidateGenesisProject()
+	assert.False(t, valid)
+	validationErrors, ok := err.(ValidationErrors)
+	assert.True(t, ok)
+	assert.Equal(t, 1, len(validationErrors))
+	assert.Equal(t, "services/api/.genesis.yml", validationErrors[0].File)
+	assert.Equal(t, 3, validationErrors[0].Line)
+}
+
+func TestGenesisTemplateApi_GetProjectsFromRepo_InvalidNested(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		".genesis.yml":              "projects:\n  - name: \"Root\"\n    root: \"base\"\n",
+		"services/api/.genesis.yml": "projects:\n  - name: \"Service\"\n    rooot: \"template\"\n",
+	})
+	defer os.RemoveAll(dir)
+	api := NewGenesisTemplateApi(dir)
+
+	names, err := api.GetProjectNames()
+	assert.Nil(t, err, "an invalid nested .genesis.yml should not hide the other templates")
+	assert.Equal(t, []string{"Root"}, names)
+
+	_, err = api.GetProjectFromRepo("services/api/Service")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "services/api/.genesis.yml could not be loaded")
+
+	// the .genesis.yml at the root must be valid
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, ".genesis.yml"), []byte("projects:\n  - nme: \"Root\"\n"), 0644))
+	_, err = api.GetProjectNames()
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,515 @@
+package template
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// Expressions are used by showIf and requiredIf to reference other options, e.g.
+//
+//	database != none && (replicas > 1 || ha)
+//
+// Identifiers resolve to option values. An identifier that is not an option is compared
+// as a string literal, so none above is the same as "none", and is false on its own.
+// Supported operators are
+// ||, &&, !, ==, !=, <, <=, >, >= and in, which tests membership in a list, a key in a map,
+// or a substring of a string. The functions len(value) and matches(value, "regex") are
+// also available.
+type Expression interface {
+	Evaluate(values OptionValues) (interface{}, error)
+}
+
+// ParseExpression parses an expression so it can be evaluated against option values
+func ParseExpression(expression string) (Expression, error) {
+	tokens, err := tokenizeExpression(expression)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid expression %q", expression)
+	}
+	parser := &expressionParser{tokens: tokens}
+	parsed, err := parser.parseOr()
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid expression %q", expression)
+	}
+	if !parser.done() {
+		return nil, errors.Errorf("invalid expression %q: unexpected %s", expression, parser.peek().text)
+	}
+	return parsed, nil
+}
+
+// EvaluateCondition parses and evaluates an expression, and reports whether the result is truthy
+func EvaluateCondition(expression string, values OptionValues) (bool, error) {
+	parsed, err := ParseExpression(expression)
+	if err != nil {
+		return false, err
+	}
+	result, err := parsed.Evaluate(values)
+	if err != nil {
+		return false, errors.Wrapf(err, "unable to evaluate expression %q", expression)
+	}
+	return isTruthyOperand(result), nil
+}
+
+type tokenKind int
+
+const (
+	identToken tokenKind = iota
+	stringToken
+	numberToken
+	operatorToken
+)
+
+type expressionToken struct {
+	kind tokenKind
+	text string
+}
+
+func tokenizeExpression(expression string) ([]expressionToken, error) {
+	var tokens []expressionToken
+	runes := []rune(expression)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			end := i + 1
+			var sb strings.Builder
+			for ; end < len(runes) && runes[end] != r; end++ {
+				if runes[end] == '\\' && end+1 < len(runes) {
+					end++
+				}
+				sb.WriteRune(runes[end])
+			}
+			if end >= len(runes) {
+				return nil, errors.Errorf("unterminated string starting at position %d", i)
+			}
+			tokens = append(tokens, expressionToken{stringToken, sb.String()})
+			i = end + 1
+		case unicode.IsDigit(r):
+			end := i
+			for end < len(runes) && (unicode.IsDigit(runes[end]) || runes[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, expressionToken{numberToken, string(runes[i:end])})
+			i = end
+		case unicode.IsLetter(r) || r == '_' || r == '@':
+			end := i
+			for end < len(runes) && isIdentRune(runes[end]) {
+				end++
+			}
+			tokens = append(tokens, expressionToken{identToken, string(runes[i:end])})
+			i = end
+		default:
+			two := ""
+			if i+1 < len(runes) {
+				two = string(runes[i : i+2])
+			}
+			switch two {
+			case "==", "!=", "<=", ">=", "&&", "||":
+				tokens = append(tokens, expressionToken{operatorToken, two})
+				i += 2
+				continue
+			}
+			switch r {
+			case '<', '>', '!', '(', ')', ',':
+				tokens = append(tokens, expressionToken{operatorToken, string(r)})
+				i++
+			default:
+				return nil, errors.Errorf("unexpected character %q at position %d", r, i)
+			}
+		}
+	}
+	return tokens, nil
+}
+
+func isIdentRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.' || r == '@'
+}
+
+type expressionParser struct {
+	tokens []expressionToken
+	pos    int
+}
+
+func (parser *expressionParser) done() bool {
+	return parser.pos >= len(parser.tokens)
+}
+
+func (parser *expressionParser) peek() expressionToken {
+	if parser.done() {
+		return expressionToken{operatorToken, "end of expression"}
+	}
+	return parser.tokens[parser.pos]
+}
+
+func (parser *expressionParser) accept(kind tokenKind, text string) bool {
+	if !parser.done() && parser.tokens[parser.pos].kind == kind && parser.tokens[parser.pos].text == text {
+		parser.pos++
+		return true
+	}
+	return false
+}
+
+func (parser *expressionParser) parseOr() (Expression, error) {
+	left, err := parser.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for parser.accept(operatorToken, "||") {
+		right, err := parser.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalExpression{operator: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (parser *expressionParser) parseAnd() (Expression, error) {
+	left, err := parser.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for parser.accept(operatorToken, "&&") {
+		right, err := parser.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalExpression{operator: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (parser *expressionParser) parseNot() (Expression, error) {
+	if parser.accept(operatorToken, "!") {
+		operand, err := parser.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notExpression{operand: operand}, nil
+	}
+	return parser.parseComparison()
+}
+
+func (parser *expressionParser) parseComparison() (Expression, error) {
+	left, err := parser.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for _, operator := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if parser.accept(operatorToken, operator) {
+			right, err := parser.parsePrimary()
+			if err != nil {
+				return nil, err
+			}
+			return comparisonExpression{operator: operator, left: left, right: right}, nil
+		}
+	}
+	if parser.accept(identToken, "in") {
+		right, err := parser.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		return comparisonExpression{operator: "in", left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (parser *expressionParser) parsePrimary() (Expression, error) {
+	if parser.done() {
+		return nil, errors.New("unexpected end of expression")
+	}
+	token := parser.tokens[parser.pos]
+	parser.pos++
+	switch token.kind {
+	case stringToken:
+		return literalExpression{value: token.text}, nil
+	case numberToken:
+		if i, err := strconv.Atoi(token.text); err == nil {
+			return literalExpression{value: i}, nil
+		}
+		f, err := strconv.ParseFloat(token.text, 64)
+		if err != nil {
+			return nil, errors.Errorf("invalid number %s", token.text)
+		}
+		return literalExpression{value: f}, nil
+	case identToken:
+		switch token.text {
+		case "true":
+			return literalExpression{value: true}, nil
+		case "false":
+			return literalExpression{value: false}, nil
+		case "null":
+			return literalExpression{value: nil}, nil
+		}
+		if parser.accept(operatorToken, "(") {
+			return parser.parseCall(token.text)
+		}
+		return identifierExpression{name: token.text}, nil
+	default:
+		if token.text == "(" {
+			inner, err := parser.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if !parser.accept(operatorToken, ")") {
+				return nil, errors.New("missing closing parenthesis")
+			}
+			return inner, nil
+		}
+		return nil, errors.Errorf("unexpected %s", token.text)
+	}
+}
+
+func (parser *expressionParser) parseCall(name string) (Expression, error) {
+	if _, ok := expressionFunctions[name]; !ok {
+		return nil, errors.Errorf("unknown function %s", name)
+	}
+	call := callExpression{name: name}
+	if parser.accept(operatorToken, ")") {
+		return call, nil
+	}
+	for {
+		argument, err := parser.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		call.arguments = append(call.arguments, argument)
+		if parser.accept(operatorToken, ")") {
+			return call, nil
+		}
+		if !parser.accept(operatorToken, ",") {
+			return nil, errors.Errorf("expected , or ) in call to %s", name)
+		}
+	}
+}
+
+type literalExpression struct {
+	value interface{}
+}
+
+func (e literalExpression) Evaluate(values OptionValues) (interface{}, error) {
+	return e.value, nil
+}
+
+type identifierExpression struct {
+	name string
+}
+
+// unresolvedIdentifier is an identifier that does not name an option
+type unresolvedIdentifier string
+
+func (e identifierExpression) Evaluate(values OptionValues) (interface{}, error) {
+	if value, ok := lookupValue(values, e.name); ok {
+		return value, nil
+	}
+	return unresolvedIdentifier(e.name), nil
+}
+
+func isTruthyOperand(value interface{}) bool {
+	if _, ok := value.(unresolvedIdentifier); ok {
+		return false
+	}
+	return IsTruthy(value)
+}
+
+func literalOperand(value interface{}) interface{} {
+	if name, ok := value.(unresolvedIdentifier); ok {
+		return string(name)
+	}
+	return value
+}
+
+type notExpression struct {
+	operand Expression
+}
+
+func (e notExpression) Evaluate(values OptionValues) (interface{}, error) {
+	value, err := e.operand.Evaluate(values)
+	if err != nil {
+		return nil, err
+	}
+	return !isTruthyOperand(value), nil
+}
+
+type logicalExpression struct {
+	operator    string
+	left, right Expression
+}
+
+func (e logicalExpression) Evaluate(values OptionValues) (interface{}, error) {
+	left, err := e.left.Evaluate(values)
+	if err != nil {
+		return nil, err
+	}
+	if e.operator == "&&" && !isTruthyOperand(left) {
+		return false, nil
+	}
+	if e.operator == "||" && isTruthyOperand(left) {
+		return true, nil
+	}
+	right, err := e.right.Evaluate(values)
+	if err != nil {
+		return nil, err
+	}
+	return isTruthyOperand(right), nil
+}
+
+type comparisonExpression struct {
+	operator    string
+	left, right Expression
+}
+
+func (e comparisonExpression) Evaluate(values OptionValues) (interface{}, error) {
+	left, err := e.left.Evaluate(values)
+	if err != nil {
+		return nil, err
+	}
+	right, err := e.right.Evaluate(values)
+	if err != nil {
+		return nil, err
+	}
+	left, right = literalOperand(left), literalOperand(right)
+	switch e.operator {
+	case "==":
+		return valuesEqual(left, right), nil
+	case "!=":
+		return !valuesEqual(left, right), nil
+	case "in":
+		return valueIn(left, right), nil
+	}
+	cmp, err := compareValues(left, right)
+	if err != nil {
+		return nil, err
+	}
+	switch e.operator {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+var expressionFunctions = map[string]func(arguments []interface{}) (interface{}, error){
+	"len": func(arguments []interface{}) (interface{}, error) {
+		if len(arguments) != 1 {
+			return nil, errors.Errorf("len expects 1 argument but got %d", len(arguments))
+		}
+		switch v := arguments[0].(type) {
+		case nil:
+			return 0, nil
+		case []interface{}:
+			return len(v), nil
+		case map[string]interface{}:
+			return len(v), nil
+		default:
+			return len(FormatValue(v)), nil
+		}
+	},
+	"matches": func(arguments []interface{}) (interface{}, error) {
+		if len(arguments) != 2 {
+			return nil, errors.Errorf("matches expects 2 arguments but got %d", len(arguments))
+		}
+		pattern, err := regexp.Compile(FormatValue(arguments[1]))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid pattern for matches")
+		}
+		return pattern.MatchString(FormatValue(arguments[0])), nil
+	},
+}
+
+type callExpression struct {
+	name      string
+	arguments []Expression
+}
+
+func (e callExpression) Evaluate(values OptionValues) (interface{}, error) {
+	arguments := make([]interface{}, len(e.arguments))
+	for i, argument := range e.arguments {
+		value, err := argument.Evaluate(values)
+		if err != nil {
+			return nil, err
+		}
+		arguments[i] = literalOperand(value)
+	}
+	return expressionFunctions[e.name](arguments)
+}
+
+func toNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func valuesEqual(left, right interface{}) bool {
+	if left == nil || right == nil {
+		return left == nil && right == nil
+	}
+	if l, ok := left.(bool); ok {
+		if r, ok := right.(bool); ok {
+			return l == r
+		}
+	}
+	if l, ok := toNumber(left); ok {
+		if r, ok := toNumber(right); ok {
+			return l == r
+		}
+	}
+	return FormatValue(left) == FormatValue(right)
+}
+
+func valueIn(item, collection interface{}) bool {
+	switch c := collection.(type) {
+	case []interface{}:
+		for _, element := range c {
+			if valuesEqual(item, element) {
+				return true
+			}
+		}
+		return false
+	case map[string]interface{}:
+		_, ok := c[FormatValue(item)]
+		return ok
+	case nil:
+		return false
+	default:
+		return strings.Contains(FormatValue(c), FormatValue(item))
+	}
+}
+
+func compareValues(left, right interface{}) (int, error) {
+	if l, ok := toNumber(left); ok {
+		if r, ok := toNumber(right); ok {
+			switch {
+			case l < r:
+				return -1, nil
+			case l > r:
+				return 1, nil
+			default:
+				return 0, nil
+			}
+		}
+	}
+	l, lok := left.(string)
+	r, rok := right.(string)
+	if !lok || !rok {
+		return 0, errors.Errorf("cannot compare %v and %v", left, right)
+	}
+	return strings.Compare(l, r), nil
+}
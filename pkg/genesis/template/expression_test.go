@@ -0,0 +1,183 @@
+package template
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluateCondition(t *testing.T) {
+	values := OptionValues{
+		"database": "postgres",
+		"replicas": 3,
+		"ha":       false,
+		"modules":  []interface{}{"kafka", "helm"},
+		"unset":    nil,
+	}
+	cases := map[string]bool{
+		"database != none":                 true,
+		`database == "postgres"`:           true,
+		"replicas > 1 && !ha":              true,
+		"replicas >= 4 || ha":              false,
+		"(replicas < 2 || ha) && database": false,
+		`"kafka" in modules`:               true,
+		"redis in modules":                 false,
+		"unset":                            false,
+		"undeclared":                       false,
+		"replicas == '3'":                  true,
+	}
+	for expression, expected := range cases {
+		result, err := EvaluateCondition(expression, values)
+		assert.Nil(t, err, expression)
+		assert.Equal(t, expected, result, expression)
+	}
+
+	_, err := EvaluateCondition("database ==", values)
+	assert.NotNil(t, err, "there should be an error because the expression is incomplete")
+}
+
+func TestGenesisTemplate_SetValidatedOptions_Conditional(t *testing.T) {
+	p := GenesisTemplate{
+		Options: []Option{
+			{Name: "database", Default: "none"},
+			{Name: "dbHost", RequiredIf: "database != none"},
+			{Name: "dbPool", Type: INT_TYPE, ShowIf: "database != none", Default: 5},
+		},
+	}
+
+	err := p.SetValidatedOptions(OptionValues{"dbPool": "10"})
+	assert.Nil(t, err, "dbHost is not required without a database")
+	validated, _ := p.GetValidatedOptions()
+	assert.Equal(t, 5, validated["dbPool"], "hidden options should ignore provided values")
+
+	err = p.SetValidatedOptions(OptionValues{"database": "postgres"})
+	assert.NotNil(t, err, "there should be an error because dbHost is required with a database")
+
+	err = p.SetValidatedOptions(OptionValues{"database": "postgres", "dbHost": "localhost", "dbPool": "10"})
+	assert.Nil(t, err)
+	validated, _ = p.GetValidatedOptions()
+	assert.Equal(t, 10, validated["dbPool"])
+}
+
+func TestGenesisTemplate_SetValidatedOptions_HiddenOptions(t *testing.T) {
+	p := GenesisTemplate{
+		Options: []Option{
+			{Name: "cloud", Default: "aws"},
+			{Name: "region", ShowIf: "cloud == aws", Default: "us-east-1"},
+			{Name: "database", ShowIf: "cloud == onprem"},
+			{Name: "dbHost", ShowIf: "database != none", RequiredIf: "database == postgres"},
+			{Name: "backup", RequiredIf: "database == postgres"},
+		},
+		Rules: []ValidationRule{
+			{When: "database == postgres", Assert: "region == none", Message: "postgres is only on premises"},
+		},
+	}
+
+	// region is shown by the default of cloud
+	err := p.SetValidatedOptions(OptionValues{"region": "eu-west-1"})
+	assert.Nil(t, err)
+	validated, _ := p.GetValidatedOptions()
+	assert.Equal(t, "eu-west-1", validated["region"])
+
+	// database is hidden, so its value neither requires dbHost and backup nor triggers the rule, and dbHost is
+	// hidden with it
+	err = p.SetValidatedOptions(OptionValues{"database": "postgres"})
+	assert.Nil(t, err, "hidden options should not be used by conditions and rules")
+	validated, _ = p.GetValidatedOptions()
+	assert.Nil(t, validated["database"])
+
+	err = p.SetValidatedOptions(OptionValues{"cloud": "onprem", "database": "postgres"})
+	assert.NotNil(t, err, "there should be an error because dbHost is required with postgres")
+}
+
+func TestGenesisTemplate_SetValidatedOptions_RequiredIfHiddenOption(t *testing.T) {
+	p := GenesisTemplate{
+		Options: []Option{
+			{Name: "cloud", Default: "aws"},
+			{Name: "database", ShowIf: "cloud == onprem", Default: "none"},
+			{Name: "dbHost", RequiredIf: "database != none"},
+		},
+	}
+
+	// database is hidden, so requiredIf sees its default rather than the provided value or no value at all
+	err := p.SetValidatedOptions(OptionValues{"database": "postgres"})
+	assert.Nil(t, err, "dbHost should not be required while database is hidden")
+	validated, _ := p.GetValidatedOptions()
+	assert.Equal(t, "none", validated["database"])
+
+	err = p.SetValidatedOptions(OptionValues{"cloud": "onprem", "database": "postgres"})
+	assert.NotNil(t, err, "there should be an error because dbHost is required with postgres")
+}
+
+func TestGenesisTemplate_GetRequiredOptions(t *testing.T) {
+	p := GenesisTemplate{
+		Options: []Option{
+			{Name: "name", Required: true},
+			{Name: "cloud", Default: "aws"},
+			{Name: "region", ShowIf: "cloud == aws", Required: true},
+			{Name: "database", Default: "postgres"},
+			{Name: "dbHost", RequiredIf: "database != none"},
+			{Name: "datacenter", ShowIf: "cloud == onprem", Required: true},
+		},
+		Modules: []GenesisModule{
+			{Name: "metrics", When: "metrics", Options: []Option{{Name: "metricsPort", Required: true}}},
+		},
+	}
+
+	var names []string
+	for _, option := range p.GetRequiredOptions() {
+		names = append(names, option.Name)
+	}
+	assert.Equal(t, []string{"name", "region", "dbHost"}, names, "options hidden by the defaults should not be required")
+
+	p.Options = append(p.Options, Option{Name: "metrics", Type: BOOL_TYPE, Default: true})
+	names = nil
+	for _, option := range p.GetRequiredOptions() {
+		names = append(names, option.Name)
+	}
+	assert.Equal(t, []string{"name", "region", "dbHost", "metricsPort"}, names, "options of selected modules should be required")
+}
+
+func TestGenesisTemplate_OrganizeGroups_Conditional(t *testing.T) {
+	p := GenesisTemplate{
+		Options: []Option{
+			{Name: "dbHost", GroupName: "Database", RequiredIf: "database != none", FormField: FormField{Type: TEXT, Label: "Host"}},
+		},
+		FormGroups: []FormGroup{{DisplayName: "Database"}},
+	}
+
+	err := p.OrganizeGroups()
+	assert.Nil(t, err)
+	assert.Equal(t, "database != none", p.FormGroups[0].FormFields[0].RequiredIf, "form fields should carry the option conditions")
+
+	p.Options[0].ShowIf = "database =="
+	p.FormGroups = []FormGroup{{DisplayName: "Database"}}
+	assert.NotNil(t, p.OrganizeGroups(), "there should be an error because the condition is invalid")
+}
+
+func TestGenesisTemplate_SetValidatedOptions_Rules(t *testing.T) {
+	p := GenesisTemplate{
+		Options: []Option{
+			{Name: "name", Required: true},
+			{Name: "artifactId", Required: true},
+			{Name: "port", Type: INT_TYPE, Default: 8080},
+			{Name: "runtime", Default: "kubernetes"},
+			{Name: "framework", Default: "gin"},
+		},
+		Rules: []ValidationRule{
+			{Assert: "port >= 1024 && port <= 65535", Message: "port must be between 1024 and 65535"},
+			{When: "runtime == lambda", Assert: "framework != spring", Message: "spring is not supported on lambda"},
+			{Assert: "name != artifactId", Message: "name and artifactId must differ"},
+			{Assert: `matches(name, "^[a-z-]+$")`, Message: "name must be lower case"},
+		},
+	}
+
+	err := p.SetValidatedOptions(OptionValues{"name": "svc", "artifactId": "svc-api", "runtime": "lambda"})
+	assert.Nil(t, err)
+
+	err = p.SetValidatedOptions(OptionValues{"name": "svc", "artifactId": "svc", "port": "80", "runtime": "lambda", "framework": "spring"})
+	assert.NotNil(t, err, "there should be an error because every rule is violated")
+	assert.Contains(t, err.Error(), "port must be between 1024 and 65535")
+	assert.Contains(t, err.Error(), "spring is not supported on lambda")
+	assert.Contains(t, err.Error(), "name and artifactId must differ")
+}
@@ -0,0 +1,232 @@
+package template
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// TemplateRepositoryResolver gives access to repositories other than the one being rendered.
+// The orchestrator implements it with the configured git clients.
+type TemplateRepositoryResolver interface {
+	// CloneTemplateRepository clones the template repository registered under key, and returns
+	// the path to the cloned directory and any errors encountered
+	CloneTemplateRepository(key string) (directoryPath string, err error)
+
+	// FetchGitRepository clones a repository at the ref it declares, or at its default branch, and returns the path to the
+	// cloned directory and any errors encountered
+	FetchGitRepository(repository GenesisGitRepository) (directoryPath string, err error)
+}
+
+// extendsResolver merges base templates into the templates that extend them.
+//
+// `extends: Base` refers to a template in the same .genesis.yml, while
+// `extends: My Templates/Base` refers to the template named Base in the repository
+// registered as "My Templates".
+type extendsResolver struct {
+	templateApi *GenesisTemplateApi
+	projects    map[string]*GenesisProject
+	repoDirs    map[string]string
+	resolved    map[string]bool
+}
+
+func (gTemplateApi *GenesisTemplateApi) resolveExtends(projects *GenesisProject) error {
+	r := &extendsResolver{
+		templateApi: gTemplateApi,
+		projects:    map[string]*GenesisProject{gTemplateApi.DirectoryPath: projects},
+		repoDirs:    make(map[string]string),
+		resolved:    make(map[string]bool),
+	}
+	for i := range projects.Projects {
+		err := r.resolve(gTemplateApi.DirectoryPath, &projects.Projects[i], nil)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *extendsResolver) resolve(directoryPath string, project *GenesisTemplate, chain []string) error {
+	key := directoryPath + "\x00" + project.Name
+	if project.Extends == "" || r.resolved[key] {
+		return nil
+	}
+	for _, visited := range chain {
+		if visited == key {
+			return errors.Errorf("template %s extends itself through %s", project.Name, project.Extends)
+		}
+	}
+
+	baseDirectory, base, err := r.find(directoryPath, project.namespace, project.Extends)
+	if err != nil {
+		return errors.Wrapf(err, "unable to resolve extends for template %s", project.Name)
+	}
+
+	err = r.resolve(baseDirectory, base, append(chain, key))
+	if err != nil {
+		return err
+	}
+
+	err = project.mergeBase(directoryPath, base, baseDirectory)
+	if err != nil {
+		return err
+	}
+	r.resolved[key] = true
+	return nil
+}
+
+// find looks for the base template in the same descriptor first, then in the same repository,
+// then in another template repository
+func (r *extendsResolver) find(directoryPath, namespace, extends string) (string, *GenesisTemplate, error) {
+	if namespace != "" {
+		if base := r.projects[directoryPath].find(joinNamespace(namespace, extends)); base != nil {
+			return directoryPath, base, nil
+		}
+	}
+	if base := r.projects[directoryPath].find(extends); base != nil {
+		return directoryPath, base, nil
+	}
+
+	split := strings.SplitN(extends, "/", 2)
+	if len(split) != 2 {
+		return "", nil, errors.Errorf("no template named %s", extends)
+	}
+	repoKey, name := split[0], split[1]
+	if r.templateApi.Resolver == nil {
+		return "", nil, errors.Errorf("no template named %s, and other template repositories are not available", extends)
+	}
+
+	repoDirectory, ok := r.repoDirs[repoKey]
+	if !ok {
+		var err error
+		repoDirectory, err = r.templateApi.Resolver.CloneTemplateRepository(repoKey)
+		if err != nil {
+			return "", nil, err
+		}
+		r.templateApi.clonedDirectories = append(r.templateApi.clonedDirectories, repoDirectory)
+		projects, err := loadGenesisProject(repoDirectory)
+		if err != nil {
+			return "", nil, err
+		}
+		r.repoDirs[repoKey] = repoDirectory
+		r.projects[repoDirectory] = &projects
+	}
+
+	base := r.projects[repoDirectory].find(name)
+	if base == nil {
+		return "", nil, errors.Errorf("no template named %s in template repository %s", name, repoKey)
+	}
+	return repoDirectory, base, nil
+}
+
+func (p *GenesisProject) find(name string) *GenesisTemplate {
+	for i := range p.Projects {
+		if p.Projects[i].Name == name {
+			return &p.Projects[i]
+		}
+	}
+	return nil
+}
+
+// mergeBase merges a resolved base template into the template. Values set by the template
+// override the base, options are merged by name, and the root of the base becomes a layer
+// underneath the root of the template.
+func (p *GenesisTemplate) mergeBase(directoryPath string, base *GenesisTemplate, baseDirectory string) error {
+	p.Options = mergeOptions(base.Options, p.Options)
+	p.FormGroups = mergeFormGroups(base.FormGroups, p.FormGroups)
+	p.Rules = append(append([]ValidationRule{}, base.Rules...), p.Rules...)
+	p.Hooks.PostRender = append(append([]Hook{}, base.Hooks.PostRender...), p.Hooks.PostRender...)
+	p.Modules = mergeModules(base.Modules, p.Modules)
+
+	if p.Language == (Language{}) {
+		p.Language = base.Language
+	}
+	if reflect.DeepEqual(p.Runtime, Runtime{}) {
+		p.Runtime = base.Runtime
+	}
+	if p.GitRepository == (GenesisGitRepository{}) {
+		p.GitRepository = base.GitRepository
+	}
+
+	sameDirectory := filepath.Clean(directoryPath) == filepath.Clean(baseDirectory)
+	switch {
+	case p.Root == "" && sameDirectory:
+		p.Root = base.Root
+	case p.Root == "":
+		return errors.Errorf("template %s must declare a root to extend %s from another repository", p.Name, base.Name)
+	case base.Root != "" && !(sameDirectory && base.Root == p.Root):
+		p.layers = append(p.layers, Layer{Source: filepath.Join(baseDirectory, base.Root)})
+	}
+	p.layers = append(p.layers, base.layers...)
+	// partials of the template take precedence over partials of the base
+	p.partialDirectories = append(p.partialDirectories, base.partialDirectories...)
+	return nil
+}
+
+func mergeOptions(base, overrides []Option) []Option {
+	merged := append([]Option{}, base...)
+	for _, override := range overrides {
+		found := false
+		for i := range merged {
+			if merged[i].Name == override.Name {
+				merged[i] = mergeOption(merged[i], override)
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, override)
+		}
+	}
+	return merged
+}
+
+// mergeOption overrides the fields of a base option that are set by the extending template
+func mergeOption(base, override Option) Option {
+	merged := base
+	if override.Type != "" {
+		merged.Type = override.Type
+	}
+	if override.Default != nil {
+		merged.Default = override.Default
+	}
+	if override.Required {
+		merged.Required = true
+	}
+	if override.GroupName != "" {
+		merged.GroupName = override.GroupName
+	}
+	if !reflect.DeepEqual(override.FormField, FormField{}) {
+		merged.FormField = override.FormField
+	}
+	if override.ShowIf != "" {
+		merged.ShowIf = override.ShowIf
+	}
+	if override.RequiredIf != "" {
+		merged.RequiredIf = override.RequiredIf
+	}
+	if override.Secret {
+		merged.Secret = true
+	}
+	return merged
+}
+
+func mergeFormGroups(base, overrides []FormGroup) []FormGroup {
+	merged := append([]FormGroup{}, base...)
+	for _, override := range overrides {
+		found := false
+		for i := range merged {
+			if strings.ToLower(merged[i].DisplayName) == strings.ToLower(override.DisplayName) {
+				merged[i] = override
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, override)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,144 @@
+package template
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeTree creates a temporary directory with the given files, keyed by relative path
+func writeTree(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "genesis-test")
+	if err != nil {
+		t.Fatalf("problem creating temp directory %+v", err)
+	}
+	for path, content := range files {
+		fullPath := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), os.ModePerm); err != nil {
+			t.Fatalf("problem creating directory %+v", err)
+		}
+		if err := ioutil.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("problem writing file %+v", err)
+		}
+	}
+	return dir + "/"
+}
+
+func readFile(t *testing.T, path string) string {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("problem reading file %+v", err)
+	}
+	return string(content)
+}
+
+type fakeResolver map[string]string
+
+func (r fakeResolver) CloneTemplateRepository(key string) (string, error) {
+	return r[key], nil
+}
+
+func (r fakeResolver) FetchGitRepository(repository GenesisGitRepository) (string, error) {
+	directoryPath, ok := r[repository.Domain+"/"+repository.Name+"@"+repository.Ref]
+	if !ok {
+		return "", errors.Errorf("no ref %s", repository.Ref)
+	}
+	return directoryPath, nil
+}
+
+const extendsGenesisFile = `projects:
+  - name: "Base"
+    root: "base"
+    options:
+      - name: "name"
+        required: true
+      - name: "port"
+        type: "int"
+        default: 8080
+    rules:
+      - assert: "port > 1024"
+        message: "port must be above 1024"
+  - name: "Child"
+    extends: "Base"
+    root: "child"
+    options:
+      - name: "port"
+        default: 9090
+      - name: "framework"
+        default: "gin"
+`
+
+func TestGenesisTemplateApi_Extends(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		".genesis.yml":      extendsGenesisFile,
+		"base/Dockerfile":   "EXPOSE {{port}}",
+		"base/README.md":    "# base",
+		"child/README.md":   "# {{name}} with {{framework}}",
+		"child/main.go.txt": "package main",
+	})
+	defer os.RemoveAll(dir)
+
+	api := NewGenesisTemplateApi(dir)
+	project, err := api.GetProjectFromRepo("Child")
+	assert.Nil(t, err)
+
+	child := project.(*GenesisTemplate)
+	assert.Equal(t, 3, len(child.Options), "options should be merged by name")
+	assert.Equal(t, 9090, child.Options[1].Default, "the child should override option defaults")
+	assert.Equal(t, INT_TYPE, child.Options[1].Type, "the child should inherit option types")
+	assert.Equal(t, 1, len(child.Rules))
+
+	err = api.GenerateFromTemplate(project, OptionValues{"name": "svc"})
+	assert.Nil(t, err)
+	assert.Equal(t, "EXPOSE 9090", readFile(t, dir+"child/Dockerfile"), "base files should be rendered in the child root")
+	assert.Equal(t, "# svc with gin", readFile(t, dir+"child/README.md"), "child files should override base files")
+
+	err = api.GenerateFromTemplate(project, OptionValues{"name": "svc", "port": "80"})
+	assert.NotNil(t, err, "base rules should apply to the child")
+}
+
+func TestGenesisTemplateApi_ExtendsRemote(t *testing.T) {
+	baseDir := writeTree(t, map[string]string{
+		".genesis.yml":  "projects:\n  - name: \"Base\"\n    root: \"base\"\n    options:\n      - name: \"name\"\n",
+		"base/ci.yml":   "name: {{name}}",
+		"base/LICENSE":  "Apache",
+		"other/ignored": "not part of the base",
+	})
+	defer os.RemoveAll(baseDir)
+	dir := writeTree(t, map[string]string{
+		".genesis.yml":   "projects:\n  - name: \"Child\"\n    extends: \"Shared Templates/Base\"\n    root: \"child\"\n",
+		"child/LICENSE":  "MIT",
+		"child/.gitkeep": "",
+	})
+	defer os.RemoveAll(dir)
+
+	api := NewGenesisTemplateApi(dir)
+	api.Resolver = fakeResolver{"Shared Templates": baseDir}
+	project, err := api.GetProjectFromRepo("Child")
+	assert.Nil(t, err)
+
+	err = api.GenerateFromTemplate(project, OptionValues{"name": "svc"})
+	assert.Nil(t, err)
+	assert.Equal(t, "name: svc", readFile(t, dir+"child/ci.yml"))
+	assert.Equal(t, "MIT", readFile(t, dir+"child/LICENSE"))
+	_, err = os.Stat(dir + "child/ignored")
+	assert.True(t, os.IsNotExist(err))
+
+	assert.Nil(t, api.Cleanup())
+	_, err = os.Stat(baseDir)
+	assert.True(t, os.IsNotExist(err), "the clone of the extended repository should be deleted")
+}
+
+func TestGenesisTemplateApi_ExtendsCycle(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		".genesis.yml": "projects:\n  - name: \"A\"\n    extends: \"B\"\n    root: \"a\"\n  - name: \"B\"\n    extends: \"A\"\n    root: \"b\"\n",
+	})
+	defer os.RemoveAll(dir)
+
+	_, err := NewGenesisTemplateApi(dir).GetProjectsFromRepo()
+	assert.NotNil(t, err, "there should be an error because the templates extend each other")
+}
@@ -0,0 +1,336 @@
+package template
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const runtimeOptionName = "runtime"
+
+// conditionIdentifier matches the option names and unquoted values that conditions of a form schema can compare
+var conditionIdentifier = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// FormSchema describes the form of a template for generic form renderers: Schema is a JSON Schema
+// of the option values, and UISchema lays the fields out, in the style of react-jsonschema-form.
+// UISchema["ui:order"] lists the fields in form order, UISchema["ui:groups"] lists the form groups
+// with their fields, and each field may have ui:widget, ui:placeholder, ui:help, ui:options, and
+// the ui:showIf and ui:requiredIf expressions used by the server-side validation. Options with
+// showIf or requiredIf are not in the top-level required list: when their conditions are an option
+// or an option == value, they are required with if/then in allOf, and otherwise the form renderer
+// must enforce ui:showIf and ui:requiredIf, which the server validates in any case.
+type FormSchema struct {
+	Schema   *JSONSchema            `json:"schema" yaml:"schema"`
+	UISchema map[string]interface{} `json:"uiSchema" yaml:"uiSchema"`
+}
+
+// FormGroupLayout is a form group in the UI schema
+type FormGroupLayout struct {
+	Title      string   `json:"title" yaml:"title"`
+	Fields     []string `json:"fields" yaml:"fields"`
+	ImageGroup bool     `json:"imageGroup,omitempty" yaml:"imageGroup,omitempty"`
+}
+
+// formFieldWidgets maps the form field types to the widgets of the UI schema
+var formFieldWidgets = map[FormFieldType]string{
+	TEXT_AREA:          "textarea",
+	COLOR:              "color",
+	DATE:               "date",
+	DATETIME_LOCAL:     "datetime",
+	MONTH:              "month",
+	PASSWORD:           "password",
+	SEARCH:             "search",
+	TEL:                "tel",
+	TIME:               "time",
+	URL:                "uri",
+	WEEK:               "week",
+	SELECT:             "select",
+	SELECT_TEMPLATE:    "select",
+	AUTOCOMPLETE:       "autocomplete",
+	CHECKBOX:           "checkbox",
+	IMAGE_BUTTON:       "imageButtons",
+	IMAGE_BUTTON_GROUP: "imageButtons",
+}
+
+// formFieldFormats maps the form field types to the formats of the JSON Schema
+var formFieldFormats = map[FormFieldType]string{
+	EMAIL:          "email",
+	DATE:           "date",
+	DATETIME_LOCAL: "date-time",
+	TIME:           "time",
+	URL:            "uri",
+	COLOR:          "color",
+}
+
+// GetFormSchema returns the form of the template as a JSON Schema of the values and a UI schema.
+// Options from an OptionsUrl are resolved like they are for the form groups.
+func (p *GenesisTemplate) GetFormSchema() (FormSchema, error) {
+	schema := &JSONSchema{
+		Schema:               "http://json-schema.org/draft-07/schema#",
+		Title:                p.Name,
+		Type:                 "object",
+		Properties:           make(map[string]*JSONSchema),
+		AdditionalProperties: false,
+	}
+	uiSchema := make(map[string]interface{})
+
+	groups := make([]FormGroupLayout, len(p.FormGroups))
+	groupIndex := make(map[string]int, len(p.FormGroups))
+	for i, group := range p.FormGroups {
+		groups[i] = FormGroupLayout{Title: group.DisplayName, Fields: []string{}, ImageGroup: group.ImageGroup}
+		groupIndex[strings.ToLower(group.DisplayName)] = i
+	}
+	var order, ungrouped []string
+	var conditional []Option
+	addToGroup := func(name, groupName string) {
+		if i, ok := groupIndex[strings.ToLower(groupName)]; ok && groupName != "" {
+			groups[i].Fields = append(groups[i].Fields, name)
+		} else {
+			ungrouped = append(ungrouped, name)
+		}
+	}
+
+	for _, option := range p.allOptions() {
+		formField, err := option.getConditionalFormField(p.optionsResolver)
+		if err != nil {
+			return FormSchema{}, err
+		}
+		if formField.Type == SECTION {
+			continue
+		}
+		property, err := option.valueSchema(formField)
+		if err != nil {
+			return FormSchema{}, err
+		}
+		schema.Properties[option.Name] = property
+		if option.GetShowIf() != "" || (!option.Required && option.GetRequiredIf() != "") {
+			conditional = append(conditional, option)
+		} else if option.Required {
+			schema.Required = append(schema.Required, option.Name)
+		}
+		if ui := fieldUISchema(formField); len(ui) > 0 {
+			uiSchema[option.Name] = ui
+		}
+		addToGroup(option.Name, option.GroupName)
+	}
+
+	if len(p.Runtime.Names) > 0 {
+		property := &JSONSchema{Type: "string", Title: p.Runtime.FormField.Label, Description: p.Runtime.FormField.Hint}
+		for _, name := range p.Runtime.Names {
+			property.OneOf = append(property.OneOf, &JSONSchema{Const: name, Title: name})
+		}
+		schema.Properties[runtimeOptionName] = property
+		if ui := fieldUISchema(p.Runtime.FormField); len(ui) > 0 {
+			uiSchema[runtimeOptionName] = ui
+		}
+		addToGroup(runtimeOptionName, p.Runtime.GroupName)
+	}
+
+	// conditions may reference any option, so they are expressed once every property is known
+	for _, option := range conditional {
+		if required, ok := conditionalRequired(option, schema.Properties); ok {
+			schema.AllOf = append(schema.AllOf, required)
+		}
+	}
+
+	for _, group := range groups {
+		order = append(order, group.Fields...)
+	}
+	order = append(order, ungrouped...)
+	if len(ungrouped) > 0 {
+		groups = append(groups, FormGroupLayout{Title: "", Fields: ungrouped})
+	}
+	uiSchema["ui:order"] = append(order, "*")
+	uiSchema["ui:groups"] = groups
+
+	return FormSchema{Schema: schema, UISchema: uiSchema}, nil
+}
+
+// conditionalRequired returns an if/then schema that requires the option when it is shown and its requiredIf holds.
+// ok is false when the option is never required, or when a condition cannot be expressed in a JSON Schema.
+func conditionalRequired(option Option, properties map[string]*JSONSchema) (*JSONSchema, bool) {
+	var conditions []string
+	if option.GetShowIf() != "" {
+		conditions = append(conditions, option.GetShowIf())
+	}
+	if !option.Required {
+		if option.GetRequiredIf() == "" {
+			return nil, false
+		}
+		conditions = append(conditions, option.GetRequiredIf())
+	}
+
+	var ifSchemas []*JSONSchema
+	for _, condition := range conditions {
+		ifSchema, ok := conditionSchema(condition, properties)
+		if !ok {
+			return nil, false
+		}
+		ifSchemas = append(ifSchemas, ifSchema)
+	}
+	required := &JSONSchema{If: ifSchemas[0], Then: &JSONSchema{Required: []string{option.Name}}}
+	if len(ifSchemas) > 1 {
+		required.If = &JSONSchema{AllOf: ifSchemas}
+	}
+	return required, true
+}
+
+// conditionSchema expresses a condition that is a boolean option, or an option == value, as a JSON Schema
+func conditionSchema(condition string, properties map[string]*JSONSchema) (*JSONSchema, bool) {
+	name, value, compared := strings.TrimSpace(condition), "true", false
+	if parts := strings.Split(condition, "=="); len(parts) == 2 {
+		name, value, compared = strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), true
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		} else if !conditionIdentifier.MatchString(value) || properties[value] != nil {
+			// other expressions, and comparisons between options
+			return nil, false
+		}
+	}
+	property, ok := properties[name]
+	if !ok || !conditionIdentifier.MatchString(name) {
+		return nil, false
+	}
+
+	var constant interface{} = value
+	switch {
+	case property.Type == "boolean":
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, false
+		}
+		constant = parsed
+	case property.Type == "integer" && compared:
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, false
+		}
+		constant = parsed
+	case property.Type != "string" || !compared:
+		return nil, false
+	}
+	return &JSONSchema{Properties: map[string]*JSONSchema{name: {Const: constant}}, Required: []string{name}}, true
+}
+
+// MarshalFormSchema returns the form schema of a template as indented JSON
+func MarshalFormSchema(project ProjectTemplate) ([]byte, error) {
+	genesisTemplate, ok := project.(*GenesisTemplate)
+	if !ok {
+		return nil, errors.Errorf("template %s does not describe a form", project.GetName())
+	}
+	formSchema, err := genesisTemplate.GetFormSchema()
+	if err != nil {
+		return nil, err
+	}
+	content, err := json.MarshalIndent(formSchema, "", "  ")
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to marshal the form schema of %s", project.GetName())
+	}
+	return append(content, '\n'), nil
+}
+
+// valueSchema returns the JSON Schema of the values of the option
+func (o Option) valueSchema(formField FormField) (*JSONSchema, error) {
+	property := &JSONSchema{
+		Title:       formField.Label,
+		Description: formField.Hint,
+		Format:      formFieldFormats[formField.Type],
+		WriteOnly:   o.IsSecret(),
+	}
+	if property.Title == "" {
+		property.Title = o.Name
+	}
+	if o.Default != nil && o.Default != "" {
+		value, err := o.ParseValue(o.Default)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid default")
+		}
+		property.Default = value
+	} else if formField.Type == CHECKBOX && formField.IsCheckedByDefault {
+		property.Default = true
+	}
+
+	// the allowed values of select fields and image buttons
+	var choices []*JSONSchema
+	switch formField.Type {
+	case SELECT, SELECT_TEMPLATE, AUTOCOMPLETE:
+		for _, option := range formField.SelectOptions {
+			choices = append(choices, &JSONSchema{Const: option.Value, Title: option.DisplayValue})
+		}
+	case IMAGE_BUTTON, IMAGE_BUTTON_GROUP:
+		for _, button := range formField.ImageButtons {
+			choices = append(choices, &JSONSchema{Const: button.Name, Title: button.DisplayName})
+		}
+	}
+
+	valueType := o.GetType()
+	switch valueType {
+	case BOOL_TYPE:
+		property.Type = "boolean"
+	case INT_TYPE:
+		property.Type = "integer"
+	case LIST_TYPE:
+		property.Type = "array"
+		property.Items = &JSONSchema{Type: "string", OneOf: choices}
+		property.UniqueItems = len(choices) > 0
+		return property, nil
+	case MAP_TYPE:
+		property.Type = "object"
+		property.AdditionalProperties = &JSONSchema{Type: "string"}
+		return property, nil
+	default:
+		property.Type = "string"
+		property.Pattern = formField.Validation
+		if formField.MaxCharacters != "" {
+			maxLength, err := strconv.Atoi(formField.MaxCharacters)
+			if err != nil {
+				return nil, errors.Errorf("maxCharacters of option %s must be a number", o.Name)
+			}
+			property.MaxLength = &maxLength
+		}
+	}
+	property.OneOf = choices
+	return property, nil
+}
+
+// fieldUISchema returns the layout of a form field in the UI schema
+func fieldUISchema(formField FormField) map[string]interface{} {
+	ui := make(map[string]interface{})
+	if widget, ok := formFieldWidgets[formField.Type]; ok {
+		ui["ui:widget"] = widget
+	}
+	if formField.Placeholder != "" {
+		ui["ui:placeholder"] = formField.Placeholder
+	}
+	if formField.Hint != "" {
+		ui["ui:help"] = formField.Hint
+	}
+	if formField.ShowIf != "" {
+		ui["ui:showIf"] = formField.ShowIf
+	}
+	if formField.RequiredIf != "" {
+		ui["ui:requiredIf"] = formField.RequiredIf
+	}
+	options := make(map[string]interface{})
+	if formField.Icon != "" {
+		options["icon"] = formField.Icon
+	}
+	if formField.ValidationErrorMessage != "" {
+		options["validationErrorMessage"] = formField.ValidationErrorMessage
+	}
+	if formField.OptionsUrl != "" {
+		// left to the form when the url depends on other values
+		options["optionsUrl"] = formField.OptionsUrl
+		options["optionsMapping"] = formField.OptionsMapping
+	}
+	if len(formField.ImageButtons) > 0 {
+		options["imageButtons"] = formField.ImageButtons
+	}
+	if len(options) > 0 {
+		ui["ui:options"] = options
+	}
+	return ui
+}
@@ -0,0 +1,84 @@
+package template
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenesisTemplate_GetFormSchema(t *testing.T) {
+	maxCharacters := 20
+	project := GenesisTemplate{
+		Name: "Go Service",
+		Options: []Option{
+			{Name: "name", Required: true, GroupName: "Main", FormField: FormField{Type: TEXT, Label: "Name", Placeholder: "orders", Validation: "^[a-z]+$", MaxCharacters: "20"}},
+			{Name: "port", Default: 8080, GroupName: "Main", FormField: FormField{Type: NUMBER, Label: "Port"}},
+			{Name: "docker", GroupName: "Build", FormField: FormField{Type: CHECKBOX, Label: "Docker", IsCheckedByDefault: true}},
+			{Name: "size", GroupName: "Build", FormField: FormField{Type: SELECT, Label: "Size", SelectOptions: []SelectOption{{Value: "small", DisplayValue: "Small"}, {Value: "large", DisplayValue: "Large"}}}},
+			{Name: "tags", Type: LIST_TYPE, FormField: FormField{Type: SELECT, Label: "Tags", SelectOptions: []SelectOption{{Value: "a", DisplayValue: "A"}}}},
+			{Name: "apiToken", Secret: true, GroupName: "Build", FormField: FormField{Type: TEXT, Label: "Token", ShowIf: "docker"}},
+			{Name: "advanced", FormField: FormField{Type: SECTION, Label: "Advanced"}},
+		},
+		FormGroups: []FormGroup{{DisplayName: "Main"}, {DisplayName: "Build"}},
+		Runtime:    Runtime{Names: []string{"go"}, FormField: FormField{Type: SELECT, Label: "Runtime"}},
+	}
+
+	formSchema, err := project.GetFormSchema()
+	assert.Nil(t, err)
+	schema := formSchema.Schema
+	assert.Equal(t, "object", schema.Type)
+	assert.Equal(t, []string{"name"}, schema.Required)
+	assert.Equal(t, &JSONSchema{Type: "string", Title: "Name", Pattern: "^[a-z]+$", MaxLength: &maxCharacters}, schema.Properties["name"])
+	assert.Equal(t, &JSONSchema{Type: "integer", Title: "Port", Default: 8080}, schema.Properties["port"])
+	assert.Equal(t, &JSONSchema{Type: "boolean", Title: "Docker", Default: true}, schema.Properties["docker"])
+	assert.Equal(t, []*JSONSchema{{Const: "small", Title: "Small"}, {Const: "large", Title: "Large"}}, schema.Properties["size"].OneOf)
+	assert.Equal(t, "array", schema.Properties["tags"].Type)
+	assert.True(t, schema.Properties["tags"].UniqueItems)
+	assert.Equal(t, []*JSONSchema{{Const: "a", Title: "A"}}, schema.Properties["tags"].Items.OneOf)
+	assert.True(t, schema.Properties["apiToken"].WriteOnly)
+	assert.Equal(t, []*JSONSchema{{Const: "go", Title: "go"}}, schema.Properties["runtime"].OneOf)
+	assert.NotContains(t, schema.Properties, "advanced")
+
+	uiSchema := formSchema.UISchema
+	assert.Equal(t, []string{"name", "port", "docker", "size", "apiToken", "tags", "runtime", "*"}, uiSchema["ui:order"])
+	assert.Equal(t, []FormGroupLayout{
+		{Title: "Main", Fields: []string{"name", "port"}},
+		{Title: "Build", Fields: []string{"docker", "size", "apiToken"}},
+		{Title: "", Fields: []string{"tags", "runtime"}},
+	}, uiSchema["ui:groups"])
+	assert.Equal(t, map[string]interface{}{"ui:placeholder": "orders"}, uiSchema["name"])
+	assert.Equal(t, map[string]interface{}{"ui:widget": "checkbox"}, uiSchema["docker"])
+	assert.Equal(t, map[string]interface{}{"ui:showIf": "docker"}, uiSchema["apiToken"])
+}
+
+func TestGenesisTemplate_GetFormSchema_Conditions(t *testing.T) {
+	project := GenesisTemplate{
+		Name: "Go Service",
+		Options: []Option{
+			{Name: "name", Required: true},
+			{Name: "dbHost", RequiredIf: "database == postgres"},
+			{Name: "registry", Required: true, ShowIf: "docker"},
+			{Name: "replicas", Required: true, ShowIf: "docker", RequiredIf: "cloud == \"on prem\""},
+			{Name: "region", Required: true, ShowIf: "cloud != onprem"},
+			{Name: "zone", RequiredIf: "cloud == region"},
+			{Name: "database", Default: "none"},
+			{Name: "docker", Type: BOOL_TYPE},
+			{Name: "cloud"},
+		},
+	}
+
+	formSchema, err := project.GetFormSchema()
+	assert.Nil(t, err)
+	schema := formSchema.Schema
+	assert.Equal(t, []string{"name"}, schema.Required, "conditional options should not always be required")
+	ifDocker := &JSONSchema{Properties: map[string]*JSONSchema{"docker": {Const: true}}, Required: []string{"docker"}}
+	assert.Equal(t, []*JSONSchema{
+		{
+			If:   &JSONSchema{Properties: map[string]*JSONSchema{"database": {Const: "postgres"}}, Required: []string{"database"}},
+			Then: &JSONSchema{Required: []string{"dbHost"}},
+		},
+		{If: ifDocker, Then: &JSONSchema{Required: []string{"registry"}}},
+		{If: ifDocker, Then: &JSONSchema{Required: []string{"replicas"}}},
+	}, schema.AllOf, "conditions that are not an option or an option == value should be left to the ui schema")
+	assert.Equal(t, map[string]interface{}{"ui:showIf": "cloud != onprem"}, formSchema.UISchema["region"])
+}
@@ -0,0 +1,192 @@
+package template
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	goldenTestsDirectoryName = "tests"
+	goldenCaseFileName       = "case.yml"
+	goldenExpectedName       = "expected"
+)
+
+// GoldenCase is a test case of a template repository, in tests/<case>/case.yml. The template is
+// rendered with the options and compared with the tree in tests/<case>/expected.
+type GoldenCase struct {
+	Template string                 `yaml:"template" json:"template"`
+	Options  map[string]interface{} `yaml:"options" json:"options"`
+}
+
+// GoldenResult is the outcome of a golden test case
+type GoldenResult struct {
+	Case    string `json:"case" yaml:"case"`
+	Passed  bool   `json:"passed" yaml:"passed"`
+	Updated bool   `json:"updated,omitempty" yaml:"updated,omitempty"`
+	// Diff shows how the rendered tree differs from the expected tree, in unified diff format
+	Diff  string `json:"diff,omitempty" yaml:"diff,omitempty"`
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// RunGoldenTests renders every case under tests/ in a temporary copy of the template repository,
+// and compares the result with the expected tree of the case. With update, the expected trees
+// are replaced with the rendered trees instead. Hooks are not run.
+func (gTemplateApi *GenesisTemplateApi) RunGoldenTests(update bool) ([]GoldenResult, error) {
+	testsDirectory := filepath.Join(gTemplateApi.DirectoryPath, goldenTestsDirectoryName)
+	entries, err := ioutil.ReadDir(testsDirectory)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read test cases from %s", testsDirectory)
+	}
+
+	var results []GoldenResult
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		result := GoldenResult{Case: entry.Name()}
+		diff, err := gTemplateApi.runGoldenCase(filepath.Join(testsDirectory, entry.Name()), update)
+		switch {
+		case err != nil:
+			result.Error = err.Error()
+		case update:
+			result.Passed = true
+			result.Updated = true
+		default:
+			result.Passed = diff == ""
+			result.Diff = diff
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (gTemplateApi *GenesisTemplateApi) runGoldenCase(caseDirectory string, update bool) (string, error) {
+	file, err := ioutil.ReadFile(filepath.Join(caseDirectory, goldenCaseFileName))
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to read %s", goldenCaseFileName)
+	}
+	var goldenCase GoldenCase
+	err = yaml.Unmarshal(file, &goldenCase)
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to unmarshal %s", goldenCaseFileName)
+	}
+
+	// render in a copy so that the template repository is left untouched
+	workDirectory, err := ioutil.TempDir("", "genesis-golden")
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to create a directory for the test case")
+	}
+	defer os.RemoveAll(workDirectory)
+	err = applyLayer(workDirectory, Layer{Source: gTemplateApi.DirectoryPath})
+	if err != nil {
+		return "", err
+	}
+
+	api := NewGenesisTemplateApi(workDirectory + "/")
+	api.Resolver = gTemplateApi.Resolver
+	defer api.Cleanup()
+	project, err := api.GetProjectFromRepo(goldenCase.Template)
+	if err != nil {
+		return "", err
+	}
+	err = api.GenerateFromTemplate(project, NormalizeOptionValues(goldenCase.Options))
+	if err != nil {
+		return "", err
+	}
+	root, err := project.GetRoot()
+	if err != nil {
+		return "", err
+	}
+	rendered := filepath.Join(workDirectory, root)
+	expected := filepath.Join(caseDirectory, goldenExpectedName)
+
+	if update {
+		err = os.RemoveAll(expected)
+		if err != nil {
+			return "", errors.Wrapf(err, "unable to remove %s", expected)
+		}
+		return "", applyLayer(expected, Layer{Source: rendered})
+	}
+	return diffTrees(expected, rendered)
+}
+
+// diffTrees returns the differences between the files of two directories in unified diff format
+func diffTrees(expected, actual string) (string, error) {
+	expectedFiles, err := listFiles(expected)
+	if err != nil {
+		return "", err
+	}
+	actualFiles, err := listFiles(actual)
+	if err != nil {
+		return "", err
+	}
+
+	paths := make([]string, 0, len(expectedFiles)+len(actualFiles))
+	for path := range expectedFiles {
+		paths = append(paths, path)
+	}
+	for path := range actualFiles {
+		if !expectedFiles[path] {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var diff bytes.Buffer
+	for _, path := range paths {
+		var expectedContent, actualContent []byte
+		fromName, toName := "expected/"+path, "rendered/"+path
+		if expectedFiles[path] {
+			expectedContent, err = ioutil.ReadFile(filepath.Join(expected, path))
+			if err != nil {
+				return "", errors.Wrapf(err, "unable to read file from path %s", path)
+			}
+		} else {
+			fromName = "/dev/null"
+		}
+		if actualFiles[path] {
+			actualContent, err = ioutil.ReadFile(filepath.Join(actual, path))
+			if err != nil {
+				return "", errors.Wrapf(err, "unable to read file from path %s", path)
+			}
+		} else {
+			toName = "/dev/null"
+		}
+		diff.WriteString(UnifiedDiff(fromName, toName, expectedContent, actualContent))
+	}
+	return diff.String(), nil
+}
+
+// listFiles returns the paths of the files under a directory, relative to it
+func listFiles(directory string) (map[string]bool, error) {
+	files := make(map[string]bool)
+	if _, err := os.Stat(directory); os.IsNotExist(err) {
+		return files, nil
+	}
+	err := filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if !info.IsDir() {
+			rel, err := filepath.Rel(directory, path)
+			if err != nil {
+				return err
+			}
+			files[filepath.ToSlash(rel)] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to list files in %s", directory)
+	}
+	return files, nil
+}
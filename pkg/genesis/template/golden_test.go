@@ -0,0 +1,86 @@
+package template
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenesisTemplateApi_RunGoldenTests(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		".genesis.yml":                  "projects:\n  - name: \"Service\"\n    root: \"base\"\n    options:\n      - name: \"name\"\n      - name: \"port\"\n        type: \"int\"\n",
+		"base/{{name}}.txt":             "service {{name}}\nport {{port}}\n",
+		"tests/basic/case.yml":          "template: \"Service\"\noptions:\n  name: \"svc\"\n  port: 8080\n",
+		"tests/basic/expected/svc.txt":  "service svc\nport 8080\n",
+		"tests/stale/case.yml":          "template: \"Service\"\noptions:\n  name: \"api\"\n  port: 9090\n",
+		"tests/stale/expected/api.txt":  "service api\nport 8080\n",
+		"tests/stale/expected/old.txt":  "removed\n",
+		"tests/broken/case.yml":         "template: \"Missing\"\n",
+		"tests/broken/expected/api.txt": "",
+	})
+	defer os.RemoveAll(dir)
+	api := NewGenesisTemplateApi(dir)
+
+	results, err := api.RunGoldenTests(false)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(results))
+
+	assert.Equal(t, "basic", results[0].Case)
+	assert.True(t, results[0].Passed)
+
+	assert.Equal(t, "broken", results[1].Case)
+	assert.False(t, results[1].Passed)
+	assert.NotEqual(t, "", results[1].Error)
+
+	assert.Equal(t, "stale", results[2].Case)
+	assert.False(t, results[2].Passed)
+	assert.Equal(t, `--- expected/api.txt
++++ rendered/api.txt
+@@ -1,2 +1,2 @@
+ service api
+-port 8080
++port 9090
+--- expected/old.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-removed
+`, results[2].Diff)
+	assert.Equal(t, "service {{name}}\nport {{port}}\n", readFile(t, dir+"base/{{name}}.txt"), "the template should not be rendered in place")
+
+	results, err = api.RunGoldenTests(true)
+	assert.Nil(t, err)
+	assert.True(t, results[2].Updated)
+	assert.Equal(t, "service api\nport 9090\n", readFile(t, dir+"tests/stale/expected/api.txt"))
+	_, err = os.Stat(dir + "tests/stale/expected/old.txt")
+	assert.True(t, os.IsNotExist(err))
+
+	results, err = api.RunGoldenTests(false)
+	assert.Nil(t, err)
+	assert.True(t, results[2].Passed)
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	from := []byte(strings.Repeat("same\n", 10) + "old\n" + strings.Repeat("same\n", 10))
+	to := []byte(strings.Repeat("same\n", 10) + "new\n" + strings.Repeat("same\n", 10) + "last")
+	assert.Equal(t, `--- a
++++ b
+@@ -8,7 +8,7 @@
+ same
+ same
+ same
+-old
++new
+ same
+ same
+ same
+@@ -19,3 +19,4 @@
+ same
+ same
+ same
++last
+\ No newline at end of file
+`, UnifiedDiff("a", "b", from, to))
+	assert.Equal(t, "", UnifiedDiff("a", "b", from, from))
+}
@@ -0,0 +1,247 @@
+package template
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultHookTimeout = 60 * time.Second
+	maxHookOutput      = 64 * 1024
+)
+
+// approximate an Enum
+type HookStatus string
+
+const (
+	HOOK_SUCCEEDED HookStatus = "SUCCEEDED"
+	HOOK_FAILED    HookStatus = "FAILED"
+	HOOK_SKIPPED   HookStatus = "SKIPPED"
+)
+
+// GenesisHooks are commands declared by a template that run while a project is generated
+type GenesisHooks struct {
+	// PostRender commands run in the rendered root before the initial commit
+	PostRender []Hook `yaml:"post_render,omitempty" json:"post_render,omitempty"`
+}
+
+// Hook is a single command run with `sh -c`. The command and env values may use option
+// tokens, e.g. `chmod +x {{name}}.sh`. Option values are shell-quoted in the command, and every
+// option is also passed as a GENESIS_OPT_<name> environment variable.
+type Hook struct {
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+	// Command to run, rendered with the validated options
+	Command string `yaml:"command" json:"command"`
+	// WorkingDir is relative to the rendered root and defaults to the root itself
+	WorkingDir string `yaml:"workingDir,omitempty" json:"workingDir,omitempty"`
+	// Timeout in seconds, defaults to 60
+	Timeout int `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	// Env adds variables to the filtered environment of the command
+	Env map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
+	// When is an optional expression, the hook only runs when it is true
+	When string `yaml:"when,omitempty" json:"when,omitempty"`
+	// AllowFailure lets generation continue when the command fails
+	AllowFailure bool `yaml:"allowFailure,omitempty" json:"allowFailure,omitempty"`
+}
+
+// HookPolicy is the operator configuration for running template hooks
+type HookPolicy struct {
+	// Enabled must be true for any hook to run. Hooks are skipped otherwise.
+	Enabled bool
+	// AllowedEnv lists the environment variables passed through to hook commands
+	AllowedEnv []string
+	// MaxTimeout caps the timeout a hook may request, if set
+	MaxTimeout time.Duration
+}
+
+// HookResult records the outcome of a hook for the generation report
+type HookResult struct {
+	Name     string     `json:"name" yaml:"name"`
+	Command  string     `json:"command" yaml:"command"`
+	Status   HookStatus `json:"status" yaml:"status"`
+	ExitCode int        `json:"exitCode" yaml:"exitCode"`
+	Output   string     `json:"output,omitempty" yaml:"output,omitempty"`
+	Duration string     `json:"duration" yaml:"duration"`
+}
+
+func (h Hook) getName() string {
+	if h.Name != "" {
+		return h.Name
+	}
+	return h.Command
+}
+
+func (h Hook) getTimeout(policy HookPolicy) time.Duration {
+	timeout := defaultHookTimeout
+	if h.Timeout > 0 {
+		timeout = time.Duration(h.Timeout) * time.Second
+	}
+	if policy.MaxTimeout > 0 && timeout > policy.MaxTimeout {
+		timeout = policy.MaxTimeout
+	}
+	return timeout
+}
+
+// RunHooks runs each hook in order in the rendered root directory. It stops at the first
+// failing hook that does not allow failure, and returns the results gathered so far.
+func RunHooks(root string, hooks []Hook, options OptionValues, policy HookPolicy) ([]HookResult, error) {
+	results := make([]HookResult, 0, len(hooks))
+	for _, hook := range hooks {
+		result := HookResult{Name: hook.getName(), Command: hook.Command, Status: HOOK_SKIPPED}
+
+		if !policy.Enabled {
+			result.Output = "hooks are disabled by the operator"
+			results = append(results, result)
+			continue
+		}
+
+		if hook.When != "" {
+			applies, err := EvaluateCondition(hook.When, options)
+			if err != nil {
+				return results, errors.Wrapf(err, "invalid when for hook %s", result.Name)
+			}
+			if !applies {
+				results = append(results, result)
+				continue
+			}
+		}
+
+		result, err := runHook(root, hook, options, policy)
+		results = append(results, result)
+		if err != nil && !hook.AllowFailure {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+func runHook(root string, hook Hook, options OptionValues, policy HookPolicy) (HookResult, error) {
+	result := HookResult{Name: hook.getName(), Status: HOOK_FAILED, ExitCode: -1}
+
+	command, err := renderHookCommand(hook.Command, options)
+	if err != nil {
+		return result, errors.Wrapf(err, "unable to render command for hook %s", result.Name)
+	}
+	result.Command = command
+
+	workingDir, err := hookWorkingDir(root, hook.WorkingDir)
+	if err != nil {
+		return result, err
+	}
+
+	env, err := hookEnvironment(hook.Env, options, policy)
+	if err != nil {
+		return result, errors.Wrapf(err, "unable to render environment for hook %s", result.Name)
+	}
+
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Dir = workingDir
+	cmd.Env = env
+	setProcessGroup(cmd)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	start := time.Now()
+	err = cmd.Start()
+	if err != nil {
+		return result, errors.Wrapf(err, "unable to start hook %s", result.Name)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	timedOut := false
+	select {
+	case err = <-done:
+	case <-time.After(hook.getTimeout(policy)):
+		timedOut = true
+		killProcessGroup(cmd)
+		err = <-done
+	}
+	result.Duration = time.Since(start).Round(time.Millisecond).String()
+	result.Output = truncateOutput(output.String())
+
+	if timedOut {
+		return result, errors.Errorf("hook %s timed out after %s", result.Name, hook.getTimeout(policy))
+	}
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			result.ExitCode = exitErr.ExitCode()
+		}
+		return result, errors.Wrapf(err, "hook %s failed: %s", result.Name, result.Output)
+	}
+
+	result.Status = HOOK_SUCCEEDED
+	result.ExitCode = 0
+	return result, nil
+}
+
+// hookWorkingDir resolves the working directory and ensures it stays inside the rendered root
+func hookWorkingDir(root, workingDir string) (string, error) {
+	dir := filepath.Join(root, workingDir)
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+		return "", errors.Errorf("hook working directory %s is outside of the project root", workingDir)
+	}
+	return dir, nil
+}
+
+// renderHookCommand replaces the option tokens of a command with their shell-quoted values, so that a value
+// is always a single word and is never interpreted by the shell
+func renderHookCommand(command string, options OptionValues) (string, error) {
+	return replaceTokens(command, options, func(rendered, value string) string {
+		return shellQuote(value)
+	})
+}
+
+// shellQuote quotes value as a single word for sh
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// hookEnvironment passes through only the variables allowed by the operator, the options as
+// GENESIS_OPT_<name> variables, and the variables declared by the hook. Env values are rendered
+// with the options as is, as they are not interpreted by the shell.
+func hookEnvironment(hookEnv map[string]string, options OptionValues, policy HookPolicy) ([]string, error) {
+	env := make([]string, 0, len(policy.AllowedEnv)+len(options)+len(hookEnv))
+	for _, name := range policy.AllowedEnv {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	for _, name := range sortedKeys(options) {
+		env = append(env, EnvOptionPrefix+name+"="+FormatValue(options[name]))
+	}
+	for _, name := range sortedKeys(toInterfaceMap(hookEnv)) {
+		value, err := StringRecursiveReplace(hookEnv[name], options)
+		if err != nil {
+			return nil, err
+		}
+		env = append(env, name+"="+value)
+	}
+	return env, nil
+}
+
+func toInterfaceMap(m map[string]string) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		result[key] = value
+	}
+	return result
+}
+
+func truncateOutput(output string) string {
+	if len(output) <= maxHookOutput {
+		return output
+	}
+	return "[output truncated]\n" + output[len(output)-maxHookOutput:]
+}
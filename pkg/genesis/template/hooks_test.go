@@ -0,0 +1,72 @@
+package template
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunHooks(t *testing.T) {
+	root, err := ioutil.TempDir("", "genesis-hooks")
+	if err != nil {
+		t.Fatalf("problem creating temp directory %+v", err)
+	}
+	defer os.RemoveAll(root)
+
+	err = os.Setenv("GENESIS_HOOK_SECRET", "do-not-leak")
+	assert.Nil(t, err)
+	defer os.Unsetenv("GENESIS_HOOK_SECRET")
+
+	hooks := []Hook{
+		{Name: "write", Command: "echo {{name}} > name.txt && echo $GREETING-$GENESIS_HOOK_SECRET", Env: map[string]string{"GREETING": "hello {{name}}"}},
+		{Name: "skipped", Command: "exit 1", When: "docker"},
+		{Name: "allowed failure", Command: "exit 3", AllowFailure: true},
+	}
+	options := OptionValues{"name": "svc", "docker": false}
+	policy := HookPolicy{Enabled: true, AllowedEnv: []string{"PATH"}}
+
+	results, err := RunHooks(root, hooks, options, policy)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(results))
+	assert.Equal(t, HOOK_SUCCEEDED, results[0].Status)
+	assert.Equal(t, "hello svc-\n", results[0].Output, "only allowed environment variables should be passed through")
+	assert.Equal(t, HOOK_SKIPPED, results[1].Status)
+	assert.Equal(t, HOOK_FAILED, results[2].Status)
+	assert.Equal(t, 3, results[2].ExitCode)
+
+	content, err := ioutil.ReadFile(filepath.Join(root, "name.txt"))
+	assert.Nil(t, err)
+	assert.Equal(t, "svc\n", string(content))
+
+	results, err = RunHooks(root, []Hook{{Command: "exit 1"}}, options, HookPolicy{})
+	assert.Nil(t, err, "hooks should be skipped when disabled by the operator")
+	assert.Equal(t, HOOK_SKIPPED, results[0].Status)
+
+	_, err = RunHooks(root, []Hook{{Command: "true", WorkingDir: "../"}}, options, policy)
+	assert.NotNil(t, err, "there should be an error because the working directory is outside of the root")
+
+	_, err = RunHooks(root, []Hook{{Command: "sleep 5", Timeout: 1}}, options, policy)
+	assert.NotNil(t, err, "there should be an error because the hook timed out")
+}
+
+func TestRunHooks_OptionValuesAreQuoted(t *testing.T) {
+	root, err := ioutil.TempDir("", "genesis-hooks")
+	if err != nil {
+		t.Fatalf("problem creating temp directory %+v", err)
+	}
+	defer os.RemoveAll(root)
+
+	options := OptionValues{"name": "svc'; touch injected; echo '"}
+	policy := HookPolicy{Enabled: true, AllowedEnv: []string{"PATH"}}
+	hooks := []Hook{{Command: `echo {{name}} && echo "$GENESIS_OPT_name"`}}
+
+	results, err := RunHooks(root, hooks, options, policy)
+	assert.Nil(t, err)
+	assert.Equal(t, `echo 'svc'\''; touch injected; echo '\''' && echo "$GENESIS_OPT_name"`, results[0].Command)
+	assert.Equal(t, "svc'; touch injected; echo '\nsvc'; touch injected; echo '\n", results[0].Output)
+	_, err = os.Stat(filepath.Join(root, "injected"))
+	assert.True(t, os.IsNotExist(err), "option values should not be interpreted by the shell")
+}
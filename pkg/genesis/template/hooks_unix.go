@@ -0,0 +1,19 @@
+//go:build !windows
+// +build !windows
+
+package template
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup runs the hook in its own process group so a timeout also stops any child processes
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessGroup stops the hook and its child processes
+func killProcessGroup(cmd *exec.Cmd) {
+	_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,16 @@
+//go:build windows
+// +build windows
+
+package template
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup does nothing, as Windows has no process groups to signal
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup stops the hook. Its child processes are not stopped on Windows.
+func killProcessGroup(cmd *exec.Cmd) {
+	_ = cmd.Process.Kill()
+}
@@ -0,0 +1,46 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// includeLayer fetches the repository included by a template and returns it as a layer mounted
+// at the declared path. Files of the template take precedence over included files.
+func (gTemplateApi *GenesisTemplateApi) includeLayer(repository GenesisGitRepository) (Layer, error) {
+	if repository.Domain == "" || repository.Name == "" {
+		return Layer{}, errors.Errorf("git.domain and git.name are required to include a repository")
+	}
+	if !isRelativeInside(repository.Path) {
+		return Layer{}, errors.Errorf("git.path %s must be inside the project root", repository.Path)
+	}
+	if !isRelativeInside(repository.Source) {
+		return Layer{}, errors.Errorf("git.source %s must be inside the included repository", repository.Source)
+	}
+	if gTemplateApi.Resolver == nil {
+		return Layer{}, errors.Errorf("unable to include repository %s/%s, other repositories are not available", repository.Domain, repository.Name)
+	}
+
+	directoryPath, err := gTemplateApi.Resolver.FetchGitRepository(repository)
+	if err != nil {
+		return Layer{}, errors.Wrapf(err, "unable to fetch repository %s/%s", repository.Domain, repository.Name)
+	}
+	gTemplateApi.clonedDirectories = append(gTemplateApi.clonedDirectories, directoryPath)
+	source := filepath.Join(directoryPath, repository.Source)
+	if info, err := os.Stat(source); err != nil || !info.IsDir() {
+		return Layer{}, errors.Errorf("%s is not a directory in repository %s/%s", repository.Source, repository.Domain, repository.Name)
+	}
+	return Layer{Source: source, Target: repository.Path}, nil
+}
+
+// isRelativeInside reports whether a relative path stays inside the directory it is joined to
+func isRelativeInside(path string) bool {
+	if filepath.IsAbs(path) {
+		return false
+	}
+	clean := filepath.Clean(path)
+	return clean != ".." && !strings.HasPrefix(clean, ".."+string(os.PathSeparator))
+}
@@ -0,0 +1,54 @@
+package template
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenesisTemplateApi_Include(t *testing.T) {
+	platformDir := writeTree(t, map[string]string{
+		"ci/pipeline.yml": "service: {{name}}",
+		"ci/Makefile":     "shared",
+		"docs/ignored.md": "not included",
+	})
+	defer os.RemoveAll(platformDir)
+	dir := writeTree(t, map[string]string{
+		".genesis.yml":      "projects:\n  - name: \"Service\"\n    root: \"base\"\n    git:\n      domain: \"PLATFORM\"\n      name: \"assets\"\n      ref: \"v1.2.0\"\n      source: \"ci\"\n      path: \".ci\"\n    options:\n      - name: \"name\"\n",
+		"base/.ci/Makefile": "overridden",
+	})
+	defer os.RemoveAll(dir)
+
+	api := NewGenesisTemplateApi(dir)
+	api.Resolver = fakeResolver{"PLATFORM/assets@v1.2.0": platformDir, "PLATFORM/assets@": platformDir}
+	project, err := api.GetProjectFromRepo("Service")
+	assert.Nil(t, err)
+
+	err = api.GenerateFromTemplate(project, OptionValues{"name": "svc"})
+	assert.Nil(t, err)
+	assert.Equal(t, "service: svc", readFile(t, dir+"base/.ci/pipeline.yml"), "included files should be rendered at the declared path")
+	assert.Equal(t, "overridden", readFile(t, dir+"base/.ci/Makefile"), "template files should override included files")
+	_, err = os.Stat(dir + "base/.ci/ignored.md")
+	assert.True(t, os.IsNotExist(err))
+
+	// without a ref, the default branch is included
+	layer, err := api.includeLayer(GenesisGitRepository{Domain: "PLATFORM", Name: "assets", Source: "ci"})
+	assert.Nil(t, err)
+	assert.Equal(t, platformDir+"ci", layer.Source)
+
+	assert.Nil(t, api.Cleanup())
+	_, err = os.Stat(platformDir)
+	assert.True(t, os.IsNotExist(err), "the clone of the included repository should be deleted")
+}
+
+func TestGenesisTemplateApi_IncludeErrors(t *testing.T) {
+	api := NewGenesisTemplateApi("/tmp/")
+	api.Resolver = fakeResolver{}
+
+	_, err := api.includeLayer(GenesisGitRepository{Domain: "PLATFORM", Name: "assets", Ref: "v1", Path: "../outside"})
+	assert.NotNil(t, err, "there should be an error because the path is outside of the root")
+
+	_, err = api.includeLayer(GenesisGitRepository{Domain: "PLATFORM", Name: "assets", Ref: "v1"})
+	assert.NotNil(t, err, "there should be an error because the ref does not exist")
+}
@@ -0,0 +1,161 @@
+package template
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Layer is a directory of files placed into the root of a project, such as the root of a base
+// template or a selected module.
+type Layer struct {
+	// Source is the absolute path of the directory to copy from
+	Source string
+	// Target is the path inside the project root to copy to
+	Target string
+	// Overlay layers are rendered on their own after the project root is rendered, and are then
+	// placed on top of it following OnConflict and Merge. Other layers are copied in before the
+	// project is rendered, and files already present in the root take precedence.
+	Overlay    bool
+	OnConflict ConflictStrategy
+	Merge      []MergeRule
+}
+
+// applyLayer copies the files of a layer into the project root
+func applyLayer(root string, layer Layer) error {
+	return copyTree(layer.Source, filepath.Join(root, layer.Target), func(rel, source, destination string, info os.FileInfo) error {
+		if _, err := os.Stat(destination); err == nil {
+			// files in the project take precedence over the layer
+			return nil
+		}
+		return copyFileWithMode(source, destination, info.Mode())
+	})
+}
+
+// applyOverlay renders the files of an overlay layer and places them into the rendered project root
+func applyOverlay(root string, layer Layer, options OptionValues, partialDirectories []string) error {
+	rendered, err := ioutil.TempDir("", "genesis-overlay")
+	if err != nil {
+		return errors.Wrapf(err, "unable to create a directory for layer %s", layer.Source)
+	}
+	defer os.RemoveAll(rendered)
+
+	err = applyLayer(rendered, Layer{Source: layer.Source})
+	if err != nil {
+		return err
+	}
+	err = renderDirectory(rendered, options, partialDirectories)
+	if err != nil {
+		return err
+	}
+
+	return copyTree(rendered, filepath.Join(root, layer.Target), func(rel, source, destination string, info os.FileInfo) error {
+		if _, err := os.Stat(destination); os.IsNotExist(err) {
+			return copyFileWithMode(source, destination, info.Mode())
+		}
+		path := filepath.ToSlash(filepath.Join(layer.Target, rel))
+		return resolveConflict(layer.strategyFor(path), path, source, destination, info.Mode())
+	})
+}
+
+// strategyFor returns the conflict strategy for a path relative to the project root
+func (layer Layer) strategyFor(path string) ConflictStrategy {
+	for _, rule := range layer.Merge {
+		pattern := filepath.ToSlash(rule.Path)
+		if matched, _ := filepath.Match(pattern, path); matched {
+			return rule.Strategy
+		}
+		if !strings.Contains(pattern, "/") {
+			if matched, _ := filepath.Match(pattern, filepath.Base(path)); matched {
+				return rule.Strategy
+			}
+		}
+	}
+	if layer.OnConflict == "" {
+		return CONFLICT_OVERWRITE
+	}
+	return layer.OnConflict
+}
+
+func resolveConflict(strategy ConflictStrategy, path, source, destination string, mode os.FileMode) error {
+	switch strategy {
+	case CONFLICT_SKIP:
+		return nil
+	case CONFLICT_OVERWRITE:
+		return copyFileWithMode(source, destination, mode)
+	case CONFLICT_ERROR:
+		return errors.Errorf("%s is provided by more than one layer of the template", path)
+	case CONFLICT_APPEND, CONFLICT_MERGE:
+		existing, err := ioutil.ReadFile(destination)
+		if err != nil {
+			return errors.Wrapf(err, "unable to read file from path %s", destination)
+		}
+		addition, err := ioutil.ReadFile(source)
+		if err != nil {
+			return errors.Wrapf(err, "unable to read file from path %s", source)
+		}
+
+		var content []byte
+		if strategy == CONFLICT_MERGE {
+			content, err = mergeStructuredFile(path, existing, addition)
+			if err != nil {
+				return err
+			}
+		} else {
+			content = existing
+			if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+				content = append(content, '\n')
+			}
+			content = append(content, addition...)
+		}
+		err = ioutil.WriteFile(destination, content, mode)
+		if err != nil {
+			return errors.Wrapf(err, "unable to write file to path %s", destination)
+		}
+		return nil
+	default:
+		return errors.Errorf("unknown conflict strategy %s for %s", strategy, path)
+	}
+}
+
+// copyTree walks the source directory, creating its directories under target and calling place
+// for every file, with the path of the file relative to source
+func copyTree(source, target string, place func(rel, source, destination string, info os.FileInfo) error) error {
+	return filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return errors.Wrapf(err, "unable to read layer %s", source)
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(source, path)
+		if err != nil {
+			return errors.Wrapf(err, "unable to resolve path %s", path)
+		}
+		destination := filepath.Join(target, rel)
+		if info.IsDir() {
+			err = os.MkdirAll(destination, os.ModePerm)
+			if err != nil {
+				return errors.Wrapf(err, "unable to run MkdirAll on path %s", destination)
+			}
+			return nil
+		}
+		return place(rel, path, destination, info)
+	})
+}
+
+func copyFileWithMode(source, destination string, mode os.FileMode) error {
+	content, err := ioutil.ReadFile(source)
+	if err != nil {
+		return errors.Wrapf(err, "unable to read file from path %s", source)
+	}
+	err = ioutil.WriteFile(destination, content, mode)
+	if err != nil {
+		return errors.Wrapf(err, "unable to write file to path %s", destination)
+	}
+	return nil
+}
@@ -0,0 +1,424 @@
+package template
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LintSeverity is how serious a lint issue is
+type LintSeverity string
+
+// approximate an Enum
+const (
+	LINT_ERROR   LintSeverity = "error"
+	LINT_WARNING LintSeverity = "warning"
+)
+
+// LintIssue is a problem found in a template before it is published
+type LintIssue struct {
+	Template string       `json:"template,omitempty" yaml:"template,omitempty"`
+	File     string       `json:"file" yaml:"file"`
+	Line     int          `json:"line,omitempty" yaml:"line,omitempty"`
+	Severity LintSeverity `json:"severity" yaml:"severity"`
+	Rule     string       `json:"rule" yaml:"rule"`
+	Message  string       `json:"message" yaml:"message"`
+}
+
+func (issue LintIssue) String() string {
+	location := issue.File
+	if issue.Line > 0 {
+		location = fmt.Sprintf("%s:%d", issue.File, issue.Line)
+	}
+	if issue.Template != "" {
+		location = fmt.Sprintf("%s [%s]", location, issue.Template)
+	}
+	return fmt.Sprintf("%s: %s: %s (%s)", location, issue.Severity, issue.Message, issue.Rule)
+}
+
+// Lint checks every template in the directory for tokens that are not declared as options,
+// options that are never used, unknown filters, unclosed delimiters, paths that collide once
+// rendered and mistakes in the form configuration. Problems in .genesis.yml itself are returned
+// as issues of the schema rule.
+func (gTemplateApi *GenesisTemplateApi) Lint() ([]LintIssue, error) {
+	projects, err := gTemplateApi.GetProjectsFromRepo()
+	if validationErrors, ok := err.(ValidationErrors); ok {
+		issues := make([]LintIssue, len(validationErrors))
+		for i, validationError := range validationErrors {
+			file := validationError.File
+			if file == "" {
+				file = genesisFileName
+			}
+			issues[i] = LintIssue{File: file, Line: validationError.Line, Severity: LINT_ERROR, Rule: "schema", Message: validationError.Path + ": " + validationError.Message}
+		}
+		return issues, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var issues []LintIssue
+	for i := range projects.Projects {
+		linter := newTemplateLinter(gTemplateApi.DirectoryPath, &projects.Projects[i])
+		for _, issue := range linter.lint() {
+			// templates that share a base report the same problems in its files once
+			key := issue.File + "\x00" + fmt.Sprint(issue.Line) + "\x00" + issue.Rule + "\x00" + issue.Message
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			issues = append(issues, issue)
+		}
+	}
+	return issues, nil
+}
+
+type templateLinter struct {
+	directoryPath string
+	project       *GenesisTemplate
+	options       []Option
+	declared      map[string]bool
+	referenced    map[string]bool
+	partials      map[string]bool
+	issues        []LintIssue
+}
+
+func newTemplateLinter(directoryPath string, project *GenesisTemplate) *templateLinter {
+	linter := &templateLinter{
+		directoryPath: directoryPath,
+		project:       project,
+		options:       project.allOptions(),
+		declared:      make(map[string]bool),
+		referenced:    make(map[string]bool),
+		partials:      make(map[string]bool),
+	}
+	for _, option := range linter.options {
+		linter.declared[option.Name] = true
+	}
+	return linter
+}
+
+func (linter *templateLinter) report(severity LintSeverity, rule, file string, line int, format string, args ...interface{}) {
+	linter.issues = append(linter.issues, LintIssue{
+		Template: linter.project.Name,
+		File:     file,
+		Line:     line,
+		Severity: severity,
+		Rule:     rule,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (linter *templateLinter) lint() []LintIssue {
+	linter.lintDescriptor()
+
+	trees := []string{filepath.Join(linter.directoryPath, linter.project.Root)}
+	for _, layer := range linter.project.layers {
+		trees = append(trees, filepath.Join(layer.Source, layer.Target))
+	}
+	for _, module := range linter.project.Modules {
+		trees = append(trees, module.layer().Source)
+	}
+	for i, tree := range trees {
+		if info, err := os.Stat(tree); err != nil || !info.IsDir() {
+			if i == 0 {
+				linter.report(LINT_ERROR, "missing-root", linter.project.getDescriptor(), 0, "root %s does not exist", linter.project.Root)
+			}
+			continue
+		}
+		linter.lintTree(tree)
+	}
+
+	for _, option := range linter.options {
+		if !linter.referenced[option.Name] {
+			linter.report(LINT_WARNING, "unused-option", linter.project.getDescriptor(), 0, "option %s is never used in the template", option.Name)
+		}
+	}
+
+	sort.SliceStable(linter.issues, func(i, j int) bool {
+		if linter.issues[i].File != linter.issues[j].File {
+			return linter.issues[i].File < linter.issues[j].File
+		}
+		return linter.issues[i].Line < linter.issues[j].Line
+	})
+	return linter.issues
+}
+
+// lintDescriptor checks the options and records the options referenced by expressions and hooks
+func (linter *templateLinter) lintDescriptor() {
+	project := linter.project
+	groups := make(map[string]bool)
+	for _, group := range project.FormGroups {
+		groups[strings.ToLower(group.DisplayName)] = true
+	}
+
+	for _, option := range linter.options {
+		if option.Required && option.Default != nil && option.Default != "" {
+			linter.report(LINT_WARNING, "required-default", linter.project.getDescriptor(), 0, "option %s is required, so its default %s is never used", option.Name, FormatValue(option.Default))
+		}
+		if option.GroupName != "" && !groups[strings.ToLower(option.GroupName)] {
+			linter.report(LINT_WARNING, "missing-group", linter.project.getDescriptor(), 0, "option %s is in group %s, but there is no form group with that name", option.Name, option.GroupName)
+		}
+		linter.referenceExpression(option.GetShowIf())
+		linter.referenceExpression(option.GetRequiredIf())
+	}
+	if project.Runtime.GroupName != "" && !groups[strings.ToLower(project.Runtime.GroupName)] {
+		linter.report(LINT_WARNING, "missing-group", linter.project.getDescriptor(), 0, "runtime is in group %s, but there is no form group with that name", project.Runtime.GroupName)
+	}
+
+	for _, rule := range project.Rules {
+		linter.referenceExpression(rule.When)
+		linter.referenceExpression(rule.Assert)
+	}
+	for _, module := range project.Modules {
+		linter.referenceExpression(project.moduleCondition(module))
+	}
+	for _, hook := range project.Hooks.PostRender {
+		linter.referenceExpression(hook.When)
+		linter.lintTokens(hook.Command, linter.project.getDescriptor(), false)
+		for _, value := range hook.Env {
+			linter.lintTokens(value, linter.project.getDescriptor(), false)
+		}
+	}
+}
+
+// referenceExpression records the options used by an expression
+func (linter *templateLinter) referenceExpression(expression string) {
+	for _, name := range expressionIdentifiers(expression) {
+		if linter.declared[name] {
+			linter.referenced[name] = true
+		}
+	}
+}
+
+func (linter *templateLinter) lintTree(tree string) {
+	type renderedPath struct {
+		source string
+		path   string
+	}
+	var rendered []renderedPath
+
+	_ = filepath.Walk(tree, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		rel, _ := filepath.Rel(tree, path)
+		if rel == "." {
+			return nil
+		}
+		file := linter.relativePath(path)
+		linter.lintTokens(info.Name(), file, true)
+		if info.IsDir() {
+			return nil
+		}
+		rendered = append(rendered, renderedPath{source: file, path: linter.renderPath(rel)})
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			linter.report(LINT_ERROR, "unreadable-file", file, 0, "unable to read file: %s", err)
+			return nil
+		}
+		linter.lintTokens(string(content), file, false)
+		return nil
+	})
+
+	sort.SliceStable(rendered, func(i, j int) bool { return rendered[i].source < rendered[j].source })
+	first := make(map[string]string)
+	for _, r := range rendered {
+		if other, ok := first[r.path]; ok {
+			linter.report(LINT_ERROR, "path-collision", r.source, 0, "%s and %s both render to %s with the default values", other, r.source, r.path)
+			continue
+		}
+		first[r.path] = r.source
+	}
+}
+
+// lintTokens checks the {{...}} tags of a document, or of a file or directory name
+func (linter *templateLinter) lintTokens(document, file string, isPath bool) {
+	tags, unclosedLine := scanTags(document)
+	for _, tag := range tags {
+		line := tag.line
+		if isPath {
+			line = 0
+		}
+		linter.lintTag(tag.text, file, line)
+	}
+	if unclosedLine > 0 {
+		if isPath {
+			unclosedLine = 0
+		}
+		linter.report(LINT_ERROR, "unclosed-delimiter", file, unclosedLine, "{{ is never closed with }}")
+	}
+}
+
+func (linter *templateLinter) lintTag(text, file string, line int) {
+	switch {
+	case strings.HasPrefix(text, ">"):
+		linter.lintPartial(strings.TrimSpace(text[1:]), file, line)
+	case strings.HasPrefix(text, "#"):
+		fields := strings.Fields(text[1:])
+		if len(fields) == 0 {
+			linter.report(LINT_ERROR, "invalid-block", file, line, "block {{%s}} has no name", text)
+			return
+		}
+		argument := strings.TrimSpace(strings.TrimPrefix(text[1:], fields[0]))
+		switch fields[0] {
+		case "if", "unless":
+			linter.referenceExpression(argument)
+			// a condition that is a single name is most likely an option
+			name := strings.TrimSpace(strings.TrimPrefix(argument, "!"))
+			if identifiers := expressionIdentifiers(argument); len(identifiers) == 1 && identifiers[0] == name {
+				linter.checkKey(name, file, line)
+			}
+		case "each":
+			linter.checkKey(argument, file, line)
+		default:
+			linter.report(LINT_ERROR, "invalid-block", file, line, "unknown block {{#%s}}", fields[0])
+		}
+	case strings.HasPrefix(text, "/") || text == "else":
+		return
+	default:
+		split := strings.Split(text, "|")
+		linter.checkKey(strings.TrimSpace(split[0]), file, line)
+		for _, filterName := range split[1:] {
+			filterName = strings.TrimSpace(filterName)
+			if _, ok := LookupFilter(filterName); !ok {
+				linter.report(LINT_ERROR, "unknown-filter", file, line, "unknown filter %s in {{%s}}", filterName, text)
+			}
+		}
+	}
+}
+
+// checkKey reports keys that do not name a declared option
+func (linter *templateLinter) checkKey(key, file string, line int) {
+	if key == "this" || strings.HasPrefix(key, "this.") || strings.HasPrefix(key, "@") {
+		return
+	}
+	name := strings.SplitN(key, ".", 2)[0]
+	if linter.declared[name] {
+		linter.referenced[name] = true
+		return
+	}
+	linter.report(LINT_ERROR, "undeclared-token", file, line, "%s is not declared as an option", key)
+}
+
+func (linter *templateLinter) lintPartial(name, file string, line int) {
+	for _, directory := range linter.project.partialDirectories {
+		path := filepath.Join(directory, name)
+		matches, _ := filepath.Glob(path + ".*")
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			matches = []string{path}
+		}
+		if len(matches) == 0 {
+			continue
+		}
+		if linter.partials[matches[0]] {
+			return
+		}
+		linter.partials[matches[0]] = true
+		content, err := ioutil.ReadFile(matches[0])
+		if err == nil {
+			linter.lintTokens(string(content), linter.relativePath(matches[0]), false)
+		}
+		return
+	}
+	linter.report(LINT_ERROR, "missing-partial", file, line, "partial %s was not found", name)
+}
+
+// renderPath renders a path relative to a root with sample values for the options, the defaults
+// or else the option names, following the rules used for file and directory names
+func (linter *templateLinter) renderPath(rel string) string {
+	samples := make(map[string]string, len(linter.options))
+	for _, option := range linter.options {
+		samples[option.Name] = option.Name
+		if option.Default != nil && FormatValue(option.Default) != "" {
+			samples[option.Name] = FormatValue(option.Default)
+		}
+	}
+
+	segments := strings.Split(filepath.ToSlash(rel), "/")
+	for i, segment := range segments {
+		for key, value := range samples {
+			token := "{{" + key + "}}"
+			if i < len(segments)-1 {
+				// directories are only renamed when the placeholder is the whole name, and
+				// dotted values such as Java packages become nested directories
+				if segment == token {
+					segment = strings.ReplaceAll(value, ".", "/")
+				}
+			} else {
+				segment = strings.ReplaceAll(segment, token, value)
+			}
+		}
+		segments[i] = segment
+	}
+	return strings.Join(segments, "/")
+}
+
+func (linter *templateLinter) relativePath(path string) string {
+	if rel, err := filepath.Rel(linter.directoryPath, path); err == nil && isRelativeInside(rel) {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+type lintTag struct {
+	text string
+	line int
+}
+
+// scanTags returns the trimmed content of the {{...}} tags in a document, and the line of the
+// first delimiter that is not closed, or 0
+func scanTags(document string) ([]lintTag, int) {
+	var tags []lintTag
+	offset := 0
+	for {
+		start := strings.Index(document[offset:], "{{")
+		if start == -1 {
+			return tags, 0
+		}
+		start += offset
+		line := strings.Count(document[:start], "\n") + 1
+		end := strings.Index(document[start+2:], "}}")
+		nested := strings.Index(document[start+2:], "{{")
+		if end == -1 || (nested != -1 && nested < end) {
+			return tags, line
+		}
+		end += start + 2
+		tags = append(tags, lintTag{text: strings.TrimSpace(document[start+2 : end]), line: line})
+		offset = end + 2
+	}
+}
+
+// expressionIdentifiers returns the names used by an expression, other than function names and literals
+func expressionIdentifiers(expression string) []string {
+	if expression == "" {
+		return nil
+	}
+	tokens, err := tokenizeExpression(expression)
+	if err != nil {
+		return nil
+	}
+	var identifiers []string
+	for i, token := range tokens {
+		if token.kind != identToken {
+			continue
+		}
+		if i+1 < len(tokens) && tokens[i+1].kind == operatorToken && tokens[i+1].text == "(" {
+			continue
+		}
+		switch token.text {
+		case "true", "false", "null", "in":
+			continue
+		}
+		identifiers = append(identifiers, strings.SplitN(token.text, ".", 2)[0])
+	}
+	return identifiers
+}
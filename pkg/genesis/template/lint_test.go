@@ -0,0 +1,72 @@
+package template
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const lintGenesisFile = `projects:
+  - name: "Service"
+    root: "base"
+    formGroups:
+      - displayName: "General"
+    options:
+      - name: "name"
+        required: true
+        default: "app"
+        groupName: "General"
+      - name: "service"
+        default: "app"
+        groupName: "Generl"
+      - name: "database"
+      - name: "unused"
+      - name: "tags"
+        type: "list"
+        showIf: "database != none"
+`
+
+func TestGenesisTemplateApi_Lint(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		".genesis.yml":            lintGenesisFile,
+		"base/{{name}}.go":        "package {{name | lower}}\n",
+		"base/{{service}}.go":     "package {{service | camel}}\n",
+		"base/README.md":          "# {{name}}\n{{#if debug}}debug{{/if}}\n{{#each tags}}- {{this}}\n{{/each}}\nBroken {{name\n",
+		"base/{{owner}}/.gitkeep": "",
+	})
+	defer os.RemoveAll(dir)
+
+	issues, err := NewGenesisTemplateApi(dir).Lint()
+	assert.Nil(t, err)
+
+	rules := make(map[string][]string)
+	for _, issue := range issues {
+		rules[issue.Rule] = append(rules[issue.Rule], issue.Message)
+	}
+	assert.Equal(t, []string{"option name is required, so its default app is never used"}, rules["required-default"])
+	assert.Equal(t, []string{"option service is in group Generl, but there is no form group with that name"}, rules["missing-group"])
+	assert.Equal(t, []string{"option unused is never used in the template"}, rules["unused-option"], "options used in paths, blocks and showIf are used")
+	assert.Equal(t, []string{"debug is not declared as an option", "owner is not declared as an option"}, rules["undeclared-token"])
+	assert.Equal(t, []string{"unknown filter camel in {{service | camel}}"}, rules["unknown-filter"])
+	assert.Equal(t, []string{"{{ is never closed with }}"}, rules["unclosed-delimiter"])
+	assert.Equal(t, []string{"base/{{name}}.go and base/{{service}}.go both render to app.go with the default values"}, rules["path-collision"])
+
+	for _, issue := range issues {
+		if issue.Rule == "unclosed-delimiter" {
+			assert.Equal(t, "base/README.md", issue.File)
+			assert.Equal(t, 5, issue.Line)
+		}
+	}
+}
+
+func TestGenesisTemplateApi_LintSchema(t *testing.T) {
+	dir := writeTree(t, map[string]string{".genesis.yml": "projects:\n  - name: \"Service\"\n    rot: \"base\"\n"})
+	defer os.RemoveAll(dir)
+
+	issues, err := NewGenesisTemplateApi(dir).Lint()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(issues))
+	assert.Equal(t, "schema", issues[0].Rule)
+	assert.Equal(t, 3, issues[0].Line)
+}
@@ -0,0 +1,153 @@
+package template
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	manifestDirectoryName = ".genesis"
+	manifestFileName      = "answers.yml"
+	redactedValue         = "<redacted>"
+)
+
+// Manifest records how a project was generated. It is committed into the generated repository
+// as .genesis/answers.yml.
+type Manifest struct {
+	Template    ManifestTemplate `yaml:"template" json:"template"`
+	GeneratedAt string           `yaml:"generatedAt" json:"generatedAt"`
+	// Options are the validated options, with the values of secret options redacted
+	Options OptionValues `yaml:"options" json:"options"`
+	// Files maps the path of every generated file to its sha256 checksum
+	Files map[string]string `yaml:"files" json:"files"`
+}
+
+// ManifestTemplate identifies the template version a project was generated from
+type ManifestTemplate struct {
+	// Repository is the key of the template repository
+	Repository string `yaml:"repository" json:"repository"`
+	Name       string `yaml:"name" json:"name"`
+	// Ref is the tag or branch of the template repository
+	Ref string `yaml:"ref,omitempty" json:"ref,omitempty"`
+	// Commit is the resolved commit SHA of the template repository
+	Commit string `yaml:"commit,omitempty" json:"commit,omitempty"`
+}
+
+// IsSecret reports whether the value of the option must not be recorded
+func (o Option) IsSecret() bool {
+	return o.Secret || o.FormField.Type == PASSWORD
+}
+
+// NewManifest describes a rendered project, redacting the secret options of the template
+func NewManifest(project ProjectTemplate, source ManifestTemplate, root string) (Manifest, error) {
+	validatedOptions, err := project.GetValidatedOptions()
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	options := make(OptionValues, len(validatedOptions))
+	for key, value := range validatedOptions {
+		options[key] = value
+	}
+	for _, option := range project.GetOptions() {
+		if _, ok := options[option.Name]; ok && option.IsSecret() {
+			options[option.Name] = redactedValue
+		}
+	}
+
+	files, err := checksumFiles(root)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	if source.Name == "" {
+		source.Name = project.GetName()
+	}
+	return Manifest{
+		Template:    source,
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		Options:     options,
+		Files:       files,
+	}, nil
+}
+
+// WriteManifest writes the manifest to .genesis/answers.yml in the rendered root
+func WriteManifest(root string, manifest Manifest) error {
+	directory := filepath.Join(root, manifestDirectoryName)
+	err := os.MkdirAll(directory, os.ModePerm)
+	if err != nil {
+		return errors.Wrapf(err, "unable to run MkdirAll on path %s", directory)
+	}
+	content, err := yaml.Marshal(manifest)
+	if err != nil {
+		return errors.Wrapf(err, "unable to marshal the generation manifest")
+	}
+	path := filepath.Join(directory, manifestFileName)
+	err = ioutil.WriteFile(path, content, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "unable to write file to path %s", path)
+	}
+	return nil
+}
+
+// ReadManifest reads .genesis/answers.yml from the root of a generated project
+func ReadManifest(root string) (Manifest, error) {
+	path := filepath.Join(root, manifestDirectoryName, manifestFileName)
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Manifest{}, errors.Wrapf(err, "unable to read the generation manifest from %s", path)
+	}
+	var manifest Manifest
+	err = yaml.Unmarshal(content, &manifest)
+	if err != nil {
+		return Manifest{}, errors.Wrapf(err, "unable to unmarshal the generation manifest")
+	}
+	manifest.Options = NormalizeOptionValues(manifest.Options)
+	return manifest, nil
+}
+
+// checksumFiles returns the sha256 checksum of every file under root, except the manifest itself
+func checksumFiles(root string) (map[string]string, error) {
+	files, err := listFiles(root)
+	if err != nil {
+		return nil, err
+	}
+	checksums := make(map[string]string, len(files))
+	for path := range files {
+		if filepath.Dir(path) == manifestDirectoryName && filepath.Base(path) == manifestFileName {
+			continue
+		}
+		content, err := ioutil.ReadFile(filepath.Join(root, path))
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read file from path %s", path)
+		}
+		sum := sha256.Sum256(content)
+		checksums[path] = "sha256:" + hex.EncodeToString(sum[:])
+	}
+	return checksums, nil
+}
+
+// UpdateOptions returns the options recorded in the manifest, replaced by any overrides. Secret
+// options were redacted when the manifest was written, so they must be passed again.
+func (m Manifest) UpdateOptions(overrides OptionValues) (OptionValues, error) {
+	options := make(OptionValues, len(m.Options)+len(overrides))
+	for key, value := range m.Options {
+		options[key] = value
+	}
+	for key, value := range overrides {
+		options[key] = value
+	}
+	for key, value := range options {
+		if value == redactedValue {
+			return nil, errors.Errorf("the secret option %s was not recorded and must be passed again", key)
+		}
+	}
+	return options, nil
+}
@@ -0,0 +1,46 @@
+package template
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManifest(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		".genesis.yml":   "projects:\n  - name: \"Service\"\n    root: \"base\"\n    options:\n      - name: \"name\"\n      - name: \"token\"\n        secret: true\n      - name: \"password\"\n        formField:\n          type: \"PASSWORD\"\n",
+		"base/README.md": "# {{name}}",
+	})
+	defer os.RemoveAll(dir)
+
+	api := NewGenesisTemplateApi(dir)
+	project, err := api.GetProjectFromRepo("Service")
+	assert.Nil(t, err)
+	err = api.GenerateFromTemplate(project, OptionValues{"name": "svc", "token": "abc", "password": "hunter2"})
+	assert.Nil(t, err)
+
+	manifest, err := NewManifest(project, ManifestTemplate{Repository: "Templates", Ref: "v1.0.0", Commit: "0a1b2c"}, dir+"base")
+	assert.Nil(t, err)
+	err = WriteManifest(dir+"base", manifest)
+	assert.Nil(t, err)
+
+	written, err := ReadManifest(dir + "base")
+	assert.Nil(t, err)
+	assert.Equal(t, ManifestTemplate{Repository: "Templates", Name: "Service", Ref: "v1.0.0", Commit: "0a1b2c"}, written.Template)
+	assert.Equal(t, OptionValues{"name": "svc", "token": "<redacted>", "password": "<redacted>"}, written.Options)
+	assert.Equal(t, map[string]string{"README.md": "sha256:d73c08275d4adb491a4237031ceb7db99a7dc1721a38f8bee718e0a4c3668648"}, written.Files)
+	assert.NotEqual(t, "", written.GeneratedAt)
+}
+
+func TestManifest_UpdateOptions(t *testing.T) {
+	manifest := Manifest{Options: OptionValues{"name": "svc", "port": 8080, "token": redactedValue}}
+
+	_, err := manifest.UpdateOptions(OptionValues{"port": 9090})
+	assert.NotNil(t, err)
+
+	options, err := manifest.UpdateOptions(OptionValues{"port": 9090, "token": "abc"})
+	assert.Nil(t, err)
+	assert.Equal(t, OptionValues{"name": "svc", "port": 9090, "token": "abc"}, options)
+	assert.Equal(t, 8080, manifest.Options["port"])
+}
@@ -0,0 +1,159 @@
+package template
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// mergeStructuredFile deep merges two YAML or JSON documents. Keys of the addition override keys
+// of the existing document, mappings are merged recursively and lists are combined without
+// duplicates. The order of keys is preserved.
+func mergeStructuredFile(path string, existing, addition []byte) ([]byte, error) {
+	extension := strings.ToLower(filepath.Ext(path))
+	if extension != ".yml" && extension != ".yaml" && extension != ".json" {
+		return nil, errors.Errorf("unable to merge %s, only YAML and JSON files can be merged", path)
+	}
+
+	// JSON documents are valid YAML, which keeps the order of keys when parsed into a MapSlice
+	var base, overlay yaml.MapSlice
+	err := yaml.Unmarshal(existing, &base)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to merge %s, the existing file is not a mapping", path)
+	}
+	err = yaml.Unmarshal(addition, &overlay)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to merge %s, the module file is not a mapping", path)
+	}
+	merged := mergeMapSlices(base, overlay)
+
+	if extension == ".json" {
+		var buf bytes.Buffer
+		err = writeOrderedJSON(&buf, merged, "")
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to write merged file %s", path)
+		}
+		buf.WriteString("\n")
+		return buf.Bytes(), nil
+	}
+	content, err := yaml.Marshal(merged)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to write merged file %s", path)
+	}
+	return content, nil
+}
+
+func mergeMapSlices(base, overlay yaml.MapSlice) yaml.MapSlice {
+	merged := append(yaml.MapSlice{}, base...)
+	for _, item := range overlay {
+		found := false
+		for i := range merged {
+			if reflect.DeepEqual(merged[i].Key, item.Key) {
+				merged[i].Value = mergeYamlValues(merged[i].Value, item.Value)
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, item)
+		}
+	}
+	return merged
+}
+
+func mergeYamlValues(base, overlay interface{}) interface{} {
+	switch overlayValue := overlay.(type) {
+	case yaml.MapSlice:
+		if baseValue, ok := base.(yaml.MapSlice); ok {
+			return mergeMapSlices(baseValue, overlayValue)
+		}
+	case []interface{}:
+		if baseValue, ok := base.([]interface{}); ok {
+			merged := append([]interface{}{}, baseValue...)
+			for _, item := range overlayValue {
+				if !containsValue(merged, item) {
+					merged = append(merged, item)
+				}
+			}
+			return merged
+		}
+	}
+	return overlay
+}
+
+func containsValue(list []interface{}, value interface{}) bool {
+	for _, item := range list {
+		if reflect.DeepEqual(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeOrderedJSON writes a parsed document as indented JSON without sorting its keys
+func writeOrderedJSON(buf *bytes.Buffer, value interface{}, indent string) error {
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		if len(v) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+		buf.WriteString("{\n")
+		for i, item := range v {
+			buf.WriteString(indent + "  ")
+			err := writeJSONScalar(buf, fmt.Sprint(item.Key))
+			if err != nil {
+				return err
+			}
+			buf.WriteString(": ")
+			err = writeOrderedJSON(buf, item.Value, indent+"  ")
+			if err != nil {
+				return err
+			}
+			if i < len(v)-1 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString(indent + "}")
+	case []interface{}:
+		if len(v) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+		buf.WriteString("[\n")
+		for i, item := range v {
+			buf.WriteString(indent + "  ")
+			err := writeOrderedJSON(buf, item, indent+"  ")
+			if err != nil {
+				return err
+			}
+			if i < len(v)-1 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString(indent + "]")
+	default:
+		return writeJSONScalar(buf, v)
+	}
+	return nil
+}
+
+func writeJSONScalar(buf *bytes.Buffer, value interface{}) error {
+	var scalar bytes.Buffer
+	encoder := json.NewEncoder(&scalar)
+	encoder.SetEscapeHTML(false)
+	err := encoder.Encode(value)
+	if err != nil {
+		return err
+	}
+	buf.Write(bytes.TrimSuffix(scalar.Bytes(), []byte("\n")))
+	return nil
+}
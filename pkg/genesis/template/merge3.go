@@ -0,0 +1,104 @@
+package template
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Merge3 merges the changes from base to theirs into ours, line by line. Regions changed on
+// both sides in different ways are kept with conflict markers, and conflict is true.
+func Merge3(base, ours, theirs []byte, oursLabel, theirsLabel string) (merged []byte, conflict bool) {
+	switch {
+	case bytes.Equal(ours, theirs) || bytes.Equal(base, theirs):
+		return ours, false
+	case bytes.Equal(base, ours):
+		return theirs, false
+	case bytes.IndexByte(base, 0) != -1 || bytes.IndexByte(ours, 0) != -1 || bytes.IndexByte(theirs, 0) != -1:
+		// binary files changed on both sides cannot be merged, keep ours
+		return ours, true
+	}
+
+	baseLines, oursLines, theirsLines := splitLines(base), splitLines(ours), splitLines(theirs)
+	oursMatch := matchLines(baseLines, oursLines)
+	theirsMatch := matchLines(baseLines, theirsLines)
+
+	var out []string
+	i, a, b := 0, 0, 0
+	for i < len(baseLines) || a < len(oursLines) || b < len(theirsLines) {
+		if i < len(baseLines) && oursMatch[i] == a && theirsMatch[i] == b {
+			// unchanged on both sides
+			out = append(out, baseLines[i])
+			i, a, b = i+1, a+1, b+1
+			continue
+		}
+
+		// the next base line kept on both sides ends the changed region
+		k := i
+		for k < len(baseLines) && (oursMatch[k] == -1 || theirsMatch[k] == -1) {
+			k++
+		}
+		aEnd, bEnd := len(oursLines), len(theirsLines)
+		if k < len(baseLines) {
+			aEnd, bEnd = oursMatch[k], theirsMatch[k]
+		}
+		baseChunk, oursChunk, theirsChunk := baseLines[i:k], oursLines[a:aEnd], theirsLines[b:bEnd]
+
+		switch {
+		case equalLines(oursChunk, baseChunk):
+			out = append(out, theirsChunk...)
+		case equalLines(theirsChunk, baseChunk), equalLines(oursChunk, theirsChunk):
+			out = append(out, oursChunk...)
+		default:
+			conflict = true
+			out = append(out, "<<<<<<< "+oursLabel+"\n")
+			out = appendTerminated(out, oursChunk)
+			out = append(out, "=======\n")
+			out = appendTerminated(out, theirsChunk)
+			out = append(out, ">>>>>>> "+theirsLabel+"\n")
+		}
+		i, a, b = k, aEnd, bEnd
+	}
+	return []byte(strings.Join(out, "")), conflict
+}
+
+// matchLines maps every line of a to the line of b it is kept as, or -1 when it is removed
+func matchLines(a, b []string) []int {
+	match := make([]int, len(a))
+	i, j := 0, 0
+	for _, op := range diffLines(a, b) {
+		switch op.kind {
+		case ' ':
+			match[i] = j
+			i, j = i+1, j+1
+		case '-':
+			match[i] = -1
+			i++
+		default:
+			j++
+		}
+	}
+	return match
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// appendTerminated appends lines, making sure that the last one ends with a newline
+func appendTerminated(out, lines []string) []string {
+	for i, line := range lines {
+		if i == len(lines)-1 && !strings.HasSuffix(line, "\n") {
+			line += "\n"
+		}
+		out = append(out, line)
+	}
+	return out
+}
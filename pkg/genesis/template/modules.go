@@ -0,0 +1,159 @@
+package template
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ConflictStrategy decides what happens when a module contains a file that already exists in the project
+type ConflictStrategy string
+
+// approximate an Enum
+const (
+	CONFLICT_OVERWRITE ConflictStrategy = "overwrite"
+	CONFLICT_SKIP      ConflictStrategy = "skip"
+	CONFLICT_APPEND    ConflictStrategy = "append"
+	CONFLICT_MERGE     ConflictStrategy = "merge"
+	CONFLICT_ERROR     ConflictStrategy = "error"
+)
+
+// MergeRule sets the conflict strategy for the files of a module that match Path, a glob relative
+// to the project root. A pattern without a "/" is also matched against file names, e.g.
+//
+//	merge:
+//	  - path: "docker-compose.yml"
+//	    strategy: "merge"
+type MergeRule struct {
+	Path     string           `yaml:"path" json:"path"`
+	Strategy ConflictStrategy `yaml:"strategy" json:"strategy"`
+}
+
+// GenesisModule is an optional directory, with its own options, that is layered on top of the
+// root of a template when the user selects it.
+//
+// A module is selected when When evaluates to true. Without When, it is selected when an option
+// offers an image button of type MODULE, DEPENDENCY or FRAMEWORK with the name of the module and
+// the user picks that button, or else when the option with the same name as the module is true.
+type GenesisModule struct {
+	Name       string           `yaml:"name" json:"name"`
+	Root       string           `yaml:"root" json:"root"`
+	When       string           `yaml:"when,omitempty" json:"when,omitempty"`
+	Options    []Option         `yaml:"options,omitempty" json:"options,omitempty"`
+	OnConflict ConflictStrategy `yaml:"onConflict,omitempty" json:"onConflict,omitempty"`
+	Merge      []MergeRule      `yaml:"merge,omitempty" json:"merge,omitempty"`
+	// directoryPath is the directory of the .genesis.yml that declares the module
+	directoryPath string
+}
+
+var moduleButtonTypes = map[DisplayOptionType]bool{
+	MODULE:     true,
+	DEPENDENCY: true,
+	FRAMEWORK:  true,
+}
+
+// moduleCondition returns the expression that selects the module
+func (p *GenesisTemplate) moduleCondition(module GenesisModule) string {
+	if module.When != "" {
+		return module.When
+	}
+
+	var conditions []string
+	quotedName := strconv.Quote(module.Name)
+	for _, option := range p.Options {
+		for _, button := range option.FormField.ImageButtons {
+			if !moduleButtonTypes[button.Type] || button.Name != module.Name {
+				continue
+			}
+			if option.GetType() == LIST_TYPE {
+				conditions = append(conditions, quotedName+" in "+option.Name)
+			} else {
+				conditions = append(conditions, option.Name+" == "+quotedName)
+			}
+			break
+		}
+	}
+	if len(conditions) > 0 {
+		return strings.Join(conditions, " || ")
+	}
+
+	for _, option := range p.Options {
+		if option.Name == module.Name {
+			return option.Name
+		}
+	}
+	return "false"
+}
+
+// allOptions returns the options of the template followed by the options of its modules,
+// which are only shown while their module is selected
+func (p *GenesisTemplate) allOptions() []Option {
+	if len(p.Modules) == 0 {
+		return p.Options
+	}
+	options := append([]Option{}, p.Options...)
+	for _, module := range p.Modules {
+		condition := p.moduleCondition(module)
+		for _, option := range module.Options {
+			if showIf := option.GetShowIf(); showIf != "" {
+				option.ShowIf = "(" + condition + ") && (" + showIf + ")"
+			} else {
+				option.ShowIf = condition
+			}
+			options = append(options, option)
+		}
+	}
+	return options
+}
+
+// selectModules returns the layers of the modules selected by the options
+func (p *GenesisTemplate) selectModules(values OptionValues) ([]Layer, error) {
+	scope := make(OptionValues, len(p.Options))
+	for _, option := range p.allOptions() {
+		scope[option.Name] = nil
+	}
+	for key, value := range values {
+		scope[key] = value
+	}
+
+	var layers []Layer
+	for _, module := range p.Modules {
+		selected, err := EvaluateCondition(p.moduleCondition(module), scope)
+		if err != nil {
+			return nil, err
+		}
+		if selected {
+			layers = append(layers, module.layer())
+		}
+	}
+	return layers, nil
+}
+
+func (module GenesisModule) layer() Layer {
+	return Layer{
+		Source:     filepath.Join(module.directoryPath, module.Root),
+		Overlay:    true,
+		OnConflict: module.OnConflict,
+		Merge:      module.Merge,
+	}
+}
+
+// mergeModules adds the modules of a base template, modules of the extending template replace
+// base modules with the same name
+func mergeModules(base, overrides []GenesisModule) []GenesisModule {
+	merged := append([]GenesisModule{}, base...)
+	for _, override := range overrides {
+		found := false
+		for i := range merged {
+			if merged[i].Name == override.Name {
+				merged[i] = override
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, override)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,108 @@
+package template
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const modulesGenesisFile = `projects:
+  - name: "Service"
+    root: "base"
+    options:
+      - name: "name"
+        required: true
+      - name: "modules"
+        type: "list"
+        formField:
+          type: "IMAGE_BUTTON_GROUP"
+          imageButtons:
+            - type: "DEPENDENCY"
+              name: "postgres"
+            - type: "DEPENDENCY"
+              name: "kafka"
+    modules:
+      - name: "postgres"
+        root: "modules/postgres"
+        options:
+          - name: "dbName"
+            required: true
+        merge:
+          - path: "docker-compose.yml"
+            strategy: "merge"
+          - path: "*.md"
+            strategy: "append"
+      - name: "kafka"
+        root: "modules/kafka"
+        onConflict: "error"
+`
+
+func TestGenesisTemplateApi_Modules(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		".genesis.yml":                           modulesGenesisFile,
+		"base/README.md":                         "# {{name}}",
+		"base/docker-compose.yml":                "version: \"3\"\nservices:\n  app:\n    image: {{name}}\n",
+		"base/main.go":                           "package main",
+		"modules/postgres/README.md":             "Uses the {{dbName}} database",
+		"modules/postgres/docker-compose.yml":    "services:\n  db:\n    image: postgres\n",
+		"modules/postgres/{{dbName}}/schema.sql": "CREATE DATABASE {{dbName}};",
+		"modules/kafka/main.go":                  "package kafka",
+	})
+	defer os.RemoveAll(dir)
+
+	api := NewGenesisTemplateApi(dir)
+	project, err := api.GetProjectFromRepo("Service")
+	assert.Nil(t, err)
+
+	err = api.GenerateFromTemplate(project, OptionValues{"name": "svc", "modules": "postgres"})
+	assert.NotNil(t, err, "there should be an error because dbName is required by the selected module")
+
+	err = api.GenerateFromTemplate(project, OptionValues{"name": "svc", "modules": "postgres", "dbName": "orders"})
+	assert.Nil(t, err)
+	assert.Equal(t, "# svc\nUses the orders database", readFile(t, dir+"base/README.md"))
+	assert.Equal(t, "version: \"3\"\nservices:\n  app:\n    image: svc\n  db:\n    image: postgres\n", readFile(t, dir+"base/docker-compose.yml"))
+	assert.Equal(t, "CREATE DATABASE orders;", readFile(t, dir+"base/orders/schema.sql"))
+	assert.Equal(t, "package main", readFile(t, dir+"base/main.go"))
+}
+
+func TestGenesisTemplateApi_ModulesConflict(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		".genesis.yml":          modulesGenesisFile,
+		"base/main.go":          "package main",
+		"modules/kafka/main.go": "package kafka",
+	})
+	defer os.RemoveAll(dir)
+
+	api := NewGenesisTemplateApi(dir)
+	project, err := api.GetProjectFromRepo("Service")
+	assert.Nil(t, err)
+
+	err = api.GenerateFromTemplate(project, OptionValues{"name": "svc", "modules": "kafka"})
+	assert.NotNil(t, err, "there should be an error because the kafka module does not allow conflicts")
+}
+
+func TestGenesisTemplate_ModuleOptionsHidden(t *testing.T) {
+	dir := writeTree(t, map[string]string{".genesis.yml": modulesGenesisFile})
+	defer os.RemoveAll(dir)
+
+	project, err := NewGenesisTemplateApi(dir).GetProjectFromRepo("Service")
+	assert.Nil(t, err)
+
+	err = project.SetValidatedOptions(OptionValues{"name": "svc", "dbName": "ignored"})
+	assert.Nil(t, err, "module options are not required while the module is not selected")
+	options, _ := project.GetValidatedOptions()
+	assert.Nil(t, options["dbName"])
+	assert.Equal(t, 0, len(project.GetLayers()))
+}
+
+func TestMergeStructuredFile(t *testing.T) {
+	merged, err := mergeStructuredFile("package.json",
+		[]byte(`{"name": "svc", "scripts": {"start": "node ."}, "keywords": ["api"]}`),
+		[]byte(`{"scripts": {"db": "knex"}, "keywords": ["api", "postgres"]}`))
+	assert.Nil(t, err)
+	assert.Equal(t, "{\n  \"name\": \"svc\",\n  \"scripts\": {\n    \"start\": \"node .\",\n    \"db\": \"knex\"\n  },\n  \"keywords\": [\n    \"api\",\n    \"postgres\"\n  ]\n}\n", string(merged))
+
+	_, err = mergeStructuredFile("main.go", []byte("package main"), []byte("package main"))
+	assert.NotNil(t, err, "there should be an error because only YAML and JSON files can be merged")
+}
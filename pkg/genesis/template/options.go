@@ -0,0 +1,295 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// approximate an Enum
+type OptionType string
+
+const (
+	STRING_TYPE OptionType = "string"
+	BOOL_TYPE   OptionType = "bool"
+	INT_TYPE    OptionType = "int"
+	LIST_TYPE   OptionType = "list"
+	MAP_TYPE    OptionType = "map"
+)
+
+// OptionValues holds typed option values keyed by option name. Values are one of
+// string, bool, int, []interface{} or map[string]interface{}.
+type OptionValues map[string]interface{}
+
+// StringOptionValues converts untyped key-value pairs, such as those passed with --options,
+// into OptionValues. The values are parsed into their declared types during validation.
+func StringOptionValues(args map[string]string) OptionValues {
+	values := make(OptionValues, len(args))
+	for key, value := range args {
+		values[key] = value
+	}
+	return values
+}
+
+// NormalizeOptionValues converts values decoded from YAML or JSON into the representation
+// used by OptionValues, e.g. map[interface{}]interface{} becomes map[string]interface{}.
+func NormalizeOptionValues(args map[string]interface{}) OptionValues {
+	values := make(OptionValues, len(args))
+	for key, value := range args {
+		values[key] = normalizeValue(value)
+	}
+	return values
+}
+
+func normalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		normalized := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			normalized[fmt.Sprint(key)] = normalizeValue(item)
+		}
+		return normalized
+	case map[string]interface{}:
+		normalized := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			normalized[key] = normalizeValue(item)
+		}
+		return normalized
+	case []interface{}:
+		normalized := make([]interface{}, len(v))
+		for i, item := range v {
+			normalized[i] = normalizeValue(item)
+		}
+		return normalized
+	case []string:
+		normalized := make([]interface{}, len(v))
+		for i, item := range v {
+			normalized[i] = item
+		}
+		return normalized
+	default:
+		return v
+	}
+}
+
+// GetType returns the declared type of the option. When no type is declared, it is inferred
+// from the form field so that checkboxes are booleans and number fields are integers.
+func (o Option) GetType() OptionType {
+	if o.Type != "" {
+		return OptionType(strings.ToLower(string(o.Type)))
+	}
+	switch o.FormField.Type {
+	case CHECKBOX:
+		return BOOL_TYPE
+	case NUMBER:
+		return INT_TYPE
+	default:
+		return STRING_TYPE
+	}
+}
+
+// ParseValue converts a raw value into the type declared by the option. Raw values may come
+// from the command line (strings), YAML settings files or JSON payloads.
+func (o Option) ParseValue(raw interface{}) (interface{}, error) {
+	value, err := ParseOptionValue(o.GetType(), normalizeValue(raw))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid value for option %s", o.Name)
+	}
+	return value, nil
+}
+
+// ParseOptionValue converts a raw value into the given OptionType.
+func ParseOptionValue(optionType OptionType, raw interface{}) (interface{}, error) {
+	switch optionType {
+	case STRING_TYPE:
+		return parseString(raw)
+	case BOOL_TYPE:
+		return parseBool(raw)
+	case INT_TYPE:
+		return parseInt(raw)
+	case LIST_TYPE:
+		return parseList(raw)
+	case MAP_TYPE:
+		return parseMap(raw)
+	default:
+		return nil, errors.Errorf("unknown option type %s", optionType)
+	}
+}
+
+func parseString(raw interface{}) (interface{}, error) {
+	switch v := raw.(type) {
+	case []interface{}, map[string]interface{}:
+		return nil, errors.Errorf("expected a string but got %s", FormatValue(v))
+	default:
+		return FormatValue(v), nil
+	}
+}
+
+func parseBool(raw interface{}) (interface{}, error) {
+	switch v := raw.(type) {
+	case bool:
+		return v, nil
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "true", "yes", "on", "1":
+			return true, nil
+		case "false", "no", "off", "0", "":
+			return false, nil
+		}
+		return nil, errors.Errorf("expected a boolean but got %q", v)
+	default:
+		return nil, errors.Errorf("expected a boolean but got %v", v)
+	}
+}
+
+func parseInt(raw interface{}) (interface{}, error) {
+	switch v := raw.(type) {
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case uint64:
+		return int(v), nil
+	case float64:
+		if v != float64(int(v)) {
+			return nil, errors.Errorf("expected an integer but got %v", v)
+		}
+		return int(v), nil
+	case json.Number:
+		i, err := strconv.Atoi(v.String())
+		if err != nil {
+			return nil, errors.Errorf("expected an integer but got %s", v)
+		}
+		return i, nil
+	case string:
+		i, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return nil, errors.Errorf("expected an integer but got %q", v)
+		}
+		return i, nil
+	default:
+		return nil, errors.Errorf("expected an integer but got %v", v)
+	}
+}
+
+// lists given as strings are either a JSON array or comma separated values
+func parseList(raw interface{}) (interface{}, error) {
+	switch v := raw.(type) {
+	case []interface{}:
+		return v, nil
+	case string:
+		trimmed := strings.TrimSpace(v)
+		if trimmed == "" {
+			return []interface{}{}, nil
+		}
+		if strings.HasPrefix(trimmed, "[") {
+			var list []interface{}
+			if err := json.Unmarshal([]byte(trimmed), &list); err != nil {
+				return nil, errors.Wrapf(err, "unable to parse list %s", trimmed)
+			}
+			return normalizeValue(list), nil
+		}
+		split := strings.Split(trimmed, ",")
+		list := make([]interface{}, len(split))
+		for i, item := range split {
+			list[i] = strings.TrimSpace(item)
+		}
+		return list, nil
+	default:
+		return nil, errors.Errorf("expected a list but got %v", v)
+	}
+}
+
+// maps given as strings are either a JSON object or comma separated key:value pairs
+func parseMap(raw interface{}) (interface{}, error) {
+	switch v := raw.(type) {
+	case map[string]interface{}:
+		return v, nil
+	case string:
+		trimmed := strings.TrimSpace(v)
+		result := make(map[string]interface{})
+		if trimmed == "" {
+			return result, nil
+		}
+		if strings.HasPrefix(trimmed, "{") {
+			if err := json.Unmarshal([]byte(trimmed), &result); err != nil {
+				return nil, errors.Wrapf(err, "unable to parse map %s", trimmed)
+			}
+			return normalizeValue(result), nil
+		}
+		for _, pair := range strings.Split(trimmed, ",") {
+			split := strings.SplitN(pair, ":", 2)
+			if len(split) != 2 {
+				return nil, errors.Errorf("expected key:value but got %q", pair)
+			}
+			result[strings.TrimSpace(split[0])] = strings.TrimSpace(split[1])
+		}
+		return result, nil
+	default:
+		return nil, errors.Errorf("expected a map but got %v", v)
+	}
+}
+
+// FormatValue renders a typed option value as it should appear in a generated file.
+// Lists are comma separated and maps are rendered as JSON.
+func FormatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = FormatValue(item)
+		}
+		return strings.Join(items, ",")
+	case map[string]interface{}:
+		out, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(out)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// IsTruthy reports whether a value counts as true in a condition. Empty strings, false,
+// zero, and empty lists or maps are false.
+func IsTruthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case int:
+		return v != 0
+	case float64:
+		return v != 0
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	default:
+		return true
+	}
+}
+
+// sortedKeys returns the keys of a map in a stable order for deterministic rendering
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,98 @@
+package template
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestOption_ParseValue(t *testing.T) {
+	checkbox := Option{Name: "enabled", FormField: FormField{Type: CHECKBOX}}
+	value, err := checkbox.ParseValue("true")
+	assert.Nil(t, err)
+	assert.Equal(t, true, value, "checkbox values should be parsed as booleans")
+
+	port := Option{Name: "port", Type: INT_TYPE}
+	value, err = port.ParseValue(float64(8080))
+	assert.Nil(t, err)
+	assert.Equal(t, 8080, value, "JSON numbers should be parsed as integers")
+	_, err = port.ParseValue("eighty")
+	assert.NotNil(t, err, "there should be an error because the value is not an integer")
+
+	tags := Option{Name: "tags", Type: LIST_TYPE}
+	value, err = tags.ParseValue("a, b,c")
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"a", "b", "c"}, value)
+	value, err = tags.ParseValue(`["a,b", "c"]`)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"a,b", "c"}, value, "JSON lists may contain commas")
+
+	labels := Option{Name: "labels", Type: MAP_TYPE}
+	var raw interface{}
+	err = yaml.Unmarshal([]byte("team: platform\ntier: 1"), &raw)
+	assert.Nil(t, err)
+	value, err = labels.ParseValue(raw)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"team": "platform", "tier": 1}, value, "YAML maps should be normalized")
+}
+
+func TestGenesisTemplate_SetValidatedOptions_Typed(t *testing.T) {
+	p := GenesisTemplate{
+		Options: []Option{
+			{Name: "name", Required: true},
+			{Name: "docker", FormField: FormField{Type: CHECKBOX}, Default: false},
+			{Name: "replicas", Type: INT_TYPE, Default: 2},
+		},
+	}
+
+	err := p.SetValidatedOptions(OptionValues{"name": "svc", "docker": "true"})
+	assert.Nil(t, err)
+
+	validated, _ := p.GetValidatedOptions()
+	assert.Equal(t, "svc", validated["name"])
+	assert.Equal(t, true, validated["docker"])
+	assert.Equal(t, 2, validated["replicas"], "typed defaults should be applied")
+}
+
+func TestRenderDocument_Blocks(t *testing.T) {
+	options := OptionValues{
+		"name":   "svc",
+		"docker": true,
+		"helm":   false,
+		"ports":  []interface{}{8080, 9090},
+		"labels": map[string]interface{}{"team": "platform", "tier": "1"},
+	}
+	input := `# {{name}}
+{{#if docker}}
+FROM scratch
+{{/if}}
+{{#if helm}}
+helm: true
+{{else}}
+helm: false
+{{/if}}
+{{#each ports}}
+EXPOSE {{this}}
+{{/each}}
+{{#each labels}}
+{{@key}}={{this | upper}}
+{{/each}}
+{{#unless helm}}no chart{{/unless}}
+`
+	expected := `# svc
+FROM scratch
+helm: false
+EXPOSE 8080
+EXPOSE 9090
+team=PLATFORM
+tier=1
+no chart
+`
+	output, err := RenderDocument([]byte(input), options)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, string(output))
+
+	_, err = RenderDocument([]byte("{{#if docker}}never closed"), options)
+	assert.NotNil(t, err, "there should be an error because the block is not closed")
+}
@@ -0,0 +1,352 @@
+package template
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// genesisOptionsScheme selects a built-in options source, e.g. genesis://repos?project=KEY
+	genesisOptionsScheme    = "genesis"
+	defaultOptionsCacheTime = 5 * time.Minute
+	maxOptionsResponseSize  = 4 * 1024 * 1024
+	// maxOptionsCacheEntries bounds the cache, as urls rendered with option values are unbounded
+	maxOptionsCacheEntries = 1000
+)
+
+// OptionsMapping selects the options from the JSON returned by an OptionsUrl. Items is the path to
+// the list of options, and the other paths are relative to each item. Paths use a subset of JSONPath:
+// $ is the current value, .name selects a field, [2] an element and [*] every element of a list,
+// e.g. items: $.values[*] and value: $.slug. A path left empty selects the item itself.
+type OptionsMapping struct {
+	Items        string `yaml:"items,omitempty" json:"items,omitempty"`
+	Value        string `yaml:"value,omitempty" json:"value,omitempty"`
+	DisplayValue string `yaml:"displayValue,omitempty" json:"displayValue,omitempty"`
+	IconUrl      string `yaml:"iconUrl,omitempty" json:"iconUrl,omitempty"`
+}
+
+// OptionsResolver resolves the OptionsUrl of a form field into the options the field accepts.
+// The url has been rendered with the option values when they are known.
+type OptionsResolver interface {
+	ResolveOptions(optionsUrl string, mapping OptionsMapping) ([]SelectOption, error)
+}
+
+// OptionsSource is a built-in source of options, called with the query of a genesis:// url
+type OptionsSource func(query url.Values) ([]SelectOption, error)
+
+// OptionsUrlResolver resolves http(s) OptionsUrls returning JSON, and genesis://<source> urls served
+// by the registered sources. Results are cached for CacheTime, and at most maxOptionsCacheEntries are kept.
+type OptionsUrlResolver struct {
+	Client    *http.Client
+	Sources   map[string]OptionsSource
+	CacheTime time.Duration
+
+	mutex sync.Mutex
+	cache map[string]cachedOptions
+}
+
+type cachedOptions struct {
+	options []SelectOption
+	expires time.Time
+}
+
+func NewOptionsUrlResolver(client *http.Client, cacheTime time.Duration) *OptionsUrlResolver {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if cacheTime <= 0 {
+		cacheTime = defaultOptionsCacheTime
+	}
+	return &OptionsUrlResolver{
+		Client:    client,
+		Sources:   make(map[string]OptionsSource),
+		CacheTime: cacheTime,
+		cache:     make(map[string]cachedOptions),
+	}
+}
+
+// RegisterSource makes a built-in source available as genesis://<name>
+func (r *OptionsUrlResolver) RegisterSource(name string, source OptionsSource) {
+	r.Sources[name] = source
+}
+
+func (r *OptionsUrlResolver) ResolveOptions(optionsUrl string, mapping OptionsMapping) ([]SelectOption, error) {
+	key := optionsUrl + "\x00" + mapping.Items + "\x00" + mapping.Value + "\x00" + mapping.DisplayValue + "\x00" + mapping.IconUrl
+	r.mutex.Lock()
+	cached, ok := r.cache[key]
+	r.mutex.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.options, nil
+	}
+
+	parsed, err := url.Parse(optionsUrl)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid optionsUrl %s", optionsUrl)
+	}
+	var options []SelectOption
+	switch parsed.Scheme {
+	case genesisOptionsScheme:
+		source, ok := r.Sources[parsed.Host]
+		if !ok {
+			return nil, errors.Errorf("unknown options source %s", parsed.Host)
+		}
+		options, err = source(parsed.Query())
+	case "http", "https":
+		options, err = r.fetchOptions(optionsUrl, mapping)
+	default:
+		return nil, errors.Errorf("optionsUrl %s must be an http, https or genesis url", optionsUrl)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	r.storeOptions(key, options)
+	r.mutex.Unlock()
+	return options, nil
+}
+
+// storeOptions caches options under key, evicting expired entries first, then the entries that expire soonest
+// while the cache is full. The caller holds the mutex.
+func (r *OptionsUrlResolver) storeOptions(key string, options []SelectOption) {
+	if r.cache == nil {
+		r.cache = make(map[string]cachedOptions)
+	}
+	now := time.Now()
+	for cachedKey, cached := range r.cache {
+		if !now.Before(cached.expires) {
+			delete(r.cache, cachedKey)
+		}
+	}
+	for len(r.cache) >= maxOptionsCacheEntries {
+		var oldestKey string
+		var oldest time.Time
+		for cachedKey, cached := range r.cache {
+			if oldestKey == "" || cached.expires.Before(oldest) {
+				oldestKey, oldest = cachedKey, cached.expires
+			}
+		}
+		delete(r.cache, oldestKey)
+	}
+	r.cache[key] = cachedOptions{options: options, expires: now.Add(r.CacheTime)}
+}
+
+func (r *OptionsUrlResolver) fetchOptions(optionsUrl string, mapping OptionsMapping) ([]SelectOption, error) {
+	request, err := http.NewRequest("GET", optionsUrl, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid optionsUrl %s", optionsUrl)
+	}
+	request.Header.Set("Accept", "application/json")
+	response, err := r.Client.Do(request)
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem requesting options from %s", optionsUrl)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("problem requesting options from %s: status %d", optionsUrl, response.StatusCode)
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(response.Body, maxOptionsResponseSize))
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem reading options from %s", optionsUrl)
+	}
+	var document interface{}
+	err = json.Unmarshal(body, &document)
+	if err != nil {
+		return nil, errors.Wrapf(err, "options from %s are not valid JSON", optionsUrl)
+	}
+	return MapOptions(document, mapping)
+}
+
+// MapOptions selects the options from a JSON document with the mapping
+func MapOptions(document interface{}, mapping OptionsMapping) ([]SelectOption, error) {
+	items, err := evaluateJSONPath(document, mapping.Items)
+	if err != nil {
+		return nil, err
+	}
+	// a path to a list selects its elements
+	if len(items) == 1 {
+		if list, ok := items[0].([]interface{}); ok {
+			items = list
+		}
+	}
+
+	options := make([]SelectOption, 0, len(items))
+	for _, item := range items {
+		value, err := mapOptionField(item, mapping.Value)
+		if err != nil {
+			return nil, err
+		}
+		displayValue := value
+		if mapping.DisplayValue != "" {
+			if displayValue, err = mapOptionField(item, mapping.DisplayValue); err != nil {
+				return nil, err
+			}
+		}
+		var iconUrl string
+		if mapping.IconUrl != "" {
+			if iconUrl, err = mapOptionField(item, mapping.IconUrl); err != nil {
+				return nil, err
+			}
+		}
+		options = append(options, SelectOption{Value: value, DisplayValue: displayValue, IconUrl: iconUrl})
+	}
+	return options, nil
+}
+
+func mapOptionField(item interface{}, path string) (string, error) {
+	values, err := evaluateJSONPath(item, path)
+	if err != nil {
+		return "", err
+	}
+	if len(values) != 1 {
+		return "", errors.Errorf("path %s must select a single value", path)
+	}
+	switch value := values[0].(type) {
+	case nil:
+		return "", nil
+	case []interface{}, map[string]interface{}:
+		return "", errors.Errorf("path %s must select a string, number or boolean", path)
+	default:
+		return FormatValue(value), nil
+	}
+}
+
+// evaluateJSONPath returns the values selected by a path such as $.values[*].name. Fields that do
+// not exist select nothing.
+func evaluateJSONPath(document interface{}, path string) ([]interface{}, error) {
+	path = strings.TrimPrefix(strings.TrimSpace(path), "$")
+	current := []interface{}{document}
+	for path != "" {
+		var next []interface{}
+		switch {
+		case strings.HasPrefix(path, "."):
+			end := strings.IndexAny(path[1:], ".[")
+			if end == -1 {
+				end = len(path) - 1
+			}
+			name := path[1 : end+1]
+			if name == "" {
+				return nil, errors.Errorf("invalid path %s: empty field name", path)
+			}
+			for _, value := range current {
+				if object, ok := value.(map[string]interface{}); ok {
+					if field, ok := object[name]; ok {
+						next = append(next, field)
+					}
+				}
+			}
+			path = path[end+1:]
+		case strings.HasPrefix(path, "["):
+			end := strings.Index(path, "]")
+			if end == -1 {
+				return nil, errors.Errorf("invalid path %s: unclosed [", path)
+			}
+			index := path[1:end]
+			for _, value := range current {
+				list, ok := value.([]interface{})
+				if !ok {
+					continue
+				}
+				if index == "*" {
+					next = append(next, list...)
+					continue
+				}
+				i, err := strconv.Atoi(index)
+				if err != nil {
+					return nil, errors.Errorf("invalid path %s: index %s is not a number", path, index)
+				}
+				if i >= 0 && i < len(list) {
+					next = append(next, list[i])
+				}
+			}
+			path = path[end+1:]
+		default:
+			return nil, errors.Errorf("invalid path %s: expected . or [", path)
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// resolveFieldOptions returns the options of a form field, resolving its OptionsUrl when there is
+// a resolver. ok is false when the options are not known, and values cannot be checked.
+func resolveFieldOptions(field FormField, resolver OptionsResolver, values OptionValues) (options []SelectOption, ok bool, err error) {
+	if field.OptionsUrl == "" {
+		return field.SelectOptions, len(field.SelectOptions) > 0, nil
+	}
+	if resolver == nil {
+		return nil, false, nil
+	}
+	optionsUrl := field.OptionsUrl
+	if values != nil {
+		optionsUrl, err = replaceTokens(optionsUrl, values, escapeUrlValue)
+		if err != nil {
+			return nil, false, err
+		}
+	} else if strings.Contains(optionsUrl, "{{") {
+		// the url depends on values that are not known yet
+		return nil, false, nil
+	}
+	options, err = resolver.ResolveOptions(optionsUrl, field.OptionsMapping)
+	if err != nil {
+		return nil, false, err
+	}
+	return append(append([]SelectOption{}, field.SelectOptions...), options...), true, nil
+}
+
+// escapeUrlValue escapes a value substituted in an optionsUrl, as a query value after the ? and as a path segment
+// before it, so that a value cannot change the host, the path or the other parameters of the url
+func escapeUrlValue(renderedUrl, value string) string {
+	if strings.Contains(renderedUrl, "?") {
+		return url.QueryEscape(value)
+	}
+	return url.PathEscape(value)
+}
+
+// checkSelectedValue makes sure that the values of SELECT and AUTOCOMPLETE fields are among their options, when
+// the options come from an OptionsUrl or the field restricts values to its static options
+func (o Option) checkSelectedValue(value interface{}, resolver OptionsResolver, values OptionValues) error {
+	if o.FormField.Type != SELECT && o.FormField.Type != AUTOCOMPLETE {
+		return nil
+	}
+	if o.FormField.OptionsUrl == "" && !o.FormField.RestrictToOptions {
+		return nil
+	}
+	options, ok, err := resolveFieldOptions(o.FormField, resolver, values)
+	if err != nil {
+		return errors.Wrapf(err, "unable to resolve the options of %s", o.Name)
+	}
+	if !ok {
+		return nil
+	}
+	allowed := make(map[string]bool, len(options))
+	for _, option := range options {
+		allowed[option.Value] = true
+	}
+
+	selected := []interface{}{value}
+	if list, isList := value.([]interface{}); isList {
+		selected = list
+	}
+	for _, item := range selected {
+		if !allowed[FormatValue(item)] {
+			names := make([]string, 0, len(allowed))
+			for name := range allowed {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return errors.Errorf("Invalid request. %s is not a valid value for %s. Valid values are: %s", FormatValue(item), o.Name, strings.Join(names, ", "))
+		}
+	}
+	return nil
+}
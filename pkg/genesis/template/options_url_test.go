@@ -0,0 +1,152 @@
+package template
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapOptions(t *testing.T) {
+	document := map[string]interface{}{
+		"values": []interface{}{
+			map[string]interface{}{"slug": "orders", "name": "Orders", "links": map[string]interface{}{"icon": []interface{}{"o.png"}}},
+			map[string]interface{}{"slug": "payments", "name": "Payments", "links": map[string]interface{}{"icon": []interface{}{"p.png"}}},
+		},
+	}
+
+	options, err := MapOptions(document, OptionsMapping{Items: "$.values[*]", Value: "$.slug", DisplayValue: "$.name", IconUrl: "$.links.icon[0]"})
+	assert.Nil(t, err)
+	assert.Equal(t, []SelectOption{
+		{Value: "orders", DisplayValue: "Orders", IconUrl: "o.png"},
+		{Value: "payments", DisplayValue: "Payments", IconUrl: "p.png"},
+	}, options)
+
+	options, err = MapOptions([]interface{}{"a", float64(2)}, OptionsMapping{})
+	assert.Nil(t, err)
+	assert.Equal(t, []SelectOption{{Value: "a", DisplayValue: "a"}, {Value: "2", DisplayValue: "2"}}, options)
+
+	_, err = MapOptions(document, OptionsMapping{Items: "$.values", Value: "$.links"})
+	assert.NotNil(t, err)
+	_, err = MapOptions(document, OptionsMapping{Items: "values"})
+	assert.NotNil(t, err)
+}
+
+func TestOptionsUrlResolver_ResolveOptions(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write([]byte(`{"regions": [{"id": "us-east-1"}, {"id": "eu-west-1"}]}`))
+	}))
+	defer server.Close()
+
+	resolver := NewOptionsUrlResolver(server.Client(), time.Minute)
+	resolver.RegisterSource("repos", func(query url.Values) ([]SelectOption, error) {
+		return []SelectOption{{Value: query.Get("project") + "-api"}}, nil
+	})
+	mapping := OptionsMapping{Items: "$.regions", Value: "$.id"}
+
+	options, err := resolver.ResolveOptions(server.URL+"/regions", mapping)
+	assert.Nil(t, err)
+	assert.Equal(t, []SelectOption{{Value: "us-east-1", DisplayValue: "us-east-1"}, {Value: "eu-west-1", DisplayValue: "eu-west-1"}}, options)
+	_, err = resolver.ResolveOptions(server.URL+"/regions", mapping)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, requests)
+
+	options, err = resolver.ResolveOptions("genesis://repos?project=ORD", OptionsMapping{})
+	assert.Nil(t, err)
+	assert.Equal(t, []SelectOption{{Value: "ORD-api"}}, options)
+
+	_, err = resolver.ResolveOptions("genesis://unknown", OptionsMapping{})
+	assert.NotNil(t, err)
+	_, err = resolver.ResolveOptions("file:///etc/passwd", OptionsMapping{})
+	assert.NotNil(t, err)
+}
+
+func TestGenesisTemplate_SelectValidation(t *testing.T) {
+	resolver := NewOptionsUrlResolver(nil, time.Minute)
+	resolver.RegisterSource("repos", func(query url.Values) ([]SelectOption, error) {
+		if query.Get("project") == "ORD" {
+			return []SelectOption{{Value: "orders-api"}, {Value: "orders-ui"}}, nil
+		}
+		return []SelectOption{{Value: "other"}}, nil
+	})
+	project := GenesisTemplate{
+		Name: "Service",
+		Options: []Option{
+			{Name: "project", GroupName: "Main", FormField: FormField{Type: TEXT, Label: "Project"}},
+			{Name: "size", GroupName: "Main", FormField: FormField{Type: SELECT, RestrictToOptions: true, SelectOptions: []SelectOption{{Value: "small"}, {Value: "large"}}}},
+			{Name: "upstream", GroupName: "Main", FormField: FormField{Type: AUTOCOMPLETE, OptionsUrl: "genesis://repos?project={{project}}"}},
+			{Name: "tags", Type: LIST_TYPE, FormField: FormField{Type: SELECT, RestrictToOptions: true, SelectOptions: []SelectOption{{Value: "a"}, {Value: "b"}}}},
+			{Name: "flavor", GroupName: "Main", FormField: FormField{Type: SELECT, SelectOptions: []SelectOption{{Value: "vanilla"}}}},
+		},
+		FormGroups:      []FormGroup{{DisplayName: "Main"}},
+		optionsResolver: resolver,
+	}
+
+	err := project.SetValidatedOptions(OptionValues{"project": "ORD", "size": "small", "upstream": "orders-ui", "tags": "a,b"})
+	assert.Nil(t, err)
+
+	err = project.SetValidatedOptions(OptionValues{"project": "ORD", "size": "medium"})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "medium is not a valid value for size. Valid values are: large, small")
+
+	err = project.SetValidatedOptions(OptionValues{"project": "PAY", "upstream": "orders-ui"})
+	assert.NotNil(t, err)
+
+	err = project.SetValidatedOptions(OptionValues{"project": "ORD", "tags": "a,c"})
+	assert.NotNil(t, err)
+
+	err = project.SetValidatedOptions(OptionValues{"project": "ORD", "flavor": "chocolate"})
+	assert.Nil(t, err, "static options only restrict values when the field opts in")
+
+	// options that depend on other values are left to the form
+	err = project.OrganizeGroups()
+	assert.Nil(t, err)
+	assert.Equal(t, "genesis://repos?project={{project}}", project.FormGroups[0].FormFields[2].OptionsUrl)
+
+	project.Options[2].FormField.OptionsUrl = "genesis://repos?project=ORD"
+	project.FormGroups = []FormGroup{{DisplayName: "Main"}}
+	err = project.OrganizeGroups()
+	assert.Nil(t, err)
+	assert.Equal(t, "", project.FormGroups[0].FormFields[2].OptionsUrl)
+	assert.Equal(t, []SelectOption{{Value: "orders-api"}, {Value: "orders-ui"}}, project.FormGroups[0].FormFields[2].SelectOptions)
+}
+
+func TestResolveFieldOptions_EscapesValues(t *testing.T) {
+	var resolved []string
+	resolver := NewOptionsUrlResolver(nil, time.Minute)
+	resolver.RegisterSource("branches", func(query url.Values) ([]SelectOption, error) {
+		resolved = append(resolved, query.Get("domain")+"|"+query.Get("repo"))
+		return []SelectOption{{Value: "master"}}, nil
+	})
+	field := FormField{Type: SELECT, OptionsUrl: "genesis://branches?domain={{project}}&repo=config"}
+
+	_, ok, err := resolveFieldOptions(field, resolver, OptionValues{"project": "ORD&repo=secrets#"})
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"ORD&repo=secrets#|config"}, resolved, "values should not add query parameters")
+
+	assert.Equal(t, "a%2Fb%3F", escapeUrlValue("https://example.com/", "a/b?"))
+	assert.Equal(t, "a%2Fb%3F+c", escapeUrlValue("https://example.com/?q=", "a/b? c"))
+}
+
+func TestOptionsUrlResolver_CacheIsBounded(t *testing.T) {
+	resolver := NewOptionsUrlResolver(nil, time.Minute)
+	resolver.RegisterSource("repos", func(query url.Values) ([]SelectOption, error) {
+		return []SelectOption{{Value: query.Get("project")}}, nil
+	})
+	resolver.cache["expired"] = cachedOptions{expires: time.Now().Add(-time.Second)}
+
+	for i := 0; i < maxOptionsCacheEntries+10; i++ {
+		_, err := resolver.ResolveOptions("genesis://repos?project="+strconv.Itoa(i), OptionsMapping{})
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, maxOptionsCacheEntries, len(resolver.cache))
+	_, ok := resolver.cache["expired"]
+	assert.False(t, ok, "expired entries should be evicted")
+}
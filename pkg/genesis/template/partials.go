@@ -0,0 +1,106 @@
+package template
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	partialsDirectoryName = "partials"
+	maxPartialDepth       = 10
+)
+
+// expandPartials replaces {{> name}} directives with the content of the partial named name in
+// one of the partial directories, before the document is rendered with the options.
+// A directive on a line of its own indents every line of the partial to match.
+func expandPartials(document []byte, partialDirectories []string, depth int) ([]byte, error) {
+	offset := 0
+	for {
+		next := bytes.Index(document[offset:], []byte("{{>"))
+		if next == -1 {
+			return document, nil
+		}
+		if depth >= maxPartialDepth {
+			return nil, errors.Errorf("partials are nested more than %d levels deep, check for partials that include themselves", maxPartialDepth)
+		}
+		start := offset + next
+		closeIdx := bytes.Index(document[start:], []byte("}}"))
+		if closeIdx == -1 {
+			return nil, errors.Errorf("Malformed input: found opening tags, but not closing tags.")
+		}
+		end := start + closeIdx + 2
+		name := strings.TrimSpace(string(document[start+3 : end-2]))
+
+		content, err := readPartial(name, partialDirectories)
+		if err != nil {
+			return nil, err
+		}
+		content, err = expandPartials(content, partialDirectories, depth+1)
+		if err != nil {
+			return nil, err
+		}
+
+		lineStart, lineEnd := expandStandalone(document, start, end)
+		if lineStart != start || lineEnd != end {
+			// standalone directive, indent the partial like the directive
+			content = indentPartial(content, document[lineStart:start])
+			start, end = lineStart, lineEnd
+		} else {
+			content = bytes.TrimSuffix(content, []byte("\n"))
+		}
+
+		output := make([]byte, 0, len(document)-(end-start)+len(content))
+		output = append(output, document[:start]...)
+		output = append(output, content...)
+		output = append(output, document[end:]...)
+		document = output
+		offset = start + len(content)
+	}
+}
+
+// readPartial finds a partial by name, with or without its file extension
+func readPartial(name string, partialDirectories []string) ([]byte, error) {
+	if name == "" || strings.Contains(name, "..") {
+		return nil, errors.Errorf("invalid partial name %q", name)
+	}
+	for _, directory := range partialDirectories {
+		path := filepath.Join(directory, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return ioutil.ReadFile(path)
+		}
+		matches, err := filepath.Glob(path + ".*")
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to search for partial %s", name)
+		}
+		if len(matches) > 1 {
+			return nil, errors.Errorf("partial %s is ambiguous, found %s", name, strings.Join(matches, ", "))
+		}
+		if len(matches) == 1 {
+			return ioutil.ReadFile(matches[0])
+		}
+	}
+	return nil, errors.Errorf("partial %s was not found in %s", name, strings.Join(partialDirectories, ", "))
+}
+
+func indentPartial(content, indent []byte) []byte {
+	if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+		content = append(copyBytes(content), '\n')
+	}
+	if len(indent) == 0 {
+		return content
+	}
+	lines := bytes.SplitAfter(content, []byte("\n"))
+	var output []byte
+	for _, line := range lines {
+		if len(bytes.TrimSpace(line)) > 0 {
+			output = append(output, indent...)
+		}
+		output = append(output, line...)
+	}
+	return output
+}
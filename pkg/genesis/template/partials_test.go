@@ -0,0 +1,47 @@
+package template
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenesisTemplateApi_Partials(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		".genesis.yml":              "projects:\n  - name: \"Test\"\n    root: \"base\"\n    options:\n      - name: \"name\"\n      - name: \"ports\"\n        type: \"list\"\n",
+		"partials/license-header":   "// Copyright {{name}}\n// Licensed under Apache 2.0\n",
+		"partials/ci-step.yml":      "- name: {{this}}\n  run: make {{this}}\n",
+		"partials/readme-footer.md": "{{> license-header}}",
+		"base/main.go":              "{{> license-header}}\npackage main\n",
+		"base/ci.yml":               "steps:\n{{#each ports}}\n  {{> ci-step}}\n{{/each}}\n",
+		"base/README.md":            "# {{name}} {{> readme-footer}}",
+	})
+	defer os.RemoveAll(dir)
+
+	api := NewGenesisTemplateApi(dir)
+	project, err := api.GetProjectFromRepo("Test")
+	assert.Nil(t, err)
+
+	err = api.GenerateFromTemplate(project, OptionValues{"name": "svc", "ports": "build,test"})
+	assert.Nil(t, err)
+	assert.Equal(t, "// Copyright svc\n// Licensed under Apache 2.0\npackage main\n", readFile(t, dir+"base/main.go"))
+	assert.Equal(t, "steps:\n  - name: build\n    run: make build\n  - name: test\n    run: make test\n", readFile(t, dir+"base/ci.yml"), "standalone partials should be indented and rendered in loops")
+	assert.Equal(t, "# svc // Copyright svc\n// Licensed under Apache 2.0", readFile(t, dir+"base/README.md"), "partials may include partials")
+}
+
+func TestExpandPartials_Errors(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"partials/loop": "{{> loop}}",
+	})
+	defer os.RemoveAll(dir)
+
+	_, err := expandPartials([]byte("{{> loop}}"), []string{dir + "partials"}, 0)
+	assert.NotNil(t, err, "there should be an error because the partial includes itself")
+
+	_, err = expandPartials([]byte("{{> missing}}"), []string{dir + "partials"}, 0)
+	assert.NotNil(t, err, "there should be an error because the partial does not exist")
+
+	_, err = expandPartials([]byte("{{> ../secret}}"), []string{dir + "partials"}, 0)
+	assert.NotNil(t, err, "there should be an error because the partial is outside of the partials directory")
+}
@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
@@ -31,11 +32,11 @@ type ProjectTemplateApi interface {
 
 	// GenerateFromTemplate creates a new project from the provided template, using the
 	// variableReplacementMap to customize the Project, as needed.
-	GenerateFromTemplate(project ProjectTemplate, variableReplacementMap map[string]string) error
+	GenerateFromTemplate(project ProjectTemplate, variableReplacementMap OptionValues) error
 
 	// ValidateGenesisProject goes out to gitRepositoryUrl and looks for a .yml file.
-	// If it exists, then the method returns true. If not, false.
-	// Also returns any errors encountered.
+	// If it exists and matches the genesis schema, then the method returns true. If not, false.
+	// Also returns any errors encountered, schema violations are returned as ValidationErrors.
 	ValidateGenesisProject() (bool, error)
 
 	// Cleanup deletes temporary files and folders
@@ -45,6 +46,12 @@ type ProjectTemplateApi interface {
 // Implement the ProjectTemplateApi
 type GenesisTemplateApi struct {
 	DirectoryPath string
+	// Resolver is optional, and allows templates to extend templates in other repositories
+	Resolver TemplateRepositoryResolver
+	// OptionsResolver is optional, and resolves the OptionsUrl of form fields for the form and validation
+	OptionsResolver OptionsResolver
+	// clonedDirectories are the clones of other repositories made through the Resolver, deleted by Cleanup
+	clonedDirectories []string
 }
 
 func NewGenesisTemplateApi(directoryPath string) *GenesisTemplateApi {
@@ -81,14 +88,90 @@ func (gTemplateApi *GenesisTemplateApi) GetProjectFromRepo(projectName string) (
 		names[i] = project.Name
 	}
 	msg := fmt.Sprintf("[name = %s]", names...)
+	var invalidDescriptors []string
+	for descriptor := range projects.invalidDescriptors {
+		invalidDescriptors = append(invalidDescriptors, descriptor)
+	}
+	sort.Strings(invalidDescriptors)
+	for _, descriptor := range invalidDescriptors {
+		msg += fmt.Sprintf(". %s could not be loaded: %v", descriptor, projects.invalidDescriptors[descriptor])
+	}
 	return nil, errors.Errorf("unable to find project template with name %s. Valid project names are: %s", projectName, msg)
 }
 
 func (gTemplateApi *GenesisTemplateApi) GetProjectsFromRepo() (GenesisProject, error) {
-	file, err := ioutil.ReadFile(gTemplateApi.DirectoryPath + genesisFileName)
+	projects, err := loadGenesisProject(gTemplateApi.DirectoryPath)
+
+	if err != nil {
+		return GenesisProject{}, err
+	}
+
+	err = gTemplateApi.resolveExtends(&projects)
+
+	if err != nil {
+		return GenesisProject{}, err
+	}
+
+	for i := range projects.Projects {
+		projects.Projects[i].optionsResolver = gTemplateApi.OptionsResolver
+	}
+
+	return projects, nil
+}
+
+// loadGenesisProject loads the templates of every .genesis.yml in a template repository. Templates
+// declared in a subdirectory are named <directory>/<name>, and their roots are relative to it. An invalid
+// .genesis.yml in a subdirectory is reported and skipped, so that it does not hide the other templates.
+func loadGenesisProject(directoryPath string) (GenesisProject, error) {
+	descriptors, err := FindGenesisFiles(directoryPath)
+	if err != nil {
+		return GenesisProject{}, err
+	}
+	if len(descriptors) == 0 {
+		return GenesisProject{}, errors.Errorf("Failed to read file with name %s in %s", genesisFileName, directoryPath)
+	}
+
+	var projects GenesisProject
+	for _, descriptor := range descriptors {
+		descriptorProjects, err := loadGenesisDescriptor(directoryPath, descriptor)
+		if err != nil && descriptorNamespace(descriptor) == "" {
+			return GenesisProject{}, err
+		}
+		if err != nil {
+			fmt.Printf("skipping the templates of %s. Err: %+v\n", descriptor, err)
+			if projects.invalidDescriptors == nil {
+				projects.invalidDescriptors = make(map[string]error)
+			}
+			projects.invalidDescriptors[descriptor] = err
+			continue
+		}
+		for _, project := range descriptorProjects.Projects {
+			if existing := projects.find(project.Name); existing != nil {
+				return GenesisProject{}, errors.Errorf("template %s is declared in both %s and %s", project.Name, existing.getDescriptor(), descriptor)
+			}
+			projects.Projects = append(projects.Projects, project)
+		}
+	}
+	return projects, nil
+}
+
+func loadGenesisDescriptor(directoryPath, descriptor string) (GenesisProject, error) {
+	file, err := ioutil.ReadFile(filepath.Join(directoryPath, descriptor))
 
 	if err != nil {
-		return GenesisProject{}, errors.Wrapf(err, "Failed to read file with name %s", genesisFileName)
+		return GenesisProject{}, errors.Wrapf(err, "Failed to read file with name %s", descriptor)
+	}
+
+	// reject unknown fields and invalid values instead of silently ignoring them
+	validationErrors, err := ValidateGenesisFile(file)
+	if err != nil {
+		return GenesisProject{}, errors.Wrapf(err, "problem loading %s", descriptor)
+	}
+	if len(validationErrors) > 0 {
+		for i := range validationErrors {
+			validationErrors[i].File = descriptor
+		}
+		return GenesisProject{}, validationErrors
 	}
 
 	var projects GenesisProject
@@ -98,70 +181,133 @@ func (gTemplateApi *GenesisTemplateApi) GetProjectsFromRepo() (GenesisProject, e
 		return GenesisProject{}, errors.Wrapf(err, "Failed to unmarshal genesis file file")
 	}
 
+	// partials and modules live next to the .genesis.yml file
+	namespace := descriptorNamespace(descriptor)
+	descriptorDirectory := filepath.Join(directoryPath, namespace)
+	for i := range projects.Projects {
+		project := &projects.Projects[i]
+		project.descriptor = descriptor
+		project.namespace = namespace
+		project.Name = joinNamespace(namespace, project.Name)
+		if project.Root != "" {
+			project.Root = joinNamespace(namespace, project.Root)
+		}
+		project.partialDirectories = []string{filepath.Join(descriptorDirectory, partialsDirectoryName)}
+		for j := range project.Modules {
+			project.Modules[j].directoryPath = descriptorDirectory
+		}
+	}
+
 	return projects, nil
 }
 
-func (gTemplateApi *GenesisTemplateApi) GenerateFromTemplate(project ProjectTemplate, variableReplacementMap map[string]string) error {
+func (gTemplateApi *GenesisTemplateApi) GenerateFromTemplate(project ProjectTemplate, variableReplacementMap OptionValues) error {
 	err := project.SetValidatedOptions(variableReplacementMap)
 	if err != nil {
 		return err
 	}
 
-	// get the project files
-	files, err := ioutil.ReadDir(gTemplateApi.DirectoryPath)
+	root, err := project.GetRoot()
 	if err != nil {
-		return errors.Wrapf(err, "Failed to read project files from directory.")
+		return err
 	}
 
-	root, err := project.GetRoot()
+	validatedOptions, err := project.GetValidatedOptions()
 	if err != nil {
 		return err
 	}
 
-	validatedOptions, err := project.GetValidatedOptions()
+	rootPath := filepath.Join(gTemplateApi.DirectoryPath, root)
+
+	layers := project.GetLayers()
+	if repository := project.GetGitRepository(); repository != (GenesisGitRepository{}) {
+		layer, err := gTemplateApi.includeLayer(repository)
+		if err != nil {
+			return err
+		}
+		layers = append(layers, layer)
+	}
+
+	// copy in base template and included files that the project does not override
+	for _, layer := range layers {
+		if layer.Overlay {
+			continue
+		}
+		err = applyLayer(rootPath, layer)
+		if err != nil {
+			return err
+		}
+	}
+
+	partialDirectories := project.GetPartialDirectories()
+	if len(partialDirectories) == 0 {
+		partialDirectories = []string{filepath.Join(gTemplateApi.DirectoryPath, partialsDirectoryName)}
+	}
+
+	if info, err := os.Stat(rootPath); err != nil || !info.IsDir() {
+		// nothing to render
+		return nil
+	}
+
+	err = renderDirectory(rootPath, validatedOptions, partialDirectories)
 	if err != nil {
 		return err
 	}
 
-	for _, file := range files {
-		if file.IsDir() && file.Name() == root {
-			directoryFuncs := make([]func() error, 0)
+	// place the selected modules on top of the rendered project
+	for _, layer := range layers {
+		if !layer.Overlay {
+			continue
+		}
+		err = applyOverlay(rootPath, layer, validatedOptions, partialDirectories)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-			err = filepath.Walk(gTemplateApi.DirectoryPath+file.Name(), func(path string, f os.FileInfo, err error) error {
-				if f.IsDir() { // directory
-					// create process closure with necessary parameters
-					directoryFuncs = append(directoryFuncs, processDirectoryClosure(path, f, validatedOptions))
-					return nil
-				} else { // file
-					err = processFileWithTokens(path, f, validatedOptions)
-					if err != nil {
-						return err
-					}
-				}
-				return nil
-			})
+// renderDirectory replaces the tokens in the files and directory names under a directory
+func renderDirectory(directory string, options OptionValues, partialDirectories []string) error {
+	directoryFuncs := make([]func() error, 0)
+
+	err := filepath.Walk(directory, func(path string, f os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if f.IsDir() { // directory
+			// create process closure with necessary parameters
+			directoryFuncs = append(directoryFuncs, processDirectoryClosure(path, f, options))
+			return nil
+		} else { // file
+			err = processFileWithTokens(path, f, options, partialDirectories)
 			if err != nil {
 				return err
 			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
 
-			// process directory variable changes after files are handled
-			for _, process := range directoryFuncs {
-				err = process()
-				if err != nil {
-					return err
-				}
-			}
+	// process directory variable changes after files are handled
+	for _, process := range directoryFuncs {
+		err = process()
+		if err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
-func processDirectoryClosure(path string, f os.FileInfo, options map[string]string) func() error {
+func processDirectoryClosure(path string, f os.FileInfo, options OptionValues) func() error {
 	return func() error {
 		oldName := f.Name()
 		if strings.Contains(oldName, "{{") && strings.Contains(oldName, "}}") {
 			// directory name should be replaced with one of the options passed in
-			for key, value := range options {
+			for key, optionValue := range options {
+				value := FormatValue(optionValue)
 				toFind := "{{" + key + "}}"
 				if toFind == oldName {
 					newPath := strings.Replace(path, oldName, value, 1)
@@ -242,7 +388,7 @@ func copyDirectoryContents(oldPath, newPath string) error {
 	return err
 }
 
-func FindTokens(document []byte, optionsMap map[string]string) ([]byte, error) {
+func FindTokens(document []byte, optionsMap OptionValues) ([]byte, error) {
 	start := bytes.Index([]byte("{{"), document)
 	end := bytes.Index([]byte("}}"), document)
 
@@ -253,7 +399,7 @@ func FindTokens(document []byte, optionsMap map[string]string) ([]byte, error) {
 		key := bytes.TrimSpace(split[0])
 		optionValue, ok := optionsMap[string(key)]
 		if ok { // key is present in optionsMap
-			replacement, err := ReplaceGenesisVariable(string(token), optionValue)
+			replacement, err := ReplaceGenesisVariable(string(token), FormatValue(optionValue))
 			if err != nil {
 				return nil, err
 			}
@@ -263,7 +409,7 @@ func FindTokens(document []byte, optionsMap map[string]string) ([]byte, error) {
 
 }
 
-func RecursiveReplace(document []byte, optionsMap map[string]string) ([]byte, error) {
+func RecursiveReplace(document []byte, optionsMap OptionValues) ([]byte, error) {
 	start := bytes.Index(document, []byte("{{"))
 	// base case - no opening tags
 	if start == -1 {
@@ -279,9 +425,9 @@ func RecursiveReplace(document []byte, optionsMap map[string]string) ([]byte, er
 	token := document[start : end+2]
 	split := bytes.Split(token, []byte("|"))
 	key := getTokenKey(split[0])
-	optionValue, ok := optionsMap[string(key)]
+	optionValue, ok := lookupValue(optionsMap, string(key))
 	if ok { // key is present in optionsMap
-		replacement, err := ReplaceGenesisVariable(string(token), optionValue)
+		replacement, err := ReplaceGenesisVariable(string(token), FormatValue(optionValue))
 		if err != nil {
 			return nil, err
 		}
@@ -292,7 +438,7 @@ func RecursiveReplace(document []byte, optionsMap map[string]string) ([]byte, er
 	// TODO - test this recursive method, and its cousin below
 }
 
-func StringRecursiveReplace(str string, optionsMap map[string]string) (string, error) {
+func StringRecursiveReplace(str string, optionsMap OptionValues) (string, error) {
 	inputBytes := []byte(str)
 	outputBytes, err := RecursiveReplace(inputBytes, optionsMap)
 	if err != nil {
@@ -302,6 +448,36 @@ func StringRecursiveReplace(str string, optionsMap map[string]string) (string, e
 	return outputString, nil
 }
 
+// replaceTokens replaces the option tokens of str, passing every value through escape along with the text rendered
+// before it. Unlike StringRecursiveReplace, values are not scanned for tokens again.
+func replaceTokens(str string, optionsMap OptionValues, escape func(rendered, value string) string) (string, error) {
+	var rendered strings.Builder
+	for {
+		start := strings.Index(str, "{{")
+		if start == -1 {
+			rendered.WriteString(str)
+			return rendered.String(), nil
+		}
+		end := strings.Index(str[start:], "}}")
+		if end == -1 {
+			return "", errors.Errorf("Malformed input: found opening tags, but not closing tags.")
+		}
+		token := str[start : start+end+2]
+		key := getTokenKeyString(strings.Split(token, "|")[0])
+		value, ok := lookupValue(optionsMap, key)
+		if !ok {
+			return "", errors.Errorf("Malformed input: unresolved handlebars left in document for key %s", key)
+		}
+		replacement, err := ReplaceGenesisVariable(token, FormatValue(value))
+		if err != nil {
+			return "", err
+		}
+		rendered.WriteString(str[:start])
+		rendered.WriteString(escape(rendered.String(), replacement))
+		str = str[start+end+2:]
+	}
+}
+
 func getTokenKey(token []byte) []byte {
 	stripped := bytes.ReplaceAll(token, []byte("{"), []byte(""))
 	stripped = bytes.ReplaceAll(stripped, []byte("}"), []byte(""))
@@ -318,7 +494,7 @@ func getTokenKeyString(token string) string {
 	return strings.TrimSpace(stripped)
 }
 
-func processFileWithTokens(path string, f os.FileInfo, options map[string]string) error {
+func processFileWithTokens(path string, f os.FileInfo, options OptionValues, partialDirectories []string) error {
 
 	readFile, err := ioutil.ReadFile(path)
 
@@ -332,14 +508,18 @@ func processFileWithTokens(path string, f os.FileInfo, options map[string]string
 		toFind := "{{" + key + "}}"
 		if strings.Contains(f.Name(), toFind) {
 			lastIndex := strings.LastIndex(path, toFind)
-			newPath := path[:lastIndex] + strings.Replace(path[lastIndex:], toFind, value, 1)
+			newPath := path[:lastIndex] + strings.Replace(path[lastIndex:], toFind, FormatValue(value), 1)
 			// set up file rename for later
 			fileRenameFunc = processFileRenameClosure(path, newPath)
 		}
 	}
 
-	// replace all variables with filtering
-	output, err = RecursiveReplace(output, options)
+	// include partials, then evaluate blocks and replace all variables with filtering
+	output, err = expandPartials(output, partialDirectories, 0)
+	if err != nil {
+		return errors.Wrapf(err, "unable to include partials in %s", path)
+	}
+	output, err = RenderDocument(output, options)
 	if err != nil {
 		return err
 	}
@@ -360,7 +540,7 @@ func processFileWithTokens(path string, f os.FileInfo, options map[string]string
 	return nil
 }
 
-func processFile(path string, f os.FileInfo, options map[string]string) error {
+func processFile(path string, f os.FileInfo, options OptionValues) error {
 
 	readFile, err := ioutil.ReadFile(path)
 
@@ -371,7 +551,8 @@ func processFile(path string, f os.FileInfo, options map[string]string) error {
 	output := readFile
 	updated := false
 	var fileRenameFunc func() error
-	for key, value := range options {
+	for key, optionValue := range options {
+		value := FormatValue(optionValue)
 		toFind := "{{" + key + "}}"
 		if bytes.Contains(output, []byte(toFind)) {
 			output = bytes.ReplaceAll(output, []byte(toFind), []byte(value))
@@ -402,11 +583,11 @@ func processFile(path string, f os.FileInfo, options map[string]string) error {
 	return nil
 }
 
-func (gTemplateApi *GenesisTemplateApi) Replace(validateOptions map[string]string) error {
+func (gTemplateApi *GenesisTemplateApi) Replace(validateOptions OptionValues) error {
 
 	// TODO - do something with these values
 	for key, value := range validateOptions {
-		newVal, err := ReplaceGenesisVariable(key, value)
+		newVal, err := ReplaceGenesisVariable(key, FormatValue(value))
 		if err != nil {
 			return err
 		}
@@ -416,22 +597,42 @@ func (gTemplateApi *GenesisTemplateApi) Replace(validateOptions map[string]strin
 }
 
 func (gTemplateApi *GenesisTemplateApi) ValidateGenesisProject() (bool, error) {
-	file, err := ioutil.ReadFile(gTemplateApi.DirectoryPath + genesisFileName)
+	descriptors, err := FindGenesisFiles(gTemplateApi.DirectoryPath)
 	if err != nil {
-		return false, errors.Wrapf(err, "problem reading genesis file during validation")
+		return false, err
 	}
-	var projects GenesisProject
-	err = yaml.Unmarshal(file, &projects)
-	if err != nil {
-		return false, errors.Wrapf(err, "problem unmarshalling file into a genesis template")
+	if len(descriptors) == 0 {
+		return false, errors.Errorf("problem reading genesis file during validation: no %s found", genesisFileName)
+	}
+	var allErrors ValidationErrors
+	for _, descriptor := range descriptors {
+		file, err := ioutil.ReadFile(filepath.Join(gTemplateApi.DirectoryPath, descriptor))
+		if err != nil {
+			return false, errors.Wrapf(err, "problem reading genesis file during validation")
+		}
+		validationErrors, err := ValidateGenesisFile(file)
+		if err != nil {
+			return false, errors.Wrapf(err, "problem validating %s", descriptor)
+		}
+		for _, validationError := range validationErrors {
+			validationError.File = descriptor
+			allErrors = append(allErrors, validationError)
+		}
+	}
+	if len(allErrors) > 0 {
+		return false, allErrors
 	}
 	return true, nil
 }
 
+// Cleanup deletes the template directory, and the clones of other repositories that templates extend or include
 func (gTemplateApi *GenesisTemplateApi) Cleanup() error {
-	err := os.RemoveAll(gTemplateApi.DirectoryPath)
-	if err != nil {
-		return errors.Wrapf(err, "problem deleting the temp directory %s", gTemplateApi.DirectoryPath)
+	for _, directoryPath := range append([]string{gTemplateApi.DirectoryPath}, gTemplateApi.clonedDirectories...) {
+		err := os.RemoveAll(directoryPath)
+		if err != nil {
+			return errors.Wrapf(err, "problem deleting the temp directory %s", directoryPath)
+		}
 	}
+	gTemplateApi.clonedDirectories = nil
 	return nil
 }
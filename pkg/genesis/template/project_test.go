@@ -25,7 +25,7 @@ func loadOptions(filename string) (options map[string]string, err error) {
 }
 
 func TestGenesisTemplateApi_ReplaceFilters(t *testing.T) {
-	optionsMap := make(map[string]string, 3)
+	optionsMap := make(OptionValues, 3)
 	// upper case filter
 	keyUpper := "{{mykey | upper}}"
 	// lower case filter
@@ -61,7 +61,7 @@ func TestGenesisTemplateApi_ReplaceFilters(t *testing.T) {
 
 func TestRecursiveReplace_NoErrors(t *testing.T) {
 	// setup
-	optionsMap := make(map[string]string, 3)
+	optionsMap := make(OptionValues, 3)
 	// title variable
 	keyTitle := "title"
 	// uppercase variable
@@ -94,7 +94,7 @@ func TestRecursiveReplace_NoErrors(t *testing.T) {
 
 func TestRecursiveReplace_BadInputError(t *testing.T) {
 	// setup
-	optionsMap := make(map[string]string, 3)
+	optionsMap := make(OptionValues, 3)
 	// title variable
 	keyTitle := "title"
 	// malformed variable
@@ -117,7 +117,7 @@ func TestRecursiveReplace_BadInputError(t *testing.T) {
 
 func TestStringRecursiveReplace_NoError(t *testing.T) {
 	// setup
-	optionsMap := make(map[string]string, 3)
+	optionsMap := make(OptionValues, 3)
 	// title variable
 	keyTitle := "title"
 	// uppercase variable
@@ -0,0 +1,282 @@
+package template
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// Prompter asks for the values of the options that were not provided, one line per option. Secret options
+// are read without echo when the input is a terminal.
+type Prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+	// readSecret reads a line without echoing it, nil when the input is not a terminal
+	readSecret func() (string, error)
+}
+
+func NewPrompter(in io.Reader, out io.Writer) *Prompter {
+	prompter := &Prompter{in: bufio.NewReader(in), out: out}
+	if file, ok := in.(*os.File); ok && terminal.IsTerminal(int(file.Fd())) {
+		prompter.readSecret = func() (string, error) {
+			secret, err := terminal.ReadPassword(int(file.Fd()))
+			// the new line is not echoed either
+			_, _ = fmt.Fprintln(out)
+			return string(secret), err
+		}
+	}
+	return prompter
+}
+
+// MissingOptions returns the names of the visible required options that are not in values
+func (p *GenesisTemplate) MissingOptions(values OptionValues) ([]string, error) {
+	scope, err := p.promptScope(values)
+	if err != nil {
+		return nil, err
+	}
+	var missing []string
+	for _, option := range p.allOptions() {
+		if val, ok := values[option.Name]; ok && val != "" {
+			continue
+		}
+		visible, err := option.IsVisible(scope)
+		if err != nil {
+			return nil, err
+		}
+		required, err := option.IsRequired(scope)
+		if err != nil {
+			return nil, err
+		}
+		if visible && required && scope[option.Name] == nil {
+			missing = append(missing, option.Name)
+		}
+	}
+	return missing, nil
+}
+
+// PromptOptions asks for every option of the template that is not in values, in form group order.
+// Options hidden by their showIf are skipped, an empty answer keeps the default, and invalid
+// answers are asked again. The answers are returned together with the given values.
+func (prompter *Prompter) PromptOptions(project *GenesisTemplate, values OptionValues) (OptionValues, error) {
+	scope, err := project.promptScope(values)
+	if err != nil {
+		return nil, err
+	}
+	answers := make(OptionValues, len(values))
+	for key, value := range values {
+		answers[key] = value
+	}
+
+	group := ""
+	for _, option := range project.promptOrder() {
+		if val, ok := values[option.Name]; (ok && val != "") || option.FormField.Type == SECTION {
+			continue
+		}
+		visible, err := option.IsVisible(scope)
+		if err != nil {
+			return nil, err
+		}
+		if !visible {
+			continue
+		}
+		if option.GroupName != group {
+			group = option.GroupName
+			_, _ = fmt.Fprintf(prompter.out, "\n%s\n", group)
+		}
+
+		value, err := prompter.promptOption(project, option, scope)
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			answers[option.Name] = value
+			scope[option.Name] = value
+		}
+	}
+	return answers, nil
+}
+
+// promptOption asks for the value of an option until it is valid. It returns nil to keep the default.
+func (prompter *Prompter) promptOption(project *GenesisTemplate, option Option, scope OptionValues) (interface{}, error) {
+	formField := option.FormField
+	label := formField.Label
+	if label == "" {
+		label = option.Name
+	}
+	choices, ok, err := resolveFieldOptions(formField, project.optionsResolver, scope)
+	if err != nil || !ok {
+		choices = formField.SelectOptions
+	}
+	if formField.Type != SELECT && formField.Type != AUTOCOMPLETE && formField.Type != SELECT_TEMPLATE {
+		choices = nil
+	}
+
+	if formField.Hint != "" {
+		_, _ = fmt.Fprintf(prompter.out, "  %s\n", formField.Hint)
+	}
+	for i, choice := range choices {
+		display := choice.DisplayValue
+		if display == "" || display == choice.Value {
+			display = choice.Value
+		} else {
+			display = fmt.Sprintf("%s (%s)", display, choice.Value)
+		}
+		_, _ = fmt.Fprintf(prompter.out, "  %d) %s\n", i+1, display)
+	}
+
+	question := label
+	switch {
+	case scope[option.Name] != nil && option.IsSecret():
+		question += " [********]"
+	case scope[option.Name] != nil:
+		question += fmt.Sprintf(" [%s]", FormatValue(scope[option.Name]))
+	case formField.Placeholder != "":
+		question += fmt.Sprintf(" (e.g. %s)", formField.Placeholder)
+	}
+	if option.GetType() == BOOL_TYPE {
+		question += " (y/n)"
+	}
+
+	for {
+		_, _ = fmt.Fprintf(prompter.out, "%s: ", question)
+		line, readErr := prompter.readLine(option)
+		if readErr != nil && readErr != io.EOF {
+			return nil, errors.Wrapf(readErr, "unable to read the value of %s", option.Name)
+		}
+		answer := strings.TrimSpace(line)
+
+		if answer == "" {
+			required, err := option.IsRequired(scope)
+			if err != nil {
+				return nil, err
+			}
+			if !required || scope[option.Name] != nil {
+				return nil, nil
+			}
+			if readErr == io.EOF {
+				return nil, errors.Errorf("%s is a required parameter and was not provided.", option.Name)
+			}
+			_, _ = fmt.Fprintf(prompter.out, "  %s is required\n", label)
+			continue
+		}
+
+		value, err := option.parseAnswer(answer, choices, scope, project.optionsResolver)
+		if err == nil {
+			return value, nil
+		}
+		if readErr == io.EOF {
+			return nil, err
+		}
+		_, _ = fmt.Fprintf(prompter.out, "  %s\n", err)
+	}
+}
+
+// readLine reads the answer to the prompt of an option, without echo for secret options on a terminal
+func (prompter *Prompter) readLine(option Option) (string, error) {
+	if option.IsSecret() && prompter.readSecret != nil {
+		return prompter.readSecret()
+	}
+	return prompter.in.ReadString('\n')
+}
+
+// parseAnswer parses and validates the answer to the prompt of an option. Select options can be
+// chosen by their number.
+func (o Option) parseAnswer(answer string, choices []SelectOption, scope OptionValues, resolver OptionsResolver) (interface{}, error) {
+	if len(choices) > 0 {
+		items := []string{answer}
+		if o.GetType() == LIST_TYPE {
+			items = strings.Split(answer, ",")
+		}
+		for i, item := range items {
+			item = strings.TrimSpace(item)
+			if n, err := strconv.Atoi(item); err == nil && n >= 1 && n <= len(choices) {
+				item = choices[n-1].Value
+			}
+			items[i] = item
+		}
+		answer = strings.Join(items, ",")
+	}
+	if o.GetType() == BOOL_TYPE {
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			answer = "true"
+		case "n", "no":
+			answer = "false"
+		}
+	}
+
+	value, err := o.ParseValue(answer)
+	if err != nil {
+		return nil, err
+	}
+	if o.FormField.Validation != "" {
+		pattern, err := regexp.Compile(o.FormField.Validation)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid validation of option %s", o.Name)
+		}
+		if !pattern.MatchString(answer) {
+			if o.FormField.ValidationErrorMessage != "" {
+				return nil, errors.New(o.FormField.ValidationErrorMessage)
+			}
+			return nil, errors.Errorf("%s must match %s", answer, o.FormField.Validation)
+		}
+	}
+	if o.FormField.MaxCharacters != "" {
+		if maxCharacters, err := strconv.Atoi(o.FormField.MaxCharacters); err == nil && len(answer) > maxCharacters {
+			return nil, errors.Errorf("%s must be at most %d characters", o.Name, maxCharacters)
+		}
+	}
+	if err := o.checkSelectedValue(value, resolver, scope); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// promptScope returns the parsed values and defaults of every option, for evaluating conditions
+func (p *GenesisTemplate) promptScope(values OptionValues) (OptionValues, error) {
+	options := p.allOptions()
+	scope := make(OptionValues, len(options))
+	for _, option := range options {
+		scope[option.Name] = nil
+		raw := option.Default
+		if val, ok := values[option.Name]; ok && val != "" {
+			raw = val
+		}
+		if raw == nil || raw == "" {
+			continue
+		}
+		parsed, err := option.ParseValue(raw)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid value of %s", option.Name)
+		}
+		scope[option.Name] = parsed
+	}
+	return scope, nil
+}
+
+// promptOrder returns the options in form group order, followed by the options without a form group
+func (p *GenesisTemplate) promptOrder() []Option {
+	groupIndex := make(map[string]int, len(p.FormGroups))
+	for i, group := range p.FormGroups {
+		groupIndex[strings.ToLower(group.DisplayName)] = i
+	}
+	indexOf := func(option Option) int {
+		if i, ok := groupIndex[strings.ToLower(option.GroupName)]; ok && option.GroupName != "" {
+			return i
+		}
+		return len(p.FormGroups)
+	}
+	options := append([]Option{}, p.allOptions()...)
+	sort.SliceStable(options, func(i, j int) bool {
+		return indexOf(options[i]) < indexOf(options[j])
+	})
+	return options
+}
@@ -0,0 +1,76 @@
+package template
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrompter_PromptOptions(t *testing.T) {
+	project := &GenesisTemplate{
+		Name: "Go Service",
+		Options: []Option{
+			{Name: "docker", Type: BOOL_TYPE, GroupName: "Build", FormField: FormField{Type: CHECKBOX, Label: "Docker"}},
+			{Name: "registry", GroupName: "Build", ShowIf: "docker", FormField: FormField{Type: TEXT, Label: "Registry"}},
+			{Name: "name", Required: true, GroupName: "Main", FormField: FormField{Type: TEXT, Label: "Name", Hint: "Lowercase letters", Placeholder: "orders", Validation: "^[a-z]+$"}},
+			{Name: "size", Default: "small", GroupName: "Main", FormField: FormField{Type: SELECT, Label: "Size", SelectOptions: []SelectOption{{Value: "small", DisplayValue: "Small"}, {Value: "large", DisplayValue: "Large"}}}},
+			{Name: "port", Type: INT_TYPE, Default: 8080, GroupName: "Main", FormField: FormField{Type: NUMBER, Label: "Port"}},
+		},
+		FormGroups: []FormGroup{{DisplayName: "Main"}, {DisplayName: "Build"}},
+	}
+
+	missing, err := project.MissingOptions(OptionValues{"port": "9090"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"name"}, missing)
+
+	// name is invalid, then empty, then valid; size is chosen by number; docker is no, so registry is skipped
+	in := strings.NewReader("Orders\n\norders\n2\nn\n")
+	out := &bytes.Buffer{}
+	answers, err := NewPrompter(in, out).PromptOptions(project, OptionValues{"port": "9090"})
+	assert.Nil(t, err)
+	assert.Equal(t, OptionValues{"port": "9090", "name": "orders", "size": "large", "docker": false}, answers)
+	assert.Equal(t, `
+Main
+  Lowercase letters
+Name (e.g. orders):   Orders must match ^[a-z]+$
+Name (e.g. orders):   Name is required
+Name (e.g. orders):   1) Small (small)
+  2) Large (large)
+Size [small]: 
+Build
+Docker (y/n): `, out.String())
+
+	// an empty answer keeps the default
+	answers, err = NewPrompter(strings.NewReader("orders\n\n\n"), &bytes.Buffer{}).PromptOptions(project, OptionValues{"docker": "true", "port": "9090"})
+	assert.Nil(t, err)
+	assert.Equal(t, OptionValues{"docker": "true", "port": "9090", "name": "orders"}, answers)
+
+	_, err = NewPrompter(strings.NewReader(""), &bytes.Buffer{}).PromptOptions(project, OptionValues{})
+	assert.NotNil(t, err)
+}
+
+func TestPrompter_PromptOptions_Secrets(t *testing.T) {
+	project := &GenesisTemplate{
+		Name: "Go Service",
+		Options: []Option{
+			{Name: "apiToken", Secret: true, Default: "default-token", FormField: FormField{Type: TEXT, Label: "Token"}},
+			{Name: "password", Required: true, FormField: FormField{Type: PASSWORD, Label: "Password"}},
+			{Name: "name", Required: true, FormField: FormField{Type: TEXT, Label: "Name"}},
+		},
+	}
+
+	secrets := []string{"", "s3cret"}
+	out := &bytes.Buffer{}
+	prompter := NewPrompter(strings.NewReader("orders\n"), out)
+	prompter.readSecret = func() (string, error) {
+		secret := secrets[0]
+		secrets = secrets[1:]
+		return secret, nil
+	}
+	answers, err := prompter.PromptOptions(project, OptionValues{})
+	assert.Nil(t, err)
+	assert.Equal(t, OptionValues{"password": "s3cret", "name": "orders"}, answers)
+	assert.Equal(t, "Token [********]: Password: Name: ", out.String(), "secret defaults should not be shown")
+}
@@ -0,0 +1,362 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+const genesisSchemaId = "https://github.com/att-cloudnative-labs/template-api/schema/genesis.schema.json"
+
+// JSONSchema is the subset of JSON Schema used to describe .genesis.yml files and template forms
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Id                   string                 `json:"$id,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	MaxLength            *int                   `json:"maxLength,omitempty"`
+	Default              interface{}            `json:"default,omitempty"`
+	Const                interface{}            `json:"const,omitempty"`
+	WriteOnly            bool                   `json:"writeOnly,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties interface{}            `json:"additionalProperties,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	UniqueItems          bool                   `json:"uniqueItems,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	OneOf                []*JSONSchema          `json:"oneOf,omitempty"`
+	AllOf                []*JSONSchema          `json:"allOf,omitempty"`
+	If                   *JSONSchema            `json:"if,omitempty"`
+	Then                 *JSONSchema            `json:"then,omitempty"`
+	Definitions          map[string]*JSONSchema `json:"definitions,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+}
+
+// schemaEnums lists the allowed values of the string types that approximate an Enum
+var schemaEnums = map[reflect.Type][]string{
+	reflect.TypeOf(OptionType("")):        {string(STRING_TYPE), string(BOOL_TYPE), string(INT_TYPE), string(LIST_TYPE), string(MAP_TYPE)},
+	reflect.TypeOf(ConflictStrategy("")):  {string(CONFLICT_OVERWRITE), string(CONFLICT_SKIP), string(CONFLICT_APPEND), string(CONFLICT_MERGE), string(CONFLICT_ERROR)},
+	reflect.TypeOf(FormFieldType("")):     {string(TEXT), string(NUMBER), string(EMAIL), string(CHECKBOX), string(SELECT), string(SELECT_TEMPLATE), string(AUTOCOMPLETE), string(TEXT_AREA), string(COLOR), string(DATE), string(DATETIME_LOCAL), string(MONTH), string(PASSWORD), string(SEARCH), string(TEL), string(TIME), string(URL), string(WEEK), string(SECTION), string(IMAGE_BUTTON), string(IMAGE_BUTTON_GROUP)},
+	reflect.TypeOf(DisplayOptionType("")): {string(LANGUAGE), string(FRAMEWORK), string(DEPLOYMENT_TYPE), string(CLOUD_PROVIDER), string(SOURCE_CONTROL), string(CI_SERVER), string(CD_SERVER), string(MODULE), string(DEPENDENCY), string(CONTAINER_MANAGEMENT)},
+}
+
+// schemaRequired lists the fields that must be set, by the yaml name of the field
+var schemaRequired = map[reflect.Type][]string{
+	reflect.TypeOf(GenesisProject{}):  {"projects"},
+	reflect.TypeOf(GenesisTemplate{}): {"name"},
+	reflect.TypeOf(Option{}):          {"name"},
+	reflect.TypeOf(GenesisModule{}):   {"name", "root"},
+	reflect.TypeOf(ValidationRule{}):  {"assert"},
+	reflect.TypeOf(Hook{}):            {"command"},
+	reflect.TypeOf(MergeRule{}):       {"path", "strategy"},
+}
+
+// GenesisProjectSchema returns the JSON Schema of .genesis.yml files. Objects do not allow
+// properties other than the fields of the matching type, so that typos are reported.
+func GenesisProjectSchema() *JSONSchema {
+	definitions := make(map[string]*JSONSchema)
+	schemaFor(reflect.TypeOf(GenesisProject{}), definitions)
+	schema := definitions["GenesisProject"]
+	delete(definitions, "GenesisProject")
+
+	return &JSONSchema{
+		Schema:               "http://json-schema.org/draft-07/schema#",
+		Id:                   genesisSchemaId,
+		Title:                "Genesis template descriptor (.genesis.yml)",
+		Type:                 schema.Type,
+		Properties:           schema.Properties,
+		Required:             schema.Required,
+		AdditionalProperties: schema.AdditionalProperties,
+		Definitions:          definitions,
+	}
+}
+
+// MarshalGenesisProjectSchema returns the published form of the schema
+func MarshalGenesisProjectSchema() ([]byte, error) {
+	content, err := json.MarshalIndent(GenesisProjectSchema(), "", "  ")
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to marshal the genesis schema")
+	}
+	return append(content, '\n'), nil
+}
+
+func schemaFor(t reflect.Type, definitions map[string]*JSONSchema) *JSONSchema {
+	if values, ok := schemaEnums[t]; ok {
+		return &JSONSchema{Type: "string", Enum: values}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &JSONSchema{Type: "array", Items: schemaFor(t.Elem(), definitions)}
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: schemaFor(t.Elem(), definitions)}
+	case reflect.Ptr:
+		return schemaFor(t.Elem(), definitions)
+	case reflect.Struct:
+		ref := &JSONSchema{Ref: "#/definitions/" + t.Name()}
+		if _, ok := definitions[t.Name()]; ok {
+			return ref
+		}
+		schema := &JSONSchema{
+			Type:                 "object",
+			Properties:           make(map[string]*JSONSchema),
+			Required:             schemaRequired[t],
+			AdditionalProperties: false,
+		}
+		// register before the fields are visited, in case the type refers to itself
+		definitions[t.Name()] = schema
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := yamlFieldName(field)
+			if name == "" {
+				continue
+			}
+			schema.Properties[name] = schemaFor(field.Type, definitions)
+		}
+		return ref
+	default:
+		// interface{} accepts any value
+		return &JSONSchema{}
+	}
+}
+
+// yamlFieldName returns the name of a field in .genesis.yml, or "" when the field is not read from it
+func yamlFieldName(field reflect.StructField) string {
+	if field.PkgPath != "" {
+		return ""
+	}
+	tag := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	if tag == "-" {
+		return ""
+	}
+	if tag == "" {
+		return strings.ToLower(field.Name)
+	}
+	return tag
+}
+
+// ValidationError is a problem found in a .genesis.yml file, at the given line and column
+type ValidationError struct {
+	// File is the path of the descriptor in the template repository, when it is known
+	File    string `json:"file,omitempty" yaml:"file,omitempty"`
+	Line    int    `json:"line" yaml:"line"`
+	Column  int    `json:"column" yaml:"column"`
+	Path    string `json:"path" yaml:"path"`
+	Message string `json:"message" yaml:"message"`
+}
+
+func (e ValidationError) Error() string {
+	if e.File != "" {
+		return fmt.Sprintf("%s: line %d, column %d: %s: %s", e.File, e.Line, e.Column, e.Path, e.Message)
+	}
+	return fmt.Sprintf("line %d, column %d: %s: %s", e.Line, e.Column, e.Path, e.Message)
+}
+
+// ValidationErrors are all the problems found in a .genesis.yml file
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%s is invalid:\n%s", genesisFileName, strings.Join(messages, "\n"))
+}
+
+// ValidateGenesisFile checks the content of a .genesis.yml file against the genesis schema. Syntax
+// errors are returned as an error, while schema violations are returned as ValidationErrors.
+func ValidateGenesisFile(content []byte) (ValidationErrors, error) {
+	var document yamlv3.Node
+	err := yamlv3.Unmarshal(content, &document)
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem parsing %s", genesisFileName)
+	}
+	if len(document.Content) == 0 {
+		return ValidationErrors{{Line: 1, Column: 1, Path: "$", Message: "the file is empty"}}, nil
+	}
+
+	schema := GenesisProjectSchema()
+	validator := schemaValidator{definitions: schema.Definitions}
+	validator.validate(document.Content[0], schema, "$")
+	return validator.errors, nil
+}
+
+type schemaValidator struct {
+	definitions map[string]*JSONSchema
+	errors      ValidationErrors
+}
+
+func (v *schemaValidator) report(node *yamlv3.Node, path, format string, args ...interface{}) {
+	v.errors = append(v.errors, ValidationError{Line: node.Line, Column: node.Column, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *schemaValidator) validate(node *yamlv3.Node, schema *JSONSchema, path string) {
+	if schema.Ref != "" {
+		schema = v.definitions[strings.TrimPrefix(schema.Ref, "#/definitions/")]
+	}
+	if node.Kind == yamlv3.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind == yamlv3.ScalarNode && node.Tag == "!!null" {
+		// an empty value leaves the field unset
+		return
+	}
+
+	switch schema.Type {
+	case "":
+		return
+	case "object":
+		if node.Kind != yamlv3.MappingNode {
+			v.report(node, path, "expected a mapping, found %s", describeNode(node))
+			return
+		}
+		v.validateMapping(node, schema, path)
+	case "array":
+		if node.Kind != yamlv3.SequenceNode {
+			v.report(node, path, "expected a list, found %s", describeNode(node))
+			return
+		}
+		for i, item := range node.Content {
+			v.validate(item, schema.Items, fmt.Sprintf("%s[%d]", path, i))
+		}
+	default:
+		if node.Kind != yamlv3.ScalarNode {
+			v.report(node, path, "expected a %s, found %s", schema.Type, describeNode(node))
+			return
+		}
+		switch {
+		case schema.Type == "boolean" && node.Tag != "!!bool":
+			v.report(node, path, "expected true or false, found %q", node.Value)
+		case schema.Type == "integer" && node.Tag != "!!int":
+			v.report(node, path, "expected a whole number, found %q", node.Value)
+		case len(schema.Enum) > 0 && !containsString(schema.Enum, node.Value):
+			v.report(node, path, "%q is not one of %s", node.Value, strings.Join(schema.Enum, ", "))
+		}
+	}
+}
+
+func (v *schemaValidator) validateMapping(node *yamlv3.Node, schema *JSONSchema, path string) {
+	found := make(map[string]bool)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Value == "<<" {
+			// merge keys bring in the fields of an anchored mapping
+			merged := value
+			if merged.Kind == yamlv3.AliasNode {
+				merged = merged.Alias
+			}
+			if merged.Kind == yamlv3.SequenceNode {
+				for _, item := range merged.Content {
+					v.validate(item, schema, path)
+				}
+			} else {
+				v.validate(merged, schema, path)
+			}
+			continue
+		}
+		found[key.Value] = true
+		fieldPath := path + "." + key.Value
+
+		if property, ok := schema.Properties[key.Value]; ok {
+			v.validate(value, property, fieldPath)
+			continue
+		}
+		switch additional := schema.AdditionalProperties.(type) {
+		case *JSONSchema:
+			v.validate(value, additional, fieldPath)
+		default:
+			if suggestion := closestProperty(key.Value, schema.Properties); suggestion != "" {
+				v.report(key, fieldPath, "unknown field %q, did you mean %q?", key.Value, suggestion)
+			} else {
+				v.report(key, fieldPath, "unknown field %q", key.Value)
+			}
+		}
+	}
+	for _, required := range schema.Required {
+		if !found[required] {
+			v.report(node, path, "missing required field %q", required)
+		}
+	}
+}
+
+func describeNode(node *yamlv3.Node) string {
+	switch node.Kind {
+	case yamlv3.MappingNode:
+		return "a mapping"
+	case yamlv3.SequenceNode:
+		return "a list"
+	default:
+		return fmt.Sprintf("%q", node.Value)
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// closestProperty suggests the property that a misspelled field was likely meant to be
+func closestProperty(name string, properties map[string]*JSONSchema) string {
+	candidates := make([]string, 0, len(properties))
+	for property := range properties {
+		candidates = append(candidates, property)
+	}
+	sort.Strings(candidates)
+
+	best, bestDistance := "", 3
+	for _, candidate := range candidates {
+		if strings.EqualFold(candidate, name) {
+			return candidate
+		}
+		if distance := editDistance(strings.ToLower(name), strings.ToLower(candidate)); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(minInt(previous[j]+1, current[j-1]+1), previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
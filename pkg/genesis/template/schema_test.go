@@ -0,0 +1,64 @@
+package template
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenesisProjectSchema_Published(t *testing.T) {
+	published, err := ioutil.ReadFile("../../../schema/genesis.schema.json")
+	assert.Nil(t, err)
+
+	generated, err := MarshalGenesisProjectSchema()
+	assert.Nil(t, err)
+	assert.Equal(t, string(generated), string(published), "regenerate the schema with `template-api validate --print-schema > schema/genesis.schema.json`")
+}
+
+func TestValidateGenesisFile(t *testing.T) {
+	validationErrors, err := ValidateGenesisFile([]byte(`projects:
+  - name: "Test"
+    root: "base"
+    options:
+      - name: "port"
+        requred: true
+        type: "number"
+      - groupName: "General"
+        formfield:
+          type: "TEXT"
+    hooks:
+      post_render:
+        - command: "make"
+          timeout: "soon"
+`))
+	assert.Nil(t, err)
+	assert.Equal(t, ValidationErrors{
+		{Line: 6, Column: 9, Path: "$.projects[0].options[0].requred", Message: `unknown field "requred", did you mean "required"?`},
+		{Line: 7, Column: 15, Path: "$.projects[0].options[0].type", Message: `"number" is not one of string, bool, int, list, map`},
+		{Line: 9, Column: 9, Path: "$.projects[0].options[1].formfield", Message: `unknown field "formfield", did you mean "formField"?`},
+		{Line: 8, Column: 9, Path: "$.projects[0].options[1]", Message: `missing required field "name"`},
+		{Line: 14, Column: 20, Path: "$.projects[0].hooks.post_render[0].timeout", Message: `expected a whole number, found "soon"`},
+	}, validationErrors)
+
+	validationErrors, err = ValidateGenesisFile([]byte("projects:\n  - name: \"Test\"\n    root: \"base\"\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(validationErrors))
+
+	_, err = ValidateGenesisFile([]byte("projects: [\n"))
+	assert.NotNil(t, err, "there should be an error because the file is not valid YAML")
+}
+
+func TestGenesisTemplateApi_RejectsUnknownFields(t *testing.T) {
+	dir := writeTree(t, map[string]string{".genesis.yml": "projects:\n  - name: \"Test\"\n    roots: \"base\"\n"})
+	defer os.RemoveAll(dir)
+
+	_, err := NewGenesisTemplateApi(dir).GetProjectsFromRepo()
+	assert.NotNil(t, err)
+	assert.IsType(t, ValidationErrors{}, err)
+
+	valid, err := NewGenesisTemplateApi(dir).ValidateGenesisProject()
+	assert.False(t, valid)
+	assert.NotNil(t, err)
+}
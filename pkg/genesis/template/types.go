@@ -25,6 +25,8 @@ type Project interface {
 type GenesisProject struct {
 	Projects            []GenesisTemplate `yaml:"projects"`
 	clonedDirectoryPath string
+	// invalidDescriptors are the errors of the .genesis.yml files in subdirectories that could not be loaded
+	invalidDescriptors map[string]error
 }
 
 func (p *GenesisProject) SetClonedDirectoryPath(directoryPath string) error {
@@ -52,10 +54,10 @@ type ProjectTemplate interface {
 	GetRequiredOptions() []Option
 
 	// Set all required options in a key-value map
-	SetValidatedOptions(args map[string]string) error
+	SetValidatedOptions(args OptionValues) error
 
 	// Get the validated options map
-	GetValidatedOptions() (map[string]string, error)
+	GetValidatedOptions() (OptionValues, error)
 
 	// Get the root directory name
 	GetRoot() (string, error)
@@ -65,6 +67,21 @@ type ProjectTemplate interface {
 
 	// Add formFields to groups
 	OrganizeGroups() error
+
+	// Get the hooks declared by the template
+	GetHooks() GenesisHooks
+
+	// Get the layers placed into the root, such as the roots of base templates and the selected modules
+	GetLayers() []Layer
+
+	// Get the directories searched for partials, in order of precedence
+	GetPartialDirectories() []string
+
+	// Get the repository included in the template, if any
+	GetGitRepository() GenesisGitRepository
+
+	// Get the options of the template, including the options of its modules
+	GetOptions() []Option
 }
 
 type Language struct {
@@ -79,11 +96,58 @@ type Runtime struct {
 }
 
 type Option struct {
-	Name      string    `yaml:"name" json:"name"`
-	Default   string    `yaml:"default,omitempty" json:"default,omitempty"`
-	Required  bool      `yaml:"required,omitempty" json:"required,omitempty"`
-	GroupName string    `yaml:"groupName" json:"groupName"`
-	FormField FormField `yaml:"formField,omitempty" json:"formField,omitempty"`
+	Name      string      `yaml:"name" json:"name"`
+	Type      OptionType  `yaml:"type,omitempty" json:"type,omitempty"`
+	Default   interface{} `yaml:"default,omitempty" json:"default,omitempty"`
+	Required  bool        `yaml:"required,omitempty" json:"required,omitempty"`
+	GroupName string      `yaml:"groupName" json:"groupName"`
+	FormField FormField   `yaml:"formField,omitempty" json:"formField,omitempty"`
+	// ShowIf hides the option unless the expression is true, e.g. database != none
+	ShowIf string `yaml:"showIf,omitempty" json:"showIf,omitempty"`
+	// RequiredIf makes the option required when the expression is true
+	RequiredIf string `yaml:"requiredIf,omitempty" json:"requiredIf,omitempty"`
+	// Secret values are redacted from the generation manifest, as are PASSWORD form fields
+	Secret bool `yaml:"secret,omitempty" json:"secret,omitempty"`
+}
+
+// GetShowIf returns the visibility expression of the option, falling back to its form field
+func (o Option) GetShowIf() string {
+	if o.ShowIf != "" {
+		return o.ShowIf
+	}
+	return o.FormField.ShowIf
+}
+
+// GetRequiredIf returns the required expression of the option, falling back to its form field
+func (o Option) GetRequiredIf() string {
+	if o.RequiredIf != "" {
+		return o.RequiredIf
+	}
+	return o.FormField.RequiredIf
+}
+
+// IsVisible reports whether the option applies given the values of the other options
+func (o Option) IsVisible(values OptionValues) (bool, error) {
+	if o.GetShowIf() == "" {
+		return true, nil
+	}
+	visible, err := EvaluateCondition(o.GetShowIf(), values)
+	if err != nil {
+		return false, errors.Wrapf(err, "invalid showIf for option %s", o.Name)
+	}
+	return visible, nil
+}
+
+// IsRequired reports whether the option must be provided given the values of the other options
+func (o Option) IsRequired(values OptionValues) (bool, error) {
+	if o.Required || o.GetRequiredIf() == "" {
+		return o.Required, nil
+	}
+	required, err := EvaluateCondition(o.GetRequiredIf(), values)
+	if err != nil {
+		return false, errors.Wrapf(err, "invalid requiredIf for option %s", o.Name)
+	}
+	return required, nil
 }
 
 // variable replacement
@@ -161,16 +225,24 @@ func ReplaceGenesisVariable(rawKey, rawValue string) (string, error) {
 	return gVar.GetParsedValue()
 }
 
+// filters maps filter names to filter functions
+var filters = map[string]Filter{
+	"upper": UpperCaseFilter,
+	"lower": LowerCaseFilter,
+}
+
+// LookupFilter returns the filter registered under filterKey, if any
+func LookupFilter(filterKey string) (Filter, bool) {
+	filter, ok := filters[strings.ToLower(filterKey)]
+	return filter, ok
+}
+
 // temporary solution for mapping filter names to filter functions
 func GetFilterMap(filterKey string) Filter {
-	switch strings.ToLower(filterKey) {
-	case "upper":
-		return UpperCaseFilter
-	case "lower":
-		return LowerCaseFilter
-	default:
-		return DefaultFilter
+	if filter, ok := LookupFilter(filterKey); ok {
+		return filter
 	}
+	return DefaultFilter
 }
 
 type Filter func(value string) (string, error)
@@ -195,19 +267,26 @@ type FormGroup struct {
 }
 
 type FormField struct {
-	Type                   FormFieldType  `json:"type" yaml:"type"`
-	Label                  string         `json:"label" yaml:"label"`
-	Placeholder            string         `json:"placeholder" yaml:"placeholder"`
-	FormControlName        string         `yaml:"formControlName" json:"formControlName"`
-	Hint                   string         `json:"hint" yaml:"hint"`
-	Icon                   string         `json:"icon" yaml:"icon"`
-	IsCheckedByDefault     bool           `json:"isCheckedByDefault" yaml:"isCheckedByDefault"`
-	OptionsUrl             string         `json:"optionsUrl" yaml:"optionsUrl"`
-	SelectOptions          []SelectOption `json:"selectOptions" yaml:"selectOptions"`
-	Validation             string         `json:"validation" yaml:"validation"`
-	ValidationErrorMessage string         `json:"validationErrorMessage" yaml:"validationErrorMessage"`
-	MaxCharacters          string         `json:"maxCharacters" yaml:"maxCharacters"`
-	ImageButtons           []ImageButton  `yaml:"imageButtons" json:"imageButtons"`
+	Type               FormFieldType `json:"type" yaml:"type"`
+	Label              string        `json:"label" yaml:"label"`
+	Placeholder        string        `json:"placeholder" yaml:"placeholder"`
+	FormControlName    string        `yaml:"formControlName" json:"formControlName"`
+	Hint               string        `json:"hint" yaml:"hint"`
+	Icon               string        `json:"icon" yaml:"icon"`
+	IsCheckedByDefault bool          `json:"isCheckedByDefault" yaml:"isCheckedByDefault"`
+	OptionsUrl         string        `json:"optionsUrl" yaml:"optionsUrl"`
+	// OptionsMapping selects the options from the JSON returned by an http(s) OptionsUrl
+	OptionsMapping OptionsMapping `json:"optionsMapping,omitempty" yaml:"optionsMapping,omitempty"`
+	SelectOptions  []SelectOption `json:"selectOptions" yaml:"selectOptions"`
+	// RestrictToOptions rejects values that are not among the static SelectOptions. Values of fields with an
+	// OptionsUrl are always checked.
+	RestrictToOptions      bool          `json:"restrictToOptions,omitempty" yaml:"restrictToOptions,omitempty"`
+	Validation             string        `json:"validation" yaml:"validation"`
+	ValidationErrorMessage string        `json:"validationErrorMessage" yaml:"validationErrorMessage"`
+	MaxCharacters          string        `json:"maxCharacters" yaml:"maxCharacters"`
+	ImageButtons           []ImageButton `yaml:"imageButtons" json:"imageButtons"`
+	ShowIf                 string        `yaml:"showIf,omitempty" json:"showIf,omitempty"`
+	RequiredIf             string        `yaml:"requiredIf,omitempty" json:"requiredIf,omitempty"`
 }
 
 type SelectOption struct {
@@ -269,18 +348,82 @@ const (
 
 type GenesisTemplate struct {
 	Name                string               `yaml:"name" json:"name"`
+	Extends             string               `yaml:"extends,omitempty" json:"extends,omitempty"`
 	Root                string               `yaml:"root" json:"root"`
 	Language            Language             `yaml:"language" json:"language"`
 	Runtime             Runtime              `yaml:"runtime" json:"runtime"`
 	GitRepository       GenesisGitRepository `yaml:"git,omitempty" json:"git,omitempty"`
 	Options             []Option             `yaml:"options,omitempty" json:"options,omitempty"`
 	FormGroups          []FormGroup          `yaml:"formGroups" json:"formGroups"`
-	validatedOptionsMap map[string]string
+	Rules               []ValidationRule     `yaml:"rules,omitempty" json:"rules,omitempty"`
+	Hooks               GenesisHooks         `yaml:"hooks,omitempty" json:"hooks,omitempty"`
+	Modules             []GenesisModule      `yaml:"modules,omitempty" json:"modules,omitempty"`
+	validatedOptionsMap OptionValues
+	layers              []Layer
+	moduleLayers        []Layer
+	partialDirectories  []string
+	// descriptor is the path of the .genesis.yml declaring the template, relative to the repository
+	descriptor string
+	// namespace is the directory of the descriptor, which prefixes the template name
+	namespace string
+	// optionsResolver resolves the OptionsUrl of form fields, if any
+	optionsResolver OptionsResolver
 }
 
+// ValidationRule is a constraint across options that is checked before a project is generated.
+// When is optional and limits the rule to matching requests, e.g.
+//
+//	when: runtime == lambda
+//	assert: framework != spring
+//	message: "spring is not supported on lambda"
+type ValidationRule struct {
+	Name    string `yaml:"name,omitempty" json:"name,omitempty"`
+	When    string `yaml:"when,omitempty" json:"when,omitempty"`
+	Assert  string `yaml:"assert" json:"assert"`
+	Message string `yaml:"message" json:"message"`
+}
+
+// Check evaluates the rule and returns false when the options violate it
+func (r ValidationRule) Check(values OptionValues) (bool, error) {
+	if r.When != "" {
+		applies, err := EvaluateCondition(r.When, values)
+		if err != nil {
+			return false, errors.Wrapf(err, "invalid when for rule %s", r.GetMessage())
+		}
+		if !applies {
+			return true, nil
+		}
+	}
+	ok, err := EvaluateCondition(r.Assert, values)
+	if err != nil {
+		return false, errors.Wrapf(err, "invalid assert for rule %s", r.GetMessage())
+	}
+	return ok, nil
+}
+
+// GetMessage returns the message reported when the rule fails
+func (r ValidationRule) GetMessage() string {
+	if r.Message != "" {
+		return r.Message
+	}
+	if r.Name != "" {
+		return r.Name
+	}
+	return r.Assert
+}
+
+// GenesisGitRepository is a repository whose files are included in the template, such as shared
+// platform assets. Domain is the BitBucket project key, GitHub organization, GitLab group or Gitea
+// owner, and Ref is the tag or branch to fetch, the default branch if empty. The files under Source, or the whole repository, are mounted at Path in the root.
 type GenesisGitRepository struct {
-	Domain string `yaml:"domain" json:"domain"`
-	Name   string `yaml:"name" json:"name"`
+	Domain   string `yaml:"domain" json:"domain"`
+	Name     string `yaml:"name" json:"name"`
+	Provider string `yaml:"provider,omitempty" json:"provider,omitempty"`
+	Ref      string `yaml:"ref,omitempty" json:"ref,omitempty"`
+	Source   string `yaml:"source,omitempty" json:"source,omitempty"`
+	Path     string `yaml:"path,omitempty" json:"path,omitempty"`
+	// Host of a configured GitHub instance, such as a GitHub Enterprise Server, empty for the default instance
+	Host string `yaml:"host,omitempty" json:"host,omitempty"`
 }
 
 func (p *GenesisTemplate) OrganizeGroups() error {
@@ -291,9 +434,13 @@ func (p *GenesisTemplate) OrganizeGroups() error {
 		if group.FormFields == nil {
 			p.FormGroups[i].FormFields = make([]FormField, 0)
 		}
-		for _, option := range p.Options {
+		for _, option := range p.allOptions() {
 			if strings.ToLower(option.GroupName) == strings.ToLower(group.DisplayName) {
-				p.FormGroups[i].FormFields = append(p.FormGroups[i].FormFields, option.FormField)
+				formField, err := option.getConditionalFormField(p.optionsResolver)
+				if err != nil {
+					return err
+				}
+				p.FormGroups[i].FormFields = append(p.FormGroups[i].FormFields, formField)
 			}
 		}
 		if strings.ToLower(p.Runtime.GroupName) == strings.ToLower(group.DisplayName) {
@@ -317,6 +464,34 @@ func (p *GenesisTemplate) OrganizeGroups() error {
 	return nil
 }
 
+// getConditionalFormField returns the form field of the option carrying the option's showIf and
+// requiredIf expressions, so the form and the server-side validation agree
+func (o Option) getConditionalFormField(resolver OptionsResolver) (FormField, error) {
+	formField := o.FormField
+	formField.ShowIf = o.GetShowIf()
+	formField.RequiredIf = o.GetRequiredIf()
+	for _, expression := range []string{formField.ShowIf, formField.RequiredIf} {
+		if expression == "" {
+			continue
+		}
+		if _, err := ParseExpression(expression); err != nil {
+			return FormField{}, errors.Wrapf(err, "invalid condition for option %s", o.Name)
+		}
+	}
+	// resolve the options on the server, so that the form does not call arbitrary urls. When the
+	// url depends on other values, or cannot be resolved, it is left to the form.
+	if formField.OptionsUrl != "" {
+		options, ok, err := resolveFieldOptions(formField, resolver, nil)
+		if err != nil {
+			fmt.Printf("unable to resolve the options of %s, leaving them to the form. Err: %+v\n", o.Name, err)
+		} else if ok {
+			formField.SelectOptions = options
+			formField.OptionsUrl = ""
+		}
+	}
+	return formField, nil
+}
+
 func (p *GenesisTemplate) GetRoot() (string, error) {
 	if p.Root != "" {
 		return p.Root, nil
@@ -325,25 +500,46 @@ func (p *GenesisTemplate) GetRoot() (string, error) {
 	return "", ErrRootUndefined
 }
 
+// GetRequiredOptions returns the options, including those of modules, that are required when the other options have
+// their defaults. Options hidden by the defaults are not required, as in SetValidatedOptions. When the defaults or the
+// conditions cannot be evaluated, only the options marked as required are returned.
 func (p *GenesisTemplate) GetRequiredOptions() []Option {
-	var required []Option
+	options := p.allOptions()
+	var scope OptionValues
+	var hidden map[string]bool
+	defaults, err := p.promptScope(nil)
+	if err == nil {
+		scope, hidden, err = conditionScope(options, defaults, defaults)
+	}
 
-	for _, option := range p.Options {
-		if option.Required {
+	var required []Option
+	for _, option := range options {
+		isRequired := option.Required
+		if err == nil {
+			// an invalid requiredIf is reported by SetValidatedOptions, and does not require the option here
+			isRequired, _ = option.IsRequired(scope)
+			isRequired = isRequired && !hidden[option.Name]
+		}
+		if isRequired {
 			required = append(required, option)
 		}
 	}
-
 	return required
 }
 
-func (p *GenesisTemplate) SetValidatedOptions(args map[string]string) error {
+func (p *GenesisTemplate) SetValidatedOptions(args OptionValues) error {
 	validArgs, err := p.validateOptions(args)
 	if err != nil {
 		return err
 	}
 
+	moduleLayers, err := p.selectModules(validArgs)
+	if err != nil {
+		return err
+	}
+
 	p.validatedOptionsMap = validArgs
+	p.moduleLayers = moduleLayers
 	return nil
 }
 
@@ -351,26 +547,147 @@ func (p *GenesisTemplate) GetName() string {
 	return p.Name
 }
 
-func (p *GenesisTemplate) GetValidatedOptions() (map[string]string, error) {
+func (p *GenesisTemplate) GetHooks() GenesisHooks {
+	return p.Hooks
+}
+
+func (p *GenesisTemplate) GetOptions() []Option {
+	return p.allOptions()
+}
+
+func (p *GenesisTemplate) GetGitRepository() GenesisGitRepository {
+	return p.GitRepository
+}
+
+func (p *GenesisTemplate) GetLayers() []Layer {
+	return append(append([]Layer{}, p.layers...), p.moduleLayers...)
+}
+
+func (p *GenesisTemplate) GetPartialDirectories() []string {
+	return p.partialDirectories
+}
+
+func (p *GenesisTemplate) GetValidatedOptions() (OptionValues, error) {
 	return p.validatedOptionsMap, nil
 }
 
-func (p *GenesisTemplate) validateOptions(args map[string]string) (map[string]string, error) {
-	validArgs := make(map[string]string, len(args))
-	for _, option := range p.Options {
-		var defaultVal string
-		if option.Default != "" {
-			defaultVal = option.Default
+func (p *GenesisTemplate) validateOptions(args OptionValues) (OptionValues, error) {
+	// parse all values first so that showIf and requiredIf can reference any option
+	options := p.allOptions()
+	values := make(OptionValues, len(options))
+	defaults := make(OptionValues, len(options))
+	for _, option := range options {
+		values[option.Name] = nil
+		if option.Default != nil && option.Default != "" {
+			parsed, err := option.ParseValue(option.Default)
+			if err != nil {
+				return make(OptionValues, 0), errors.Wrapf(err, "Invalid default")
+			}
+			defaults[option.Name] = parsed
+			values[option.Name] = parsed
+		}
+		if val, ok := args[option.Name]; ok && val != "" {
+			parsed, err := option.ParseValue(val)
+			if err != nil {
+				return make(OptionValues, 0), errors.Wrapf(err, "Invalid request")
+			}
+			values[option.Name] = parsed
+		}
+	}
+
+	scope, hidden, err := conditionScope(options, values, defaults)
+	if err != nil {
+		return make(OptionValues, 0), err
+	}
+
+	validArgs := make(OptionValues, len(args))
+	for _, option := range options {
+		if hidden[option.Name] {
+			// hidden options ignore provided values, but keep their defaults for rendering
+			if defaultVal, ok := defaults[option.Name]; ok {
+				validArgs[option.Name] = defaultVal
+			}
+			continue
+		}
+
+		required, err := option.IsRequired(scope)
+		if err != nil {
+			return make(OptionValues, 0), err
 		}
 		val, ok := args[option.Name]
-		if !ok && option.Required {
-			return make(map[string]string, 0), errors.Errorf("Invalid request. %s is a required parameter and was not provided.", option.Name)
-		} else if ok {
-			validArgs[option.Name] = val
-		} else if defaultVal != "" {
-			validArgs[option.Name] = defaultVal
+		if (!ok || val == "") && required {
+			return make(OptionValues, 0), errors.Errorf("Invalid request. %s is a required parameter and was not provided.", option.Name)
+		} else if values[option.Name] != nil {
+			err = option.checkSelectedValue(values[option.Name], p.optionsResolver, scope)
+			if err != nil {
+				return make(OptionValues, 0), err
+			}
+			validArgs[option.Name] = values[option.Name]
 		}
 	}
 
+	err = p.checkRules(scope)
+	if err != nil {
+		return make(OptionValues, 0), err
+	}
+
 	return validArgs, nil
 }
+
+// conditionScope returns the values that conditions and rules are evaluated against, in which hidden options have
+// their default, as they are rendered, along with the hidden options. Visibility is re-evaluated until it is stable,
+// so that an option shown only with a hidden option is hidden as well.
+func conditionScope(options []Option, values, defaults OptionValues) (OptionValues, map[string]bool, error) {
+	hidden := make(map[string]bool, len(options))
+	for pass := 0; pass <= len(options); pass++ {
+		scope := make(OptionValues, len(values))
+		for key, value := range values {
+			scope[key] = value
+			if hidden[key] {
+				scope[key] = defaults[key]
+			}
+		}
+
+		changed := false
+		for _, option := range options {
+			visible, err := option.IsVisible(scope)
+			if err != nil {
+				return nil, nil, err
+			}
+			if hidden[option.Name] == visible {
+				hidden[option.Name] = !visible
+				changed = true
+			}
+		}
+		if !changed {
+			return scope, hidden, nil
+		}
+	}
+	return nil, nil, errors.New("showIf conditions of the options depend on each other in a cycle")
+}
+
+// checkRules evaluates every rule against scope and reports all violations at once
+func (p *GenesisTemplate) checkRules(scope OptionValues) error {
+	var violations []string
+	for _, rule := range p.Rules {
+		ok, err := rule.Check(scope)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			violations = append(violations, rule.GetMessage())
+		}
+	}
+	if len(violations) > 0 {
+		return errors.Errorf("Invalid request. %s", strings.Join(violations, "; "))
+	}
+	return nil
+}
+
+// getDescriptor returns the path of the .genesis.yml that declares the template
+func (p *GenesisTemplate) getDescriptor() string {
+	if p.descriptor == "" {
+		return genesisFileName
+	}
+	return p.descriptor
+}
@@ -0,0 +1,158 @@
+package template
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	conflictOursLabel   = "current"
+	conflictTheirsLabel = "template"
+)
+
+// UpdateResult lists the files changed when a generated project is updated to a newer template version
+type UpdateResult struct {
+	Updated []string `json:"updated,omitempty" yaml:"updated,omitempty"`
+	Added   []string `json:"added,omitempty" yaml:"added,omitempty"`
+	Removed []string `json:"removed,omitempty" yaml:"removed,omitempty"`
+	// Conflicts are files changed both in the project and in the template. Text files contain
+	// conflict markers, binary files and files deleted in the project are left as they were.
+	Conflicts []string `json:"conflicts,omitempty" yaml:"conflicts,omitempty"`
+}
+
+// HasConflicts reports whether any file needs to be resolved by hand
+func (r UpdateResult) HasConflicts() bool {
+	return len(r.Conflicts) > 0
+}
+
+// MergeTemplateUpdate applies the changes between the previous and the next rendering of a template
+// to the current state of a generated project, using a three-way merge for every file. The .git and
+// .genesis directories of the project are left untouched.
+func MergeTemplateUpdate(previous, next, current string) (UpdateResult, error) {
+	var result UpdateResult
+	previousFiles, err := listUpdateFiles(previous)
+	if err != nil {
+		return result, err
+	}
+	nextFiles, err := listUpdateFiles(next)
+	if err != nil {
+		return result, err
+	}
+	currentFiles, err := listUpdateFiles(current)
+	if err != nil {
+		return result, err
+	}
+
+	paths := make([]string, 0, len(previousFiles)+len(nextFiles))
+	for path := range previousFiles {
+		paths = append(paths, path)
+	}
+	for path := range nextFiles {
+		if !previousFiles[path] {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		var base, theirs, ours []byte
+		if previousFiles[path] {
+			if base, err = readUpdateFile(previous, path); err != nil {
+				return result, err
+			}
+		}
+		if nextFiles[path] {
+			if theirs, err = readUpdateFile(next, path); err != nil {
+				return result, err
+			}
+		}
+		if currentFiles[path] {
+			if ours, err = readUpdateFile(current, path); err != nil {
+				return result, err
+			}
+		}
+
+		switch {
+		case !nextFiles[path]:
+			// removed from the template, remove it from the project unless it was changed there
+			if !currentFiles[path] {
+				continue
+			}
+			if !bytes.Equal(ours, base) {
+				result.Conflicts = append(result.Conflicts, path)
+				continue
+			}
+			err = os.Remove(filepath.Join(current, path))
+			if err != nil {
+				return result, errors.Wrapf(err, "unable to remove file %s", path)
+			}
+			result.Removed = append(result.Removed, path)
+		case !currentFiles[path]:
+			if previousFiles[path] {
+				// deleted in the project, only a conflict when the template changed it
+				if !bytes.Equal(base, theirs) {
+					result.Conflicts = append(result.Conflicts, path)
+				}
+				continue
+			}
+			info, err := os.Stat(filepath.Join(next, path))
+			if err != nil {
+				return result, errors.Wrapf(err, "unable to stat file %s", path)
+			}
+			destination := filepath.Join(current, path)
+			err = os.MkdirAll(filepath.Dir(destination), os.ModePerm)
+			if err != nil {
+				return result, errors.Wrapf(err, "unable to run MkdirAll on path %s", filepath.Dir(destination))
+			}
+			err = copyFileWithMode(filepath.Join(next, path), destination, info.Mode())
+			if err != nil {
+				return result, err
+			}
+			result.Added = append(result.Added, path)
+		default:
+			merged, conflict := Merge3(base, ours, theirs, conflictOursLabel, conflictTheirsLabel)
+			if conflict {
+				result.Conflicts = append(result.Conflicts, path)
+			}
+			if bytes.Equal(merged, ours) {
+				continue
+			}
+			err = ioutil.WriteFile(filepath.Join(current, path), merged, 0644)
+			if err != nil {
+				return result, errors.Wrapf(err, "unable to write file to path %s", path)
+			}
+			if !conflict {
+				result.Updated = append(result.Updated, path)
+			}
+		}
+	}
+	return result, nil
+}
+
+// listUpdateFiles lists the files of a project that take part in an update
+func listUpdateFiles(directory string) (map[string]bool, error) {
+	files, err := listFiles(directory)
+	if err != nil {
+		return nil, err
+	}
+	for path := range files {
+		if strings.HasPrefix(path, manifestDirectoryName+"/") {
+			delete(files, path)
+		}
+	}
+	return files, nil
+}
+
+func readUpdateFile(directory, path string) ([]byte, error) {
+	content, err := ioutil.ReadFile(filepath.Join(directory, path))
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read file from path %s", path)
+	}
+	return content, nil
+}
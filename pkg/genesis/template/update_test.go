@@ -0,0 +1,72 @@
+package template
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge3(t *testing.T) {
+	base := []byte("a\nb\nc\nd\n")
+
+	merged, conflict := Merge3(base, []byte("a\nB\nc\nd\n"), []byte("a\nb\nc\nD\n"), "current", "template")
+	assert.False(t, conflict)
+	assert.Equal(t, "a\nB\nc\nD\n", string(merged))
+
+	merged, conflict = Merge3(base, []byte("a\nb\nc\nd\ne\n"), []byte("x\na\nb\nc\nd\n"), "current", "template")
+	assert.False(t, conflict)
+	assert.Equal(t, "x\na\nb\nc\nd\ne\n", string(merged))
+
+	merged, conflict = Merge3(base, []byte("a\nmine\nc\nd\n"), []byte("a\ntheirs\nc\nd\n"), "current", "template")
+	assert.True(t, conflict)
+	assert.Equal(t, "a\n<<<<<<< current\nmine\n=======\ntheirs\n>>>>>>> template\nc\nd\n", string(merged))
+
+	merged, conflict = Merge3(base, []byte("a\nc\nd\n"), []byte("a\nc\nd\n"), "current", "template")
+	assert.False(t, conflict)
+	assert.Equal(t, "a\nc\nd\n", string(merged))
+}
+
+func TestMergeTemplateUpdate(t *testing.T) {
+	previous := writeTree(t, map[string]string{
+		"main.go":     "package main\n\nfunc main() {\n}\n",
+		"README.md":   "# svc\n",
+		"old.txt":     "old\n",
+		"edited.txt":  "one\n",
+		"deleted.txt": "one\n",
+	})
+	defer os.RemoveAll(previous)
+	next := writeTree(t, map[string]string{
+		"main.go":     "package main\n\nimport \"fmt\"\n\nfunc main() {\n}\n",
+		"README.md":   "# svc\n\nGenerated.\n",
+		"new/file.go": "package new\n",
+		"edited.txt":  "two\n",
+		"deleted.txt": "two\n",
+	})
+	defer os.RemoveAll(next)
+	current := writeTree(t, map[string]string{
+		"main.go":              "package main\n\nfunc main() {\n\tserve()\n}\n",
+		"README.md":            "# svc\n",
+		"old.txt":              "old\n",
+		"edited.txt":           "mine\n",
+		".genesis/answers.yml": "template: {}\n",
+	})
+	defer os.RemoveAll(current)
+
+	result, err := MergeTemplateUpdate(previous, next, current)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"README.md", "main.go"}, result.Updated)
+	assert.Equal(t, []string{"new/file.go"}, result.Added)
+	assert.Equal(t, []string{"old.txt"}, result.Removed)
+	assert.Equal(t, []string{"deleted.txt", "edited.txt"}, result.Conflicts)
+	assert.True(t, result.HasConflicts())
+
+	assert.Equal(t, "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tserve()\n}\n", readFile(t, current+"main.go"))
+	assert.Equal(t, "package new\n", readFile(t, current+"new/file.go"))
+	assert.Equal(t, "<<<<<<< current\nmine\n=======\ntwo\n>>>>>>> template\n", readFile(t, current+"edited.txt"))
+	assert.Equal(t, "template: {}\n", readFile(t, current+".genesis/answers.yml"))
+	_, err = os.Stat(current + "old.txt")
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(current + "deleted.txt")
+	assert.True(t, os.IsNotExist(err))
+}